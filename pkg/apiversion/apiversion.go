@@ -0,0 +1,127 @@
+// Package apiversion seleciona a versão do contrato das respostas da API. As rotas versionadas
+// (/v1/..., /v2/...) fixam a versão. Nas rotas sem versão, a versão é escolhida no header
+// X-API-Version e, sem o header, é utilizada a v1 com o header Deprecation, indicando a rota
+// versionada no header Link. Os contratos ficam nos pacotes v1 e v2, convertidos a partir dos
+// tipos do pacote domain, então o domínio pode evoluir sem alterar as respostas das versões.
+package apiversion
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion/v1"
+	v2 "github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion/v2"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+)
+
+// Header utilizado na seleção da versão nas rotas sem versão e enviado em todas as respostas
+const Header = "X-API-Version"
+
+// Versões disponíveis, utilizadas também como prefixo das rotas
+const (
+	V1 = "v1"
+	V2 = "v2"
+)
+
+// Versão das rotas sem versão quando o header não é informado
+const Default = V1
+
+// Data padrão a partir da qual as rotas sem versão, sem o header X-API-Version, são obsoletas:
+// a data de publicação da v2. Os serviços podem informar outra data na variável API_DEPRECATION_DATE.
+var DefaultDeprecation = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+type contextKey struct{}
+
+// Função que retorna as versões disponíveis
+func Versions() []string {
+	return []string{V1, V2}
+}
+
+// Função que interpreta a versão informada no header. São aceitos o número (2) e o nome (v2),
+// sem diferenciar maiúsculas.
+func Parse(value string) (string, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if !strings.HasPrefix(value, "v") {
+		value = "v" + value
+	}
+	for _, version := range Versions() {
+		if value == version {
+			return version, true
+		}
+	}
+	return "", false
+}
+
+// Função que retorna o contexto com a versão informada
+func WithVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, contextKey{}, version)
+}
+
+// Função que retorna a versão do contexto, ou o Default quando não há versão no contexto
+func Version(ctx context.Context) string {
+	if version, ok := ctx.Value(contextKey{}).(string); ok {
+		return version
+	}
+	return Default
+}
+
+// Middleware das rotas versionadas. A versão da rota tem prioridade sobre o header X-API-Version.
+func Fixed(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(Header, version)
+			next.ServeHTTP(w, r.WithContext(WithVersion(r.Context(), version)))
+		})
+	}
+}
+
+// Middleware das rotas sem versão, obsoletas a partir da DefaultDeprecation
+func Middleware(next http.Handler) http.Handler {
+	return Unversioned(DefaultDeprecation)(next)
+}
+
+// Middleware das rotas sem versão. A versão é escolhida no header X-API-Version; sem o header,
+// é utilizada a versão padrão e a resposta informa que a rota é obsoleta desde a data informada
+// (RFC 9745) e a rota versionada equivalente. Uma versão desconhecida é respondida com 400.
+// Sem data, é utilizada a DefaultDeprecation.
+func Unversioned(deprecated time.Time) func(http.Handler) http.Handler {
+	if deprecated.IsZero() {
+		deprecated = DefaultDeprecation
+	}
+	deprecation := "@" + strconv.FormatInt(deprecated.Unix(), 10)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", Header)
+			value := r.Header.Get(Header)
+			if value == "" {
+				w.Header().Set("Deprecation", deprecation)
+				w.Header().Set("Link", "</"+Default+r.URL.Path+`>; rel="successor-version"`)
+				Fixed(Default)(next).ServeHTTP(w, r)
+				return
+			}
+			version, ok := Parse(value)
+			if !ok {
+				problem.Write(r.Context(), w, r, problem.BadRequest("").Detailf("the %s header must be one of %s", Header, strings.Join(Versions(), ", ")))
+				return
+			}
+			Fixed(version)(next).ServeHTTP(w, r)
+		})
+	}
+}
+
+// Função que interpreta a data de obsolescência no formato AAAA-MM-DD, em UTC
+func ParseDeprecation(value string) (time.Time, error) {
+	return time.Parse(time.DateOnly, strings.TrimSpace(value))
+}
+
+// Função que converte a temperatura no contrato da versão do contexto
+func ClimaCidade(ctx context.Context, clima *domain.ClimaCidade) any {
+	if Version(ctx) == V2 {
+		return v2.FromDomain(clima)
+	}
+	return v1.FromDomain(clima)
+}
@@ -0,0 +1,92 @@
+package apiversion
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion/v1"
+	v2 "github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion/v2"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+)
+
+func TestParse(t *testing.T) {
+	testes := map[string]string{"1": V1, "v1": V1, "V2": V2, " 2 ": V2, "3": "", "": "", "latest": ""}
+	for valor, esperado := range testes {
+		version, ok := Parse(valor)
+		assert.Equal(t, esperado, version, valor)
+		assert.Equal(t, esperado != "", ok, valor)
+	}
+}
+
+// Nas rotas sem versão, a versão vem do header; sem o header, a rota é obsoleta
+func TestMiddleware(t *testing.T) {
+	var versao string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versao = Version(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cep?cep=32450000", nil))
+	assert.Equal(t, V1, versao)
+	assert.Equal(t, V1, w.Header().Get(Header))
+	assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+	assert.Equal(t, `</v1/cep>; rel="successor-version"`, w.Header().Get("Link"))
+	assert.Equal(t, Header, w.Header().Get("Vary"))
+
+	req := httptest.NewRequest(http.MethodGet, "/cep", nil)
+	req.Header.Set(Header, "2")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, V2, versao)
+	assert.Equal(t, V2, w.Header().Get(Header))
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	versao = ""
+	req.Header.Set(Header, "v3")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "the X-API-Version header must be one of v1, v2")
+	assert.Empty(t, versao)
+}
+
+// A data de obsolescência pode ser configurada
+func TestUnversioned(t *testing.T) {
+	deprecated, err := ParseDeprecation("2027-01-01")
+	require.NoError(t, err)
+	handler := Unversioned(deprecated)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cep", nil))
+	assert.Equal(t, "@1798761600", w.Header().Get("Deprecation"))
+
+	handler = Unversioned(time.Time{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cep", nil))
+	assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+
+	_, err = ParseDeprecation("19/10/2026")
+	assert.Error(t, err)
+}
+
+// Nas rotas versionadas, a versão da rota tem prioridade sobre o header
+func TestFixed(t *testing.T) {
+	var clima any
+	handler := Fixed(V2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clima = ClimaCidade(r.Context(), &domain.ClimaCidade{Cidade: "Ibirité"})
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/v2/cep", nil)
+	req.Header.Set(Header, V1)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.IsType(t, &v2.ClimaCidade{}, clima)
+	assert.Equal(t, V2, w.Header().Get(Header))
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	assert.IsType(t, &v1.ClimaCidade{}, ClimaCidade(req.Context(), &domain.ClimaCidade{}))
+}
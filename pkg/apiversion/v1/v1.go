@@ -0,0 +1,105 @@
+// Package v1 contém o contrato JSON da versão 1 da API (/v1/cep no service-a e /v1/{cep} no
+// service-b), que é o formato original das respostas. Os campos não devem ser alterados: as
+// mudanças no contrato entram em uma nova versão.
+package v1
+
+import (
+	"strconv"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Struct da resposta com o valor das temperaturas
+type ClimaCidade struct {
+	Cidade   string    `json:"city"`
+	TempC    float64   `json:"temp_C"`
+	TempF    float64   `json:"temp_F"`
+	TempK    float64   `json:"temp_K"`
+	Endereco *Endereco `json:"address,omitempty"`
+	Condicao string    `json:"condition,omitempty"`
+	Consenso *Consenso `json:"consensus,omitempty"`
+}
+
+// Struct com o endereço do CEP consultado
+type Endereco struct {
+	Cep        string `json:"cep"`
+	Logradouro string `json:"street,omitempty"`
+	Bairro     string `json:"neighborhood,omitempty"`
+	Cidade     string `json:"city"`
+	Uf         string `json:"state"`
+	Pais       string `json:"country,omitempty"`
+}
+
+// Struct com o consenso entre os provedores de clima consultados
+type Consenso struct {
+	Estrategia  string            `json:"strategy"`
+	Provedores  []LeituraProvedor `json:"providers"`
+	Diferenca   float64           `json:"spread_C"`
+	Divergencia bool              `json:"disagreement"`
+}
+
+// Struct com a leitura de um provedor de clima
+type LeituraProvedor struct {
+	Provedor string   `json:"provider"`
+	TempC    *float64 `json:"temp_C,omitempty"`
+	Erro     string   `json:"error,omitempty"`
+}
+
+// Função que converte a temperatura do domínio no contrato da v1
+func FromDomain(c *domain.ClimaCidade) *ClimaCidade {
+	resp := &ClimaCidade{
+		Cidade:   c.Cidade,
+		TempC:    c.TempC,
+		TempF:    c.TempF,
+		TempK:    c.TempK,
+		Condicao: c.Condicao,
+	}
+	if e := c.Endereco; e != nil {
+		resp.Endereco = &Endereco{Cep: e.Cep, Logradouro: e.Logradouro, Bairro: e.Bairro, Cidade: e.Cidade, Uf: e.Uf, Pais: e.Pais}
+	}
+	if consenso := c.Consenso; consenso != nil {
+		resp.Consenso = &Consenso{Estrategia: consenso.Estrategia, Diferenca: consenso.Diferenca, Divergencia: consenso.Divergencia}
+		for _, leitura := range consenso.Provedores {
+			resp.Consenso.Provedores = append(resp.Consenso.Provedores, LeituraProvedor{Provedor: leitura.Provedor, TempC: leitura.TempC, Erro: leitura.Erro})
+		}
+	}
+	return resp
+}
+
+// Função que representa a temperatura em CSV, com uma única linha. O consenso não é incluído.
+func (c *ClimaCidade) MarshalCSV() ([]string, [][]string) {
+	header := []string{"cep", "country", "city", "state", "temp_C", "temp_F", "temp_K", "condition"}
+	var endereco Endereco
+	if c.Endereco != nil {
+		endereco = *c.Endereco
+	}
+	formatFloat := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return header, [][]string{{endereco.Cep, endereco.Pais, c.Cidade, endereco.Uf, formatFloat(c.TempC), formatFloat(c.TempF), formatFloat(c.TempK), c.Condicao}}
+}
+
+// Função que converte a temperatura na mensagem protobuf do contrato gRPC, que segue a v1. O
+// consenso não faz parte da mensagem.
+func (c *ClimaCidade) ToProto() proto.Message {
+	resp := &pb.ClimaCidade{
+		City:      c.Cidade,
+		TempC:     c.TempC,
+		TempF:     c.TempF,
+		TempK:     c.TempK,
+		Condition: c.Condicao,
+	}
+	if endereco := c.Endereco; endereco != nil {
+		resp.Address = &pb.Endereco{
+			Cep:          endereco.Cep,
+			Street:       endereco.Logradouro,
+			Neighborhood: endereco.Bairro,
+			City:         endereco.Cidade,
+			State:        endereco.Uf,
+			Country:      endereco.Pais,
+		}
+	}
+	return resp
+}
@@ -0,0 +1,48 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+)
+
+// O JSON da v1 é o contrato original das respostas e não pode mudar
+func TestContratoJSON(t *testing.T) {
+	tempC := 28.0
+	testes := []struct {
+		nome     string
+		clima    *domain.ClimaCidade
+		esperado string
+	}{
+		{
+			"somente temperaturas",
+			&domain.ClimaCidade{Cidade: "Ibirité", TempC: 28.5, TempF: 83.3, TempK: 301.5},
+			`{"city":"Ibirité","temp_C":28.5,"temp_F":83.3,"temp_K":301.5}`,
+		},
+		{
+			"completo",
+			&domain.ClimaCidade{
+				Cidade: "Ibirité", TempC: 28.5, TempF: 83.3, TempK: 301.5, Condicao: "Sunny",
+				Endereco: &domain.Endereco{Cep: "32450-000", Logradouro: "Rua A", Bairro: "Centro", Cidade: "Ibirité", Uf: "MG", Pais: "BR"},
+				Consenso: &domain.Consenso{Estrategia: "median", Diferenca: 1, Provedores: []domain.LeituraProvedor{
+					{Provedor: "weatherapi", TempC: &tempC},
+					{Provedor: "open-meteo", Erro: "open-meteo is unavailable"},
+				}},
+			},
+			`{"city":"Ibirité","temp_C":28.5,"temp_F":83.3,"temp_K":301.5,` +
+				`"address":{"cep":"32450-000","street":"Rua A","neighborhood":"Centro","city":"Ibirité","state":"MG","country":"BR"},` +
+				`"condition":"Sunny",` +
+				`"consensus":{"strategy":"median","providers":[{"provider":"weatherapi","temp_C":28},{"provider":"open-meteo","error":"open-meteo is unavailable"}],"spread_C":1,"disagreement":false}}`,
+		},
+	}
+	for _, tt := range testes {
+		t.Run(tt.nome, func(t *testing.T) {
+			data, err := json.Marshal(FromDomain(tt.clima))
+			require.NoError(t, err)
+			assert.Equal(t, tt.esperado, string(data))
+		})
+	}
+}
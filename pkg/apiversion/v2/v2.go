@@ -0,0 +1,97 @@
+// Package v2 contém o contrato JSON da versão 2 da API (/v2/cep no service-a e /v2/{cep} no
+// service-b). Em relação à v1, a localização, as temperaturas nas três unidades, as condições
+// do tempo e as leituras dos provedores são agrupadas em objetos, e as unidades são nomeadas
+// por extenso (celsius, fahrenheit e kelvin).
+package v2
+
+import (
+	"strconv"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+)
+
+// Struct da resposta com o clima da localização do código postal
+type ClimaCidade struct {
+	Location    Location    `json:"location"`
+	Temperature Temperature `json:"temperature"`
+	// Ausente quando o provedor de clima não informa a condição do tempo
+	Conditions *Conditions `json:"conditions,omitempty"`
+	// Presente somente quando a temperatura combina as leituras de vários provedores
+	Sources *Sources `json:"sources,omitempty"`
+}
+
+// Struct com a localização do código postal. Sem o endereço, somente a cidade é informada.
+type Location struct {
+	PostalCode   string `json:"postal_code,omitempty"`
+	Country      string `json:"country,omitempty"`
+	State        string `json:"state,omitempty"`
+	City         string `json:"city"`
+	Neighborhood string `json:"neighborhood,omitempty"`
+	Street       string `json:"street,omitempty"`
+}
+
+// Struct com a temperatura em cada unidade
+type Temperature struct {
+	Celsius    float64 `json:"celsius"`
+	Fahrenheit float64 `json:"fahrenheit"`
+	Kelvin     float64 `json:"kelvin"`
+}
+
+// Struct com as condições do tempo, no idioma negociado no Accept-Language
+type Conditions struct {
+	Description string `json:"description"`
+}
+
+// Struct com as leituras dos provedores de clima e a estratégia de combinação
+type Sources struct {
+	Strategy      string     `json:"strategy"`
+	SpreadCelsius float64    `json:"spread_celsius"`
+	Disagreement  bool       `json:"disagreement"`
+	Providers     []Provider `json:"providers"`
+}
+
+// Struct com a leitura de um provedor de clima. Os provedores com falha possuem somente o erro.
+type Provider struct {
+	Name    string   `json:"name"`
+	Celsius *float64 `json:"celsius,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Função que converte a temperatura do domínio no contrato da v2
+func FromDomain(c *domain.ClimaCidade) *ClimaCidade {
+	resp := &ClimaCidade{
+		Location:    Location{City: c.Cidade},
+		Temperature: Temperature{Celsius: c.TempC, Fahrenheit: c.TempF, Kelvin: c.TempK},
+	}
+	if e := c.Endereco; e != nil {
+		resp.Location.PostalCode = e.Cep
+		resp.Location.Country = e.Pais
+		resp.Location.State = e.Uf
+		resp.Location.Neighborhood = e.Bairro
+		resp.Location.Street = e.Logradouro
+	}
+	if c.Condicao != "" {
+		resp.Conditions = &Conditions{Description: c.Condicao}
+	}
+	if consenso := c.Consenso; consenso != nil {
+		resp.Sources = &Sources{Strategy: consenso.Estrategia, SpreadCelsius: consenso.Diferenca, Disagreement: consenso.Divergencia}
+		for _, leitura := range consenso.Provedores {
+			resp.Sources.Providers = append(resp.Sources.Providers, Provider{Name: leitura.Provedor, Celsius: leitura.TempC, Error: leitura.Erro})
+		}
+	}
+	return resp
+}
+
+// Função que representa o clima em CSV, com uma única linha. As leituras dos provedores não são incluídas.
+func (c *ClimaCidade) MarshalCSV() ([]string, [][]string) {
+	header := []string{"postal_code", "country", "state", "city", "celsius", "fahrenheit", "kelvin", "conditions"}
+	formatFloat := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	var conditions string
+	if c.Conditions != nil {
+		conditions = c.Conditions.Description
+	}
+	l, t := c.Location, c.Temperature
+	return header, [][]string{{l.PostalCode, l.Country, l.State, l.City, formatFloat(t.Celsius), formatFloat(t.Fahrenheit), formatFloat(t.Kelvin), conditions}}
+}
@@ -0,0 +1,48 @@
+package v2
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+)
+
+// Teste que fixa o JSON da v2
+func TestContratoJSON(t *testing.T) {
+	tempC := 28.0
+	testes := []struct {
+		nome     string
+		clima    *domain.ClimaCidade
+		esperado string
+	}{
+		{
+			"somente temperaturas",
+			&domain.ClimaCidade{Cidade: "Ibirité", TempC: 28.5, TempF: 83.3, TempK: 301.5},
+			`{"location":{"city":"Ibirité"},"temperature":{"celsius":28.5,"fahrenheit":83.3,"kelvin":301.5}}`,
+		},
+		{
+			"completo",
+			&domain.ClimaCidade{
+				Cidade: "Ibirité", TempC: 28.5, TempF: 83.3, TempK: 301.5, Condicao: "Sunny",
+				Endereco: &domain.Endereco{Cep: "32450-000", Logradouro: "Rua A", Bairro: "Centro", Cidade: "Ibirité", Uf: "MG", Pais: "BR"},
+				Consenso: &domain.Consenso{Estrategia: "median", Diferenca: 1, Provedores: []domain.LeituraProvedor{
+					{Provedor: "weatherapi", TempC: &tempC},
+					{Provedor: "open-meteo", Erro: "open-meteo is unavailable"},
+				}},
+			},
+			`{"location":{"postal_code":"32450-000","country":"BR","state":"MG","city":"Ibirité","neighborhood":"Centro","street":"Rua A"},` +
+				`"temperature":{"celsius":28.5,"fahrenheit":83.3,"kelvin":301.5},` +
+				`"conditions":{"description":"Sunny"},` +
+				`"sources":{"strategy":"median","spread_celsius":1,"disagreement":false,"providers":[{"name":"weatherapi","celsius":28},{"name":"open-meteo","error":"open-meteo is unavailable"}]}}`,
+		},
+	}
+	for _, tt := range testes {
+		t.Run(tt.nome, func(t *testing.T) {
+			data, err := json.Marshal(FromDomain(tt.clima))
+			require.NoError(t, err)
+			assert.Equal(t, tt.esperado, string(data))
+		})
+	}
+}
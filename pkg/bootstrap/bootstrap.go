@@ -0,0 +1,224 @@
+// Package bootstrap reúne a inicialização comum aos serviços: configuração padrão, router,
+// execução dos servidores HTTP e gRPC e o graceful shutdown.
+package bootstrap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/spf13/viper"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/deadline"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/realip"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/tlsconfig"
+	"google.golang.org/grpc"
+)
+
+// Tempo máximo do graceful shutdown dos servidores
+const ShutdownTimeout = 5 * time.Second
+
+// Função que define os valores padrão das variáveis comuns aos serviços
+func SetDefaults(serviceName, httpPort string) {
+	viper.SetDefault("OTEL_SERVICE_NAME", serviceName)
+	viper.SetDefault("REQUEST_NAME_OTEL", serviceName+"-request")
+	viper.SetDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "otel-collector:4317")
+	viper.SetDefault("HTTP_PORT", httpPort)
+	// TLS dos servidores. Sem certificado, os servidores não utilizam TLS. Com o CA dos clientes,
+	// o certificado do cliente é obrigatório (mTLS).
+	viper.SetDefault("TLS_CERT_FILE", "")
+	viper.SetDefault("TLS_KEY_FILE", "")
+	viper.SetDefault("TLS_CLIENT_CA_FILE", "")
+	viper.SetDefault("TLS_RELOAD_INTERVAL", tlsconfig.DefaultReloadInterval)
+	// Proxies confiáveis (IPs ou redes CIDR), dos quais os headers X-Forwarded-For e X-Real-IP
+	// são aceitos. Sem proxies, o IP de origem é sempre o endereço da conexão.
+	viper.SetDefault("TRUSTED_PROXIES", "")
+	// Data, no formato AAAA-MM-DD, a partir da qual as rotas sem versão são obsoletas (header Deprecation)
+	viper.SetDefault("API_DEPRECATION_DATE", apiversion.DefaultDeprecation.Format(time.DateOnly))
+}
+
+// Função que cria a configuração TLS dos servidores a partir das variáveis TLS_*. Retorna nil
+// quando o certificado não é informado. Os arquivos são recarregados até o contexto ser cancelado.
+func ServerTLS(ctx context.Context) (*tls.Config, error) {
+	if viper.GetString("TLS_CERT_FILE") == "" {
+		return nil, nil
+	}
+	reloader, err := tlsconfig.NewReloader(tlsconfig.Files{
+		Cert: viper.GetString("TLS_CERT_FILE"),
+		Key:  viper.GetString("TLS_KEY_FILE"),
+		CA:   viper.GetString("TLS_CLIENT_CA_FILE"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	go reloader.Watch(ctx, viper.GetDuration("TLS_RELOAD_INTERVAL"))
+	return reloader.ServerConfig(), nil
+}
+
+// Middlewares comuns aos serviços, pelo nome utilizado na configuração
+var Middlewares = map[string]func(http.Handler) http.Handler{
+	"requestid": middleware.RequestID,
+	// Sem proxies confiáveis, mantém o endereço da conexão. Os serviços substituem este
+	// middleware pelo realip.Middleware com os proxies da variável TRUSTED_PROXIES.
+	"realip":    realip.Middleware(nil),
+	"recoverer": middleware.Recoverer,
+	"logger":    middleware.Logger,
+	// Prazo da requisição recebido no header do deadline.Header
+	"deadline": deadline.Middleware,
+	// Idioma das respostas negociado no header Accept-Language
+	"language": i18n.Middleware,
+}
+
+// Middlewares utilizados pelo NewRouter, na ordem de execução
+var DefaultMiddlewares = []string{"requestid", "realip", "recoverer", "logger", "deadline", "language"}
+
+// Cria um novo router utilizando o chi e acrescentando os midlewares comuns aos serviços.
+func NewRouter() *chi.Mux {
+	router, _ := NewRouterWith(DefaultMiddlewares, nil)
+	return router
+}
+
+// Cria um novo router com os middlewares informados, na ordem de execução. Os nomes são procurados
+// nos middlewares do serviço (extra) e nos middlewares comuns. Nome desconhecido retorna erro.
+func NewRouterWith(names []string, extra map[string]func(http.Handler) http.Handler) (*chi.Mux, error) {
+	router := chi.NewRouter()
+	for _, name := range names {
+		mw, ok := extra[name]
+		if !ok {
+			mw, ok = Middlewares[name]
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware %q", name)
+		}
+		router.Use(mw)
+	}
+	return router, nil
+}
+
+// Função que retorna um contexto cancelado quando o processo recebe CTRL+C ou SIGTERM
+func SignalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// Interface dos servidores executados pelo Run
+type Server interface {
+	// Nome e endereço do servidor, utilizados nos logs
+	Name() string
+	Addr() string
+	// Inicia o servidor e bloqueia até o shutdown. Retorna nil quando o servidor é encerrado pelo Shutdown.
+	Serve() error
+	Shutdown(ctx context.Context) error
+}
+
+// Servidor HTTP
+type httpServer struct {
+	server *http.Server
+}
+
+// Função que cria o Server a partir do http.Server informado
+func HTTP(server *http.Server) Server {
+	return &httpServer{server: server}
+}
+
+func (s *httpServer) Name() string { return "HTTP" }
+
+func (s *httpServer) Addr() string { return s.server.Addr }
+
+// Com o TLSConfig definido, o servidor utiliza HTTPS com os certificados da configuração
+func (s *httpServer) Serve() error {
+	serve := s.server.ListenAndServe
+	if s.server.TLSConfig != nil {
+		serve = func() error { return s.server.ListenAndServeTLS("", "") }
+	}
+	if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *httpServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// Servidor gRPC
+type grpcServer struct {
+	addr   string
+	server *grpc.Server
+}
+
+// Função que cria o Server a partir do grpc.Server informado, escutando no endereço addr
+func GRPC(addr string, server *grpc.Server) Server {
+	return &grpcServer{addr: addr, server: server}
+}
+
+func (s *grpcServer) Name() string { return "gRPC" }
+
+func (s *grpcServer) Addr() string { return s.addr }
+
+func (s *grpcServer) Serve() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.server.Serve(listener)
+}
+
+// O GracefulStop aguarda as chamadas em andamento. Caso o prazo do contexto termine antes, as conexões são fechadas.
+func (s *grpcServer) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
+
+// Função que inicia os servidores e aguarda o contexto ser cancelado ou algum servidor falhar.
+// Em seguida, realiza o graceful shutdown de todos os servidores. Retorna o erro do servidor que falhou.
+func Run(ctx context.Context, servers ...Server) error {
+	errCh := make(chan error, len(servers))
+	for _, s := range servers {
+		go func(s Server) {
+			log.Printf("Starting %s server on %s", s.Name(), s.Addr())
+			if err := s.Serve(); err != nil {
+				errCh <- fmt.Errorf("%s server: %w", s.Name(), err)
+			}
+		}(s)
+	}
+
+	// Select para realizar o gracefull shutdown
+	var err error
+	select {
+	case <-ctx.Done():
+		log.Println("Shutting down gracefully...")
+	case err = <-errCh:
+		log.Println("Shutting down due to server error:", err)
+	}
+
+	// Create a timeout context for the graceful shutdown
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	for _, s := range servers {
+		if shutdownErr := s.Shutdown(shutdownCtx); shutdownErr != nil {
+			log.Printf("Error during %s server shutdown: %s", s.Name(), shutdownErr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,156 @@
+package bootstrap
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/tlsconfig"
+	"google.golang.org/grpc"
+)
+
+// Porta livre para os servidores dos testes
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// O router deve incluir o request id nas requisições
+func TestNewRouter(t *testing.T) {
+	router := NewRouter()
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, middleware.GetReqID(r.Context()))
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// Os middlewares são aplicados na ordem informada e nomes desconhecidos são rejeitados
+func TestNewRouterWith(t *testing.T) {
+	var ordem []string
+	marca := func(nome string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ordem = append(ordem, nome)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	router, err := NewRouterWith([]string{"b", "requestid", "a"}, map[string]func(http.Handler) http.Handler{"a": marca("a"), "b": marca("b")})
+	require.NoError(t, err)
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, middleware.GetReqID(r.Context()))
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, []string{"b", "a"}, ordem)
+
+	_, err = NewRouterWith([]string{"requestid", "gzip"}, nil)
+	assert.ErrorContains(t, err, `unknown middleware "gzip"`)
+}
+
+// O cancelamento do contexto deve encerrar os servidores HTTP e gRPC sem erro
+func TestRunShutdown(t *testing.T) {
+	addr := freeAddr(t)
+	server := &http.Server{Addr: addr, Handler: http.NotFoundHandler()}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Run(ctx, HTTP(server), GRPC(freeAddr(t), grpc.NewServer()))
+	}()
+
+	require.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusNotFound
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(ShutdownTimeout):
+		t.Fatal("Run não finalizou após o cancelamento do contexto")
+	}
+}
+
+// A falha de um servidor deve encerrar os demais e ser retornada pelo Run
+func TestRunErroServidor(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	err = Run(context.Background(), HTTP(&http.Server{Addr: freeAddr(t)}), GRPC(listener.Addr().String(), grpc.NewServer()))
+	assert.ErrorContains(t, err, "gRPC server")
+}
+
+// Com o certificado configurado, o servidor HTTP utiliza HTTPS e exige o certificado do cliente
+func TestServerTLS(t *testing.T) {
+	SetDefaults("service-b", ":8282")
+	tlsConfig, err := ServerTLS(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+
+	dir := t.TempDir()
+	ca, err := tlsconfig.NewCA("dev CA", time.Hour)
+	require.NoError(t, err)
+	files := map[string]tlsconfig.Files{}
+	for _, name := range []string{"service-a", "service-b"} {
+		pair, err := ca.Issue(name, []string{"localhost"}, time.Hour)
+		require.NoError(t, err)
+		files[name] = tlsconfig.Files{Cert: filepath.Join(dir, name+".pem"), Key: filepath.Join(dir, name+"-key.pem"), CA: filepath.Join(dir, "ca.pem")}
+		require.NoError(t, os.WriteFile(files[name].Cert, pair.Cert, 0o600))
+		require.NoError(t, os.WriteFile(files[name].Key, pair.Key, 0o600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), ca.Cert, 0o600))
+
+	viper.Set("TLS_CERT_FILE", files["service-b"].Cert)
+	viper.Set("TLS_KEY_FILE", files["service-b"].Key)
+	viper.Set("TLS_CLIENT_CA_FILE", files["service-b"].CA)
+	defer func() {
+		for _, key := range []string{"TLS_CERT_FILE", "TLS_KEY_FILE", "TLS_CLIENT_CA_FILE"} {
+			viper.Set(key, "")
+		}
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tlsConfig, err = ServerTLS(ctx)
+	require.NoError(t, err)
+
+	addr := freeAddr(t)
+	go Run(ctx, HTTP(&http.Server{Addr: addr, Handler: http.NotFoundHandler(), TLSConfig: tlsConfig}))
+
+	client, err := tlsconfig.NewReloader(files["service-a"])
+	require.NoError(t, err)
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: client.ClientConfig()}}
+	_, port, _ := net.SplitHostPort(addr)
+	require.Eventually(t, func() bool {
+		resp, err := httpClient.Get("https://localhost:" + port)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusNotFound
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Requisição sem TLS é rejeitada pelo servidor
+	resp, err := http.Get("http://" + addr)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,125 @@
+package cep
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		entrada string
+		cep     CEP
+		uf      string
+		err     error
+	}{
+		{"32450000", "32450000", "MG", nil},
+		{"32450-000", "32450000", "MG", nil},
+		{"32.450-000", "32450000", "MG", nil},
+		{" 32450000 ", "32450000", "MG", nil},
+		{"\t01001-000\n", "01001000", "SP", nil},
+		{"69301000", "69301000", "RR", nil},
+		{"72800000", "72800000", "GO", nil},
+		{"99999999", "99999999", "RS", nil},
+		{"00000000", "", "", ErrInvalidRange},
+		{"00999999", "", "", ErrInvalidRange},
+		{"+1234567", "", "", ErrInvalidFormat},
+		{"-1234567", "", "", ErrInvalidFormat},
+		{"3245000", "", "", ErrInvalidFormat},
+		{"324500000", "", "", ErrInvalidFormat},
+		{"3245-0000", "", "", ErrInvalidFormat},
+		{"324.50-000", "", "", ErrInvalidFormat},
+		{"32450 000", "", "", ErrInvalidFormat},
+		{"32.450.000", "", "", ErrInvalidFormat},
+		{"3245000a", "", "", ErrInvalidFormat},
+		{"３２４５００００", "", "", ErrInvalidFormat},
+		{"٣٢٤٥٠٠٠٠", "", "", ErrInvalidFormat},
+		{"", "", "", ErrInvalidFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entrada, func(t *testing.T) {
+			c, err := Parse(tt.entrada)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.cep, c)
+			assert.Equal(t, tt.uf, c.UF())
+			assert.Equal(t, tt.err == nil, Valid(tt.entrada))
+		})
+	}
+}
+
+func TestFormatted(t *testing.T) {
+	c, err := Parse("32.450-000")
+	assert.NoError(t, err)
+	assert.Equal(t, "32450000", c.String())
+	assert.Equal(t, "32450-000", c.Formatted())
+}
+
+// Todo CEP aceito pelo Parse deve ter 8 dígitos ASCII, pertencer a uma UF
+// e ser aceito novamente, sem alterações, nos formatos normalizado e formatado
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{"32450000", "32450-000", "32.450-000", " 01001000 ", "+1234567", "00000000", "3245-0000", "٣٢٤٥٠٠٠٠", ""} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, entrada string) {
+		c, err := Parse(entrada)
+		if err != nil {
+			if c != "" {
+				t.Fatalf("Parse(%q) retornou %q junto com o erro %v", entrada, c, err)
+			}
+			return
+		}
+		if len(c) != 8 || strings.Trim(c.String(), "0123456789") != "" {
+			t.Fatalf("Parse(%q) retornou um CEP inválido: %q", entrada, c)
+		}
+		if c.UF() == "" {
+			t.Fatalf("Parse(%q) retornou um CEP sem UF: %q", entrada, c)
+		}
+		for _, s := range []string{c.String(), c.Formatted()} {
+			if again, err := Parse(s); err != nil || again != c {
+				t.Fatalf("Parse(%q) = %q, %v; esperado %q", s, again, err, c)
+			}
+		}
+	})
+}
+
+func TestParsePostalCode(t *testing.T) {
+	tests := []struct {
+		pais      string
+		entrada   string
+		code      string
+		formatted string
+		err       error
+	}{
+		{"", "32.450-000", "32450000", "32450-000", nil},
+		{"br", "32450000", "32450000", "32450-000", nil},
+		{"BR", "00000000", "", "", ErrInvalidRange},
+		{"PT", "1000-001", "1000001", "1000-001", nil},
+		{" pt ", "4470558", "4470558", "4470-558", nil},
+		{"PT", "0100-001", "", "", ErrInvalidFormat},
+		{"PT", "1000 001", "", "", ErrInvalidFormat},
+		{"PT", "100-0001", "", "", ErrInvalidFormat},
+		{"AR", "c1425abc", "C1425ABC", "C1425ABC", nil},
+		{"AR", "X5000", "", "", ErrInvalidFormat},
+		{"AR", "5000", "5000", "5000", nil},
+		{"AR", "I1425ABC", "", "", ErrInvalidFormat},
+		{"AR", "C1425AB1", "", "", ErrInvalidFormat},
+		{"US", "10001", "", "", ErrUnsupportedCountry},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pais+"/"+tt.entrada, func(t *testing.T) {
+			p, err := ParsePostalCode(tt.pais, tt.entrada)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.code, p.String())
+			assert.Equal(t, tt.formatted, p.Formatted())
+		})
+	}
+
+	// Cada país possui a sua própria mensagem de erro
+	_, err := ParsePostalCode("PT", "123")
+	assert.EqualError(t, err, ErrInvalidFormatPT.Error())
+	assert.Equal(t, []string{"AR", "BR", "PT"}, Countries())
+	assert.Equal(t, "Portugal", CountryName("pt"))
+}
@@ -0,0 +1,189 @@
+package cep
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Países suportados, pelo código ISO 3166-1 alpha-2
+const (
+	CountryBrazil    = "BR"
+	CountryPortugal  = "PT"
+	CountryArgentina = "AR"
+	// País utilizado quando nenhum é informado
+	DefaultCountry = CountryBrazil
+)
+
+// Erro retornado quando o país informado não é suportado
+var ErrUnsupportedCountry = errors.New("unsupported country")
+
+// Erros de formato dos códigos postais de Portugal e da Argentina. Assim como o ErrInvalidFormat,
+// dos CEPs brasileiros, podem ser identificados com errors.Is(err, ErrInvalidFormat).
+var (
+	ErrInvalidFormatPT = &formatError{"the postal code must be in the format 1234-567"}
+	ErrInvalidFormatAR = &formatError{"the postal code must be a CPA like C1425ABC or contain exactly 4 digits"}
+)
+
+// Erro de formato de um país, equivalente ao ErrInvalidFormat
+type formatError struct {
+	message string
+}
+
+func (e *formatError) Error() string {
+	return e.message
+}
+
+func (e *formatError) Is(target error) bool {
+	return target == ErrInvalidFormat
+}
+
+// Validação e formatação dos códigos postais de um país
+type country struct {
+	// Nome do país em inglês, utilizado nas consultas de clima
+	name   string
+	parse  func(s string) (string, error)
+	format func(code string) string
+}
+
+var countries = map[string]country{
+	CountryBrazil: {
+		name: "Brazil",
+		parse: func(s string) (string, error) {
+			c, err := Parse(s)
+			return c.String(), err
+		},
+		format: func(code string) string { return CEP(code).Formatted() },
+	},
+	CountryPortugal:  {name: "Portugal", parse: parsePT, format: formatPT},
+	CountryArgentina: {name: "Argentina", parse: parseAR, format: func(code string) string { return code }},
+}
+
+// Código postal normalizado de um país. Só deve ser criado pelo ParsePostalCode.
+type PostalCode struct {
+	Country string
+	Code    string
+}
+
+// Função que converte o texto informado no código postal do país (BR, PT ou AR, sem diferenciar
+// maiúsculas e minúsculas). Sem país, o código é tratado como um CEP brasileiro.
+func ParsePostalCode(pais, s string) (PostalCode, error) {
+	pais, err := NormalizeCountry(pais)
+	if err != nil {
+		return PostalCode{}, err
+	}
+	code, err := countries[pais].parse(s)
+	if err != nil {
+		return PostalCode{}, err
+	}
+	return PostalCode{Country: pais, Code: code}, nil
+}
+
+// Função que normaliza o código do país. Vazio é o DefaultCountry.
+func NormalizeCountry(pais string) (string, error) {
+	pais = strings.ToUpper(strings.TrimSpace(pais))
+	if pais == "" {
+		return DefaultCountry, nil
+	}
+	if _, ok := countries[pais]; !ok {
+		return "", ErrUnsupportedCountry
+	}
+	return pais, nil
+}
+
+// Códigos dos países suportados, em ordem alfabética
+func Countries() []string {
+	codes := make([]string, 0, len(countries))
+	for code := range countries {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// Nome do país em inglês (ex.: BR é Brazil). Vazio para países não suportados.
+func CountryName(pais string) string {
+	return countries[strings.ToUpper(pais)].name
+}
+
+// Código normalizado (ex.: 32450000, 1000001 ou C1425ABC)
+func (p PostalCode) String() string {
+	return p.Code
+}
+
+// Código no formato usual do país (ex.: 32450-000, 1000-001 ou C1425ABC)
+func (p PostalCode) Formatted() string {
+	c, ok := countries[p.Country]
+	if !ok {
+		return p.Code
+	}
+	return c.format(p.Code)
+}
+
+// Código postal português: 1234-567 ou 1234567, com o primeiro dígito diferente de zero
+func parsePT(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch len(s) {
+	case 7:
+	case 8:
+		if s[4] != '-' {
+			return "", ErrInvalidFormatPT
+		}
+		s = s[:4] + s[5:]
+	default:
+		return "", ErrInvalidFormatPT
+	}
+	if !digitos(s) || s[0] == '0' {
+		return "", ErrInvalidFormatPT
+	}
+	return s, nil
+}
+
+func formatPT(code string) string {
+	if len(code) != 7 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
+
+// Letras das províncias argentinas no CPA. As letras I e O não são utilizadas.
+const provinciasAR = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// Código postal argentino: CPA (letra da província, 4 dígitos e 3 letras, como C1425ABC) ou o
+// código antigo de 4 dígitos. As letras são convertidas para maiúsculas.
+func parseAR(s string) (string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch len(s) {
+	case 4:
+		if !digitos(s) || s[0] == '0' {
+			return "", ErrInvalidFormatAR
+		}
+	case 8:
+		if !strings.ContainsRune(provinciasAR, rune(s[0])) || !digitos(s[1:5]) || !letras(s[5:]) {
+			return "", ErrInvalidFormatAR
+		}
+	default:
+		return "", ErrInvalidFormatAR
+	}
+	return s, nil
+}
+
+// Verifica se o texto possui somente dígitos ASCII
+func digitos(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Verifica se o texto possui somente letras ASCII maiúsculas
+func letras(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,364 @@
+// Package client é o SDK em Go para consumir a API de temperatura por CEP do service-a ou do service-b.
+//
+//	c := client.New(client.WithBaseURL("http://localhost:8181"), client.WithRetries(2))
+//	b := client.New(client.WithService(client.ServiceB), client.WithBaseURL("http://localhost:8282"))
+//	clima, err := c.GetTemperature(ctx, "32450000")
+//	if errors.Is(err, client.ErrZipcodeNotFound) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Valores padrão do client. O DefaultBaseURL aponta para o service-a e o DefaultServiceBURL para o service-b.
+const (
+	DefaultBaseURL     = "http://localhost:8181"
+	DefaultServiceBURL = "http://localhost:8282"
+	DefaultTimeout     = 10 * time.Second
+	DefaultConcurrency = 4
+	DefaultBackoff     = 100 * time.Millisecond
+)
+
+// Header com a chave de API do cliente, aceito pelo service-a com a autenticação habilitada
+const APIKeyHeader = "X-API-Key"
+
+// Nome do tracer utilizado nos spans do client
+const tracerName = "github.com/wandermaia/desafio-temperatura-cep/pkg/client"
+
+// Erros que representam as respostas conhecidas da API
+var (
+	ErrInvalidZipcode  = errors.New("invalid zipcode")
+	ErrZipcodeNotFound = errors.New("can not find zipcode")
+)
+
+// Struct com a resposta de sucesso da API
+type ClimaCidade = domain.ClimaCidade
+
+// Struct com o endereço do CEP consultado
+type Endereco = domain.Endereco
+
+// Erro retornado quando a API responde com um status diferente de 200.
+// Os campos são preenchidos a partir do corpo application/problem+json, quando existir.
+type APIError struct {
+	StatusCode int    `json:"status"`
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Detail     string `json:"detail"`
+	Instance   string `json:"instance"`
+	TraceID    string `json:"trace_id"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("api respondeu com o status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("api respondeu com o status %d", e.StatusCode)
+}
+
+// Permite utilizar errors.Is com os erros conhecidos da API
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrInvalidZipcode:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrZipcodeNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// Indica se a requisição pode ser repetida
+func (e *APIError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Serviço consultado pelo client, que define o formato da requisição
+type Service int
+
+const (
+	// Envia o CEP no corpo JSON de um POST /cep
+	ServiceA Service = iota
+	// Envia o CEP no caminho de um GET /{cep}
+	ServiceB
+)
+
+// Resultado individual de uma consulta em lote
+type BatchResult struct {
+	Cep   string
+	Clima *ClimaCidade
+	Err   error
+}
+
+// Struct do client da API
+type Client struct {
+	service     Service
+	baseURL     string
+	httpClient  *http.Client
+	timeout     time.Duration
+	retries     int
+	backoff     time.Duration
+	concurrency int
+	tracer      trace.Tracer
+	// Credenciais enviadas em todas as requisições
+	apiKey      string
+	bearerToken string
+}
+
+// Tipo das opções de configuração do client
+type Option func(*Client)
+
+// Define o serviço consultado pelo client. O padrão é o ServiceA.
+func WithService(service Service) Option {
+	return func(c *Client) {
+		c.service = service
+	}
+}
+
+// Define o endereço base da API
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// Define o timeout de cada tentativa. O timeout é aplicado como deadline do contexto de cada
+// requisição, independente da ordem das opções e do http.Client informado.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// Define quantas vezes uma requisição com falha temporária (429, 502, 503, 504, timeout ou conexão
+// recusada/encerrada) será repetida
+func WithRetries(retries int) Option {
+	return func(c *Client) {
+		c.retries = retries
+	}
+}
+
+// Define o intervalo inicial entre as tentativas. O intervalo dobra a cada nova tentativa.
+func WithBackoff(backoff time.Duration) Option {
+	return func(c *Client) {
+		c.backoff = backoff
+	}
+}
+
+// Define quantas consultas do GetBatch são executadas em paralelo
+func WithConcurrency(concurrency int) Option {
+	return func(c *Client) {
+		if concurrency > 0 {
+			c.concurrency = concurrency
+		}
+	}
+}
+
+// Define a chave de API enviada no header X-API-Key, exigida pelo service-a com a autenticação habilitada
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// Define o token JWT enviado no header Authorization: Bearer, alternativa à chave de API
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.bearerToken = token
+	}
+}
+
+// Define o http.Client utilizado nas requisições
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// Função que cria um novo client com base nas opções informadas
+func New(opts ...Option) *Client {
+	c := &Client{
+		httpClient:  http.DefaultClient,
+		timeout:     DefaultTimeout,
+		backoff:     DefaultBackoff,
+		concurrency: DefaultConcurrency,
+		tracer:      otel.Tracer(tracerName),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.baseURL == "" {
+		c.baseURL = DefaultBaseURL
+		if c.service == ServiceB {
+			c.baseURL = DefaultServiceBURL
+		}
+	}
+	return c
+}
+
+// Função que consulta a temperatura do CEP informado
+func (c *Client) GetTemperature(ctx context.Context, cep string) (*ClimaCidade, error) {
+	ctx, span := c.tracer.Start(ctx, "client.GetTemperature", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	span.SetAttributes(attribute.String("cep", cep))
+
+	var clima *ClimaCidade
+	err := c.retry(ctx, func() error {
+		var err error
+		clima, err = c.do(ctx, cep)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	return clima, nil
+}
+
+// Função que consulta a temperatura de vários CEPs em paralelo. O resultado segue a ordem dos CEPs informados
+// e o erro de cada consulta fica no próprio BatchResult.
+func (c *Client) GetBatch(ctx context.Context, ceps []string) []BatchResult {
+	ctx, span := c.tracer.Start(ctx, "client.GetBatch", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	span.SetAttributes(attribute.Int("ceps", len(ceps)))
+
+	results := make([]BatchResult, len(ceps))
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, cep := range ceps {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, cep string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			clima, err := c.GetTemperature(ctx, cep)
+			results[i] = BatchResult{Cep: cep, Clima: clima, Err: err}
+		}(i, cep)
+	}
+	wg.Wait()
+	return results
+}
+
+// Função que executa a chamada repetindo as falhas temporárias
+func (c *Client) retry(ctx context.Context, call func() error) error {
+	backoff := c.backoff
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = call()
+		if err == nil || attempt >= c.retries || !temporary(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Verifica se o erro é temporário. Erros de rede só são repetidos em caso de timeout, conexão
+// recusada ou encerrada; falhas como certificado inválido ou URL malformada não mudam com uma nova tentativa.
+func temporary(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// Função que realiza a requisição. O contexto de trace e as credenciais configuradas são enviados nos headers.
+func (c *Client) do(ctx context.Context, cep string) (*ClimaCidade, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := c.newRequest(ctx, cep)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	// O client utiliza o contrato da v1, sem depender da versão padrão da rota sem versão
+	req.Header.Set(apiversion.Header, apiversion.V1)
+	if c.apiKey != "" {
+		req.Header.Set(APIKeyHeader, c.apiKey)
+	}
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{}
+		json.Unmarshal(body, apiErr)
+		apiErr.StatusCode = resp.StatusCode
+		return nil, apiErr
+	}
+
+	var clima ClimaCidade
+	if err := json.Unmarshal(body, &clima); err != nil {
+		return nil, fmt.Errorf("resposta inválida da api: %w", err)
+	}
+	return &clima, nil
+}
+
+// Função que monta a requisição no formato do serviço configurado
+func (c *Client) newRequest(ctx context.Context, cep string) (*http.Request, error) {
+	if c.service == ServiceB {
+		return http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/"+url.PathEscape(cep), nil)
+	}
+
+	payload, err := json.Marshal(struct {
+		Cep string `json:"cep"`
+	}{Cep: cep})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/cep", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
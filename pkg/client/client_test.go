@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+)
+
+// Responde de acordo com o CEP consultado, no formato de erro application/problem+json dos serviços
+func responde(w http.ResponseWriter, cep, instance string) {
+	switch {
+	case len(cep) != 8:
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"title": "invalid zipcode", "status": 422, "instance": "` + instance + `", "message": "invalid zipcode"}`))
+	case cep == "99999999":
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"title": "can not find zipcode", "status": 404, "instance": "` + instance + `", "message": "can not find zipcode"}`))
+	default:
+		w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+	}
+}
+
+// Service-a simulado: POST /cep com o CEP no corpo JSON
+func serviceAMock(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Cep string `json:"cep"`
+	}
+	if r.Method != http.MethodPost || r.URL.Path != "/cep" || r.Header.Get("Content-Type") != "application/json" ||
+		r.Header.Get(apiversion.Header) != apiversion.V1 || json.NewDecoder(r.Body).Decode(&body) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	responde(w, body.Cep, "/cep")
+}
+
+// Service-b simulado: GET /{cep}
+func serviceBMock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || r.Header.Get(apiversion.Header) != apiversion.V1 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	responde(w, strings.TrimPrefix(r.URL.Path, "/"), r.URL.Path)
+}
+
+func newServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetTemperature(t *testing.T) {
+	server := newServer(t, serviceAMock)
+	c := New(WithBaseURL(server.URL))
+
+	clima, err := c.GetTemperature(context.Background(), "32450000")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ibirité", clima.Cidade)
+	assert.Equal(t, 301.5, clima.TempK)
+}
+
+func TestGetTemperatureServiceB(t *testing.T) {
+	server := newServer(t, serviceBMock)
+	c := New(WithService(ServiceB), WithBaseURL(server.URL))
+
+	clima, err := c.GetTemperature(context.Background(), "32450000")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ibirité", clima.Cidade)
+
+	_, err = c.GetTemperature(context.Background(), "99999999")
+	assert.ErrorIs(t, err, ErrZipcodeNotFound)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "/99999999", apiErr.Instance)
+}
+
+// As credenciais configuradas são enviadas em todas as requisições
+func TestGetTemperatureCredenciais(t *testing.T) {
+	var apiKey, authorization string
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get(APIKeyHeader)
+		authorization = r.Header.Get("Authorization")
+		serviceAMock(w, r)
+	})
+
+	_, err := New(WithBaseURL(server.URL)).GetTemperature(context.Background(), "32450000")
+	assert.NoError(t, err)
+	assert.Empty(t, apiKey)
+	assert.Empty(t, authorization)
+
+	_, err = New(WithBaseURL(server.URL), WithAPIKey("chave-secreta")).GetTemperature(context.Background(), "32450000")
+	assert.NoError(t, err)
+	assert.Equal(t, "chave-secreta", apiKey)
+	assert.Empty(t, authorization)
+
+	_, err = New(WithBaseURL(server.URL), WithBearerToken("token-jwt")).GetTemperature(context.Background(), "32450000")
+	assert.NoError(t, err)
+	assert.Empty(t, apiKey)
+	assert.Equal(t, "Bearer token-jwt", authorization)
+}
+
+// O endereço padrão depende do serviço configurado
+func TestNewBaseURLPadrao(t *testing.T) {
+	assert.Equal(t, DefaultBaseURL, New().baseURL)
+	assert.Equal(t, DefaultServiceBURL, New(WithService(ServiceB)).baseURL)
+	assert.Equal(t, "http://exemplo", New(WithBaseURL("http://exemplo/"), WithService(ServiceB)).baseURL)
+}
+
+func TestGetTemperatureErrosTipados(t *testing.T) {
+	server := newServer(t, serviceAMock)
+	c := New(WithBaseURL(server.URL))
+
+	_, err := c.GetTemperature(context.Background(), "324500000")
+	assert.ErrorIs(t, err, ErrInvalidZipcode)
+
+	_, err = c.GetTemperature(context.Background(), "99999999")
+	assert.ErrorIs(t, err, ErrZipcodeNotFound)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "/cep", apiErr.Instance)
+}
+
+// Falhas temporárias devem ser repetidas até o limite de tentativas
+func TestGetTemperatureRetries(t *testing.T) {
+	var chamadas atomic.Int32
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if chamadas.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		serviceAMock(w, r)
+	})
+
+	_, err := New(WithBaseURL(server.URL), WithBackoff(time.Millisecond)).GetTemperature(context.Background(), "32450000")
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), chamadas.Load())
+
+	chamadas.Store(0)
+	clima, err := New(WithBaseURL(server.URL), WithRetries(2), WithBackoff(time.Millisecond)).GetTemperature(context.Background(), "32450000")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ibirité", clima.Cidade)
+	assert.Equal(t, int32(3), chamadas.Load())
+}
+
+// Erros 4xx não devem ser repetidos
+func TestGetTemperatureSemRetryEm4xx(t *testing.T) {
+	var chamadas atomic.Int32
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		chamadas.Add(1)
+		serviceAMock(w, r)
+	})
+
+	_, err := New(WithBaseURL(server.URL), WithRetries(3), WithBackoff(time.Millisecond)).GetTemperature(context.Background(), "99999999")
+	assert.ErrorIs(t, err, ErrZipcodeNotFound)
+	assert.Equal(t, int32(1), chamadas.Load())
+}
+
+// Um certificado não confiável não muda entre as tentativas e não deve ser repetido
+func TestGetTemperatureSemRetryEmErroTLS(t *testing.T) {
+	var chamadas atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chamadas.Add(1)
+		serviceAMock(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	_, err := New(WithBaseURL(server.URL), WithRetries(3), WithBackoff(time.Millisecond)).GetTemperature(context.Background(), "32450000")
+	assert.ErrorContains(t, err, "certificate")
+	assert.False(t, temporary(err))
+	assert.Equal(t, int32(0), chamadas.Load())
+}
+
+// Conexão recusada é temporária e deve ser repetida
+func TestGetTemperatureRetryEmConexaoRecusada(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	_, err = New(WithBaseURL("http://"+addr), WithRetries(1), WithBackoff(time.Millisecond)).GetTemperature(context.Background(), "32450000")
+	assert.Error(t, err)
+	assert.True(t, temporary(err))
+}
+
+func TestGetTemperatureTimeout(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		serviceAMock(w, r)
+	})
+
+	_, err := New(WithBaseURL(server.URL), WithTimeout(50*time.Millisecond)).GetTemperature(context.Background(), "32450000")
+	assert.Error(t, err)
+	assert.True(t, temporary(err))
+}
+
+// O timeout deve ser respeitado independente da ordem das opções e sem alterar o http.Client informado
+func TestGetTemperatureTimeoutOrdemDasOpcoes(t *testing.T) {
+	server := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		serviceAMock(w, r)
+	})
+
+	httpClient := &http.Client{}
+	c := New(WithBaseURL(server.URL), WithTimeout(50*time.Millisecond), WithHTTPClient(httpClient))
+
+	inicio := time.Now()
+	_, err := c.GetTemperature(context.Background(), "32450000")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(inicio), 150*time.Millisecond)
+	assert.Zero(t, httpClient.Timeout)
+}
+
+func TestGetBatch(t *testing.T) {
+	server := newServer(t, serviceAMock)
+	c := New(WithBaseURL(server.URL), WithConcurrency(2))
+
+	results := c.GetBatch(context.Background(), []string{"32450000", "99999999", "324500000", "01021200"})
+	assert.Len(t, results, 4)
+	assert.Equal(t, "32450000", results[0].Cep)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "Ibirité", results[0].Clima.Cidade)
+	assert.ErrorIs(t, results[1].Err, ErrZipcodeNotFound)
+	assert.ErrorIs(t, results[2].Err, ErrInvalidZipcode)
+	assert.NoError(t, results[3].Err)
+}
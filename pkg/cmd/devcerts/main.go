@@ -0,0 +1,50 @@
+// Comando de desenvolvimento que gera um CA local e os certificados do service-a e do
+// service-b, utilizados no TLS dos servidores e no mTLS entre os serviços.
+//
+//	go run ./cmd/devcerts -out ../certs
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/tlsconfig"
+)
+
+func main() {
+	out := flag.String("out", "certs", "diretório onde os arquivos PEM serão gravados")
+	validity := flag.Duration("validity", 365*24*time.Hour, "validade do CA e dos certificados")
+	flag.Parse()
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	ca, err := tlsconfig.NewCA("desafio-opentelemetry dev CA", *validity)
+	if err != nil {
+		log.Fatal(err)
+	}
+	write(*out, "ca", ca.PEM)
+
+	// Cada certificado vale para o nome do serviço no docker-compose e para o acesso local
+	for _, service := range []string{"service-a", "service-b"} {
+		pair, err := ca.Issue(service, []string{service, "localhost", "127.0.0.1"}, *validity)
+		if err != nil {
+			log.Fatal(err)
+		}
+		write(*out, service, pair)
+	}
+	log.Printf("Certificados gerados em %s", *out)
+}
+
+// Grava o certificado em <nome>.pem e a chave em <nome>-key.pem
+func write(dir, name string, pair tlsconfig.PEM) {
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"), pair.Cert, 0o644); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+"-key.pem"), pair.Key, 0o600); err != nil {
+		log.Fatal(err)
+	}
+}
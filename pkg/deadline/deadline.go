@@ -0,0 +1,65 @@
+// Package deadline propaga o prazo das requisições entre os serviços. O tempo restante do
+// contexto é enviado no header X-Request-Timeout-Ms, em milissegundos, e o serviço chamado o
+// aplica ao contexto da requisição. Por ser relativo, o valor não depende do relógio dos serviços.
+// No gRPC, o prazo do contexto já é propagado pelo próprio protocolo (grpc-timeout).
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header com o tempo restante da requisição, em milissegundos
+const Header = "X-Request-Timeout-Ms"
+
+// Atributo dos spans com o tempo restante, em milissegundos, no início da etapa
+const RemainingAttribute = "deadline.remaining_ms"
+
+// Função que adiciona ao header o tempo restante do contexto. Sem prazo no contexto, o header não é enviado.
+func Inject(ctx context.Context, header http.Header) {
+	if d, ok := ctx.Deadline(); ok {
+		header.Set(Header, strconv.FormatInt(max(time.Until(d).Milliseconds(), 0), 10))
+	}
+}
+
+// Função que retorna o contexto com o prazo informado no header. O prazo só reduz o prazo
+// já existente no contexto; valores inválidos são ignorados.
+func Extract(ctx context.Context, header http.Header) (context.Context, context.CancelFunc) {
+	ms, err := strconv.ParseInt(header.Get(Header), 10, 64)
+	if err != nil || ms < 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(ms)*time.Millisecond)
+}
+
+// Middleware que aplica o prazo recebido no header ao contexto da requisição
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(Header) == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := Extract(r.Context(), r.Header)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Função que cria o contexto de uma chamada externa com a fração informada do tempo restante,
+// descontada a reserva para as etapas seguintes (como o envio da resposta). Sem prazo no
+// contexto, a chamada não recebe prazo. O tempo restante é registrado no span do contexto.
+func Budget(ctx context.Context, share float64, reserve time.Duration) (context.Context, context.CancelFunc) {
+	d, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	remaining := time.Until(d)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64(RemainingAttribute, remaining.Milliseconds()))
+	budget := time.Duration(float64(remaining-reserve) * share)
+	return context.WithTimeout(ctx, max(budget, 0))
+}
@@ -0,0 +1,98 @@
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectExtract(t *testing.T) {
+	header := http.Header{}
+	Inject(context.Background(), header)
+	assert.Empty(t, header.Get(Header), "sem prazo, o header não é enviado")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	Inject(ctx, header)
+	assert.InDelta(t, 2000, mustAtoi(t, header.Get(Header)), 50)
+
+	extracted, cancel := Extract(context.Background(), header)
+	defer cancel()
+	d, ok := extracted.Deadline()
+	require.True(t, ok)
+	assert.InDelta(t, 2*time.Second, time.Until(d), float64(100*time.Millisecond))
+
+	// O header não aumenta o prazo existente
+	short, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	extracted, cancel = Extract(short, header)
+	defer cancel()
+	d, _ = extracted.Deadline()
+	assert.LessOrEqual(t, time.Until(d), 100*time.Millisecond)
+
+	// Prazo esgotado é enviado como zero e o contexto extraído já está expirado
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	Inject(expired, header)
+	assert.Equal(t, "0", header.Get(Header))
+	extracted, cancel = Extract(context.Background(), header)
+	defer cancel()
+	assert.ErrorIs(t, extracted.Err(), context.DeadlineExceeded)
+
+	// Valor inválido é ignorado
+	header.Set(Header, "abc")
+	extracted, cancel = Extract(context.Background(), header)
+	defer cancel()
+	_, ok = extracted.Deadline()
+	assert.False(t, ok)
+}
+
+func TestMiddleware(t *testing.T) {
+	var remaining time.Duration
+	var ok bool
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d time.Time
+		d, ok = r.Context().Deadline()
+		remaining = time.Until(d)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/01001000", nil)
+	req.Header.Set(Header, "750")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, ok)
+	assert.InDelta(t, 750*time.Millisecond, remaining, float64(50*time.Millisecond))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/01001000", nil))
+	assert.False(t, ok)
+}
+
+func TestBudget(t *testing.T) {
+	ctx, cancel := Budget(context.Background(), 0.5, 0)
+	defer cancel()
+	_, ok := ctx.Deadline()
+	assert.False(t, ok, "sem prazo, a chamada não recebe prazo")
+
+	parent, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx, cancel = Budget(parent, 0.5, 200*time.Millisecond)
+	defer cancel()
+	d, ok := ctx.Deadline()
+	require.True(t, ok)
+	assert.InDelta(t, 400*time.Millisecond, time.Until(d), float64(50*time.Millisecond))
+
+	// Reserva maior que o tempo restante
+	ctx, cancel = Budget(parent, 1, 2*time.Second)
+	defer cancel()
+	assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+}
+
+func mustAtoi(t *testing.T, s string) float64 {
+	d, err := time.ParseDuration(s + "ms")
+	require.NoError(t, err)
+	return float64(d.Milliseconds())
+}
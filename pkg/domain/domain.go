@@ -0,0 +1,55 @@
+// Package domain contém os tipos do contrato JSON entre o service-a, o service-b e os clients.
+// Somente as respostas da consulta do CEP (/cep, /{cep} e as rotas /v1 e /v2) são convertidas nos
+// contratos versionados do pacote apiversion. Os eventos do stream e os resultados dos jobs do
+// service-a serializam estes tipos diretamente, então uma alteração nos campos também altera esses
+// payloads. O GraphQL, o histórico e os webhooks dos alertas copiam os campos para os próprios formatos.
+package domain
+
+// Struct que será utilizada para formar a resposta com o valor das temperaturas
+type ClimaCidade struct {
+	Cidade   string    `json:"city"`
+	TempC    float64   `json:"temp_C"`
+	TempF    float64   `json:"temp_F"`
+	TempK    float64   `json:"temp_K"`
+	Endereco *Endereco `json:"address,omitempty"`
+	Condicao string    `json:"condition,omitempty"`
+	// Leituras dos provedores de clima, presente somente quando a temperatura é a mediana ou a
+	// média de vários provedores
+	Consenso *Consenso `json:"consensus,omitempty"`
+}
+
+// Struct com o consenso entre os provedores de clima consultados
+type Consenso struct {
+	Estrategia  string            `json:"strategy"`
+	Provedores  []LeituraProvedor `json:"providers"`
+	Diferenca   float64           `json:"spread_C"`
+	Divergencia bool              `json:"disagreement"`
+}
+
+// Struct com a leitura de um provedor de clima. Os provedores com falha possuem somente o erro.
+type LeituraProvedor struct {
+	Provedor string   `json:"provider"`
+	TempC    *float64 `json:"temp_C,omitempty"`
+	Erro     string   `json:"error,omitempty"`
+}
+
+// Struct com o endereço do CEP consultado. O país é o código ISO 3166-1 alpha-2 (ex.: BR).
+type Endereco struct {
+	Cep        string `json:"cep"`
+	Logradouro string `json:"street,omitempty"`
+	Bairro     string `json:"neighborhood,omitempty"`
+	Cidade     string `json:"city"`
+	Uf         string `json:"state"`
+	Pais       string `json:"country,omitempty"`
+}
+
+// Struct que será utilizada para receber o cep da requisição. Sem país, o CEP é brasileiro.
+type DadosCep struct {
+	Cep  string `json:"cep"`
+	Pais string `json:"country,omitempty"`
+}
+
+// Função que converte a temperatura de Celsius para Kelvin
+func Kelvin(tempC float64) float64 {
+	return tempC + 273.0
+}
@@ -0,0 +1,123 @@
+package i18n
+
+// Catálogo de mensagens por idioma. A chave é a mensagem (ou o formato do fmt) em inglês, como
+// escrita no código; o inglês não possui entradas. Os formatos devem manter os mesmos verbos e
+// na mesma ordem da chave.
+var catalog = map[string]map[string]string{
+	PortugueseBR: {
+		// Títulos dos problemas
+		"invalid zipcode":        "CEP inválido",
+		"can not find zipcode":   "CEP não encontrado",
+		"invalid request":        "requisição inválida",
+		"resource not found":     "recurso não encontrado",
+		"not acceptable":         "formato não disponível",
+		"unauthorized":           "não autenticado",
+		"forbidden":              "acesso negado",
+		"conflict":               "conflito",
+		"payload too large":      "corpo da requisição muito grande",
+		"unsupported media type": "tipo de conteúdo não suportado",
+		"too many requests":      "requisições em excesso",
+		"internal server error":  "erro interno do servidor",
+		"bad gateway":            "resposta inválida do serviço externo",
+		"service unavailable":    "serviço indisponível",
+		"gateway timeout":        "tempo de resposta esgotado",
+
+		// Validação dos códigos postais
+		"the zipcode must contain exactly 8 digits":                               "o CEP deve conter exatamente 8 dígitos",
+		"the zipcode is not in a known brazilian range":                           "o CEP não pertence a nenhuma faixa conhecida dos Correios",
+		"the postal code must be in the format 1234-567":                          "o código postal deve estar no formato 1234-567",
+		"the postal code must be a CPA like C1425ABC or contain exactly 4 digits": "o código postal deve ser um CPA como C1425ABC ou conter exatamente 4 dígitos",
+		"the country must be one of %s":                                           "o país deve ser um dos seguintes: %s",
+		"zipcode %s does not exist":                                               "o CEP %s não existe",
+		"the cep query parameter is required, like %s":                            "o parâmetro cep é obrigatório, como em %s",
+
+		// Serviços externos
+		"%s is unavailable":               "%s está indisponível",
+		"%s did not respond in time":      "%s não respondeu a tempo",
+		"%s responded with status %d":     "%s respondeu com o status %d",
+		"%s returned an invalid response": "%s retornou uma resposta inválida",
+
+		// Corpo das requisições
+		"the request body must be sent with Content-Type: application/json": "o corpo da requisição deve ser enviado com o Content-Type: application/json",
+		"the request body must contain a single JSON object like %s":        "o corpo da requisição deve conter um único objeto JSON como %s",
+		"the request body must be a JSON object like %s":                    "o corpo da requisição deve ser um objeto JSON como %s",
+		"the request body is empty, expected a JSON object like %s":         "o corpo da requisição está vazio, era esperado um objeto JSON como %s",
+		"the field %q must be a %s":                                         "o campo %q deve ser do tipo %s",
+		"the field %q is not allowed, expected a JSON object like %s":       "o campo %q não é permitido, era esperado um objeto JSON como %s",
+		"the request body must have at most %d bytes":                       "o corpo da requisição deve ter no máximo %d bytes",
+
+		// Formatos e versões das respostas
+		"the response is available as %s": "a resposta está disponível como %s",
+		"the %s header must be one of %s": "o header %s deve ser um dos seguintes: %s",
+
+		// Autenticação e rate limit
+		"the request must have an X-API-Key header or an Authorization: Bearer token": "a requisição deve conter o header X-API-Key ou um token Authorization: Bearer",
+		"the credentials are invalid or expired":                                      "as credenciais são inválidas ou expiraram",
+		"the request is not authenticated":                                            "a requisição não está autenticada",
+		"the %s scope is required":                                                    "o escopo %s é obrigatório",
+		"the %s plan allows %d requests every %s":                                     "o plano %s permite %d requisições a cada %s",
+		"the request contains %d zipcodes and the %s plan allows at most %d at once":  "a requisição contém %d CEPs e o plano %s permite no máximo %d de uma vez",
+
+		// Jobs
+		"the job must contain between 1 and %d zipcodes": "o job deve conter entre 1 e %d CEPs",
+		"the job is %s: %d of %d zipcodes processed":     "o job está %s: %d de %d CEPs processados",
+	},
+	Spanish: {
+		// Títulos dos problemas
+		"invalid zipcode":        "código postal inválido",
+		"can not find zipcode":   "código postal no encontrado",
+		"invalid request":        "solicitud inválida",
+		"resource not found":     "recurso no encontrado",
+		"not acceptable":         "formato no disponible",
+		"unauthorized":           "no autenticado",
+		"forbidden":              "acceso denegado",
+		"conflict":               "conflicto",
+		"payload too large":      "cuerpo de la solicitud demasiado grande",
+		"unsupported media type": "tipo de contenido no soportado",
+		"too many requests":      "demasiadas solicitudes",
+		"internal server error":  "error interno del servidor",
+		"bad gateway":            "respuesta inválida del servicio externo",
+		"service unavailable":    "servicio no disponible",
+		"gateway timeout":        "tiempo de respuesta agotado",
+
+		// Validação dos códigos postais
+		"the zipcode must contain exactly 8 digits":                               "el código postal debe contener exactamente 8 dígitos",
+		"the zipcode is not in a known brazilian range":                           "el código postal no pertenece a ningún rango conocido de Brasil",
+		"the postal code must be in the format 1234-567":                          "el código postal debe tener el formato 1234-567",
+		"the postal code must be a CPA like C1425ABC or contain exactly 4 digits": "el código postal debe ser un CPA como C1425ABC o contener exactamente 4 dígitos",
+		"the country must be one of %s":                                           "el país debe ser uno de los siguientes: %s",
+		"zipcode %s does not exist":                                               "el código postal %s no existe",
+		"the cep query parameter is required, like %s":                            "el parámetro cep es obligatorio, como en %s",
+
+		// Serviços externos
+		"%s is unavailable":               "%s no está disponible",
+		"%s did not respond in time":      "%s no respondió a tiempo",
+		"%s responded with status %d":     "%s respondió con el estado %d",
+		"%s returned an invalid response": "%s devolvió una respuesta inválida",
+
+		// Corpo das requisições
+		"the request body must be sent with Content-Type: application/json": "el cuerpo de la solicitud debe enviarse con Content-Type: application/json",
+		"the request body must contain a single JSON object like %s":        "el cuerpo de la solicitud debe contener un único objeto JSON como %s",
+		"the request body must be a JSON object like %s":                    "el cuerpo de la solicitud debe ser un objeto JSON como %s",
+		"the request body is empty, expected a JSON object like %s":         "el cuerpo de la solicitud está vacío, se esperaba un objeto JSON como %s",
+		"the field %q must be a %s":                                         "el campo %q debe ser de tipo %s",
+		"the field %q is not allowed, expected a JSON object like %s":       "el campo %q no está permitido, se esperaba un objeto JSON como %s",
+		"the request body must have at most %d bytes":                       "el cuerpo de la solicitud debe tener como máximo %d bytes",
+
+		// Formatos e versões das respostas
+		"the response is available as %s": "la respuesta está disponible como %s",
+		"the %s header must be one of %s": "el header %s debe ser uno de los siguientes: %s",
+
+		// Autenticação e rate limit
+		"the request must have an X-API-Key header or an Authorization: Bearer token": "la solicitud debe contener el header X-API-Key o un token Authorization: Bearer",
+		"the credentials are invalid or expired":                                      "las credenciales son inválidas o han expirado",
+		"the request is not authenticated":                                            "la solicitud no está autenticada",
+		"the %s scope is required":                                                    "el alcance %s es obligatorio",
+		"the %s plan allows %d requests every %s":                                     "el plan %s permite %d solicitudes cada %s",
+		"the request contains %d zipcodes and the %s plan allows at most %d at once":  "la solicitud contiene %d códigos postales y el plan %s permite como máximo %d a la vez",
+
+		// Jobs
+		"the job must contain between 1 and %d zipcodes": "el job debe contener entre 1 y %d códigos postales",
+		"the job is %s: %d of %d zipcodes processed":     "el job está %s: %d de %d códigos postales procesados",
+	},
+}
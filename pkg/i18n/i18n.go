@@ -0,0 +1,168 @@
+// Package i18n negocia o idioma das respostas a partir do header Accept-Language e traduz as
+// mensagens pelo catálogo. As mensagens são escritas em inglês no código e utilizadas como chave
+// do catálogo; mensagens sem tradução são mantidas em inglês. O idioma negociado fica no contexto
+// e é propagado entre os serviços no próprio Accept-Language (no gRPC, no metadata accept-language).
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Header utilizado na negociação e na propagação do idioma
+const Header = "Accept-Language"
+
+// Chave do metadata gRPC com o idioma
+const MetadataKey = "accept-language"
+
+// Idiomas suportados (tags BCP 47)
+const (
+	English      = "en"
+	PortugueseBR = "pt-BR"
+	Spanish      = "es"
+)
+
+// Idioma utilizado quando o cliente não informa o Accept-Language ou não aceita nenhum dos
+// idiomas suportados. As mensagens originais dos serviços são em inglês.
+const Default = English
+
+type contextKey struct{}
+
+// Função que retorna os idiomas suportados
+func Languages() []string {
+	return []string{English, PortugueseBR, Spanish}
+}
+
+// Função que escolhe o idioma suportado de maior preferência no valor do Accept-Language
+// (ex.: "pt-BR,pt;q=0.9,en;q=0.8"). As tags são comparadas sem diferenciar maiúsculas e, sem
+// correspondência exata, pelo idioma principal: pt-PT resulta em pt-BR e es-AR em es.
+func Negotiate(acceptLanguage string) string {
+	type opcao struct {
+		tag string
+		q   float64
+	}
+	var opcoes []opcao
+	for _, parte := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(parte), ";")
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		if valor, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(valor, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			opcoes = append(opcoes, opcao{strings.TrimSpace(tag), q})
+		}
+	}
+	sort.SliceStable(opcoes, func(i, j int) bool { return opcoes[i].q > opcoes[j].q })
+
+	for _, o := range opcoes {
+		if lang, ok := match(o.tag); ok {
+			return lang
+		}
+	}
+	return Default
+}
+
+// Busca o idioma suportado correspondente à tag, primeiro pela tag completa e depois pelo idioma principal
+func match(tag string) (string, bool) {
+	if tag == "*" {
+		return Default, true
+	}
+	for _, lang := range Languages() {
+		if strings.EqualFold(tag, lang) {
+			return lang, true
+		}
+	}
+	principal, _, _ := strings.Cut(tag, "-")
+	for _, lang := range Languages() {
+		if base, _, _ := strings.Cut(lang, "-"); strings.EqualFold(principal, base) {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// Função que retorna o contexto com o idioma informado
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, contextKey{}, lang)
+}
+
+// Função que retorna o idioma do contexto e se ele foi negociado
+func FromContext(ctx context.Context) (string, bool) {
+	lang, ok := ctx.Value(contextKey{}).(string)
+	return lang, ok
+}
+
+// Função que retorna o idioma do contexto, ou o Default quando não há idioma no contexto
+func Language(ctx context.Context) string {
+	if lang, ok := FromContext(ctx); ok {
+		return lang
+	}
+	return Default
+}
+
+// Middleware que negocia o idioma da requisição e o adiciona ao contexto. A resposta informa
+// o idioma no header Content-Language.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := Negotiate(r.Header.Get(Header))
+		w.Header().Set("Content-Language", lang)
+		w.Header().Add("Vary", Header)
+		next.ServeHTTP(w, r.WithContext(WithLanguage(r.Context(), lang)))
+	})
+}
+
+// Função que adiciona ao header o idioma negociado. Sem idioma no contexto, o header não é enviado.
+func Inject(ctx context.Context, header http.Header) {
+	if lang, ok := FromContext(ctx); ok {
+		header.Set(Header, lang)
+	}
+}
+
+// Função que adiciona o idioma do contexto ao metadata da chamada gRPC
+func OutgoingContext(ctx context.Context) context.Context {
+	if lang, ok := FromContext(ctx); ok {
+		return metadata.AppendToOutgoingContext(ctx, MetadataKey, lang)
+	}
+	return ctx
+}
+
+// Função que retorna o contexto com o idioma negociado a partir do metadata recebido na chamada gRPC
+func IncomingContext(ctx context.Context) context.Context {
+	if valores := metadata.ValueFromIncomingContext(ctx, MetadataKey); len(valores) > 0 {
+		return WithLanguage(ctx, Negotiate(strings.Join(valores, ",")))
+	}
+	return ctx
+}
+
+// Função que traduz a mensagem para o idioma informado. Mensagens sem tradução são retornadas sem alteração.
+func T(lang, msg string) string {
+	if traducao, ok := catalog[lang][msg]; ok {
+		return traducao
+	}
+	return msg
+}
+
+// Função que traduz o formato para o idioma informado e o aplica aos argumentos
+func Sprintf(lang, format string, args ...any) string {
+	return fmt.Sprintf(T(lang, format), args...)
+}
+
+// Função que retorna o idioma principal da tag (ex.: pt-BR resulta em pt), utilizado pelas APIs
+// externas que não aceitam a região
+func Base(lang string) string {
+	base, _, _ := strings.Cut(lang, "-")
+	return strings.ToLower(base)
+}
@@ -0,0 +1,98 @@
+package i18n
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestNegotiate(t *testing.T) {
+	testes := []struct {
+		header string
+		lang   string
+	}{
+		{"", English},
+		{"pt-BR", PortugueseBR},
+		{"pt-br,en;q=0.5", PortugueseBR},
+		{"pt-PT", PortugueseBR},
+		{"es-AR,es;q=0.9", Spanish},
+		{"fr-FR,es;q=0.4,en;q=0.8", English},
+		{"en;q=0.1, pt;q=0.9", PortugueseBR},
+		{"es;q=0,pt", PortugueseBR},
+		{"fr, de", English},
+		{"*", English},
+		{"pt;q=abc,es", Spanish},
+	}
+	for _, tt := range testes {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.lang, Negotiate(tt.header))
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "CEP inválido", T(PortugueseBR, "invalid zipcode"))
+	assert.Equal(t, "código postal inválido", T(Spanish, "invalid zipcode"))
+	assert.Equal(t, "invalid zipcode", T(English, "invalid zipcode"))
+	assert.Equal(t, "sem tradução", T(PortugueseBR, "sem tradução"))
+	assert.Equal(t, "o escopo cep:read é obrigatório", Sprintf(PortugueseBR, "the %s scope is required", "cep:read"))
+	assert.Equal(t, "pt", Base(PortugueseBR))
+	assert.Equal(t, "es", Base(Spanish))
+}
+
+// Os formatos traduzidos devem manter os verbos da chave, na mesma ordem
+func TestCatalogVerbos(t *testing.T) {
+	verbos := func(s string) []string {
+		var v []string
+		for i := 0; i < len(s)-1; i++ {
+			if s[i] == '%' {
+				v = append(v, s[i:i+2])
+				i++
+			}
+		}
+		return v
+	}
+	for lang, mensagens := range catalog {
+		for chave, traducao := range mensagens {
+			assert.Equal(t, verbos(chave), verbos(traducao), "%s: %s", lang, chave)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var lang string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang = Language(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(Header, "es")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, Spanish, lang)
+	assert.Equal(t, Spanish, w.Header().Get("Content-Language"))
+	assert.Equal(t, Header, w.Header().Get("Vary"))
+
+	assert.Equal(t, Default, Language(context.Background()))
+}
+
+// O idioma do contexto é propagado no header HTTP e no metadata gRPC
+func TestPropagacao(t *testing.T) {
+	header := http.Header{}
+	Inject(context.Background(), header)
+	assert.Empty(t, header.Get(Header), "sem idioma no contexto, o header não é enviado")
+
+	ctx := WithLanguage(context.Background(), PortugueseBR)
+	Inject(ctx, header)
+	assert.Equal(t, PortugueseBR, header.Get(Header))
+
+	md, _ := metadata.FromOutgoingContext(OutgoingContext(ctx))
+	incoming := IncomingContext(metadata.NewIncomingContext(context.Background(), md))
+	assert.Equal(t, PortugueseBR, Language(incoming))
+
+	_, ok := FromContext(IncomingContext(context.Background()))
+	assert.False(t, ok)
+}
@@ -0,0 +1,10 @@
+// Inicializa o Swagger UI com a especificação informada no atributo data-spec-url
+window.addEventListener("load", function () {
+  var el = document.getElementById("swagger-ui");
+  window.ui = SwaggerUIBundle({
+    url: el.getAttribute("data-spec-url"),
+    dom_id: "#swagger-ui",
+    validatorUrl: null,
+    deepLinking: true
+  });
+});
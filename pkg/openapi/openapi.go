@@ -0,0 +1,299 @@
+package openapi
+
+import (
+	"embed"
+	"encoding/json"
+	"html"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Versão da especificação OpenAPI gerada
+const Version = "3.1.0"
+
+// Struct raiz do documento OpenAPI
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Operações de um path, indexadas pelo método HTTP
+type PathItem struct {
+	Get    *Operation `json:"get,omitempty"`
+	Post   *Operation `json:"post,omitempty"`
+	Put    *Operation `json:"put,omitempty"`
+	Patch  *Operation `json:"patch,omitempty"`
+	Delete *Operation `json:"delete,omitempty"`
+}
+
+type Operation struct {
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationID string               `json:"operationId,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Operação obsoleta, mantida por compatibilidade
+	Deprecated bool `json:"deprecated,omitempty"`
+	// Alternativas de autenticação aceitas pela operação
+	Security []SecurityRequirement `json:"security,omitempty"`
+}
+
+// Esquemas de autenticação exigidos, com os escopos necessários em cada um
+type SecurityRequirement map[string][]string
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required"`
+	Schema      *Schema `json:"schema,omitempty"`
+	Example     any     `json:"example,omitempty"`
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema  *Schema `json:"schema,omitempty"`
+	Example any     `json:"example,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// Esquema de autenticação (API key no header ou token bearer no header Authorization)
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Subconjunto do JSON Schema utilizado nos documentos
+type Schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Example     any                `json:"example,omitempty"`
+}
+
+// Função que cria um novo documento vazio
+func New(title, description, version string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       title,
+			Description: description,
+			Version:     version,
+		},
+		Paths: map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+		},
+	}
+}
+
+// Função que registra a operação no path e método informados
+func (d *Document) AddOperation(method, path string, op *Operation) {
+	item, ok := d.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+	switch strings.ToUpper(method) {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPost:
+		item.Post = op
+	case http.MethodPut:
+		item.Put = op
+	case http.MethodPatch:
+		item.Patch = op
+	case http.MethodDelete:
+		item.Delete = op
+	}
+}
+
+// Função que gera o schema do valor informado e o registra em components/schemas.
+// Retorna a referência para ser utilizada nas operações.
+func (d *Document) AddSchema(name string, v any) *Schema {
+	d.Components.Schemas[name] = SchemaOf(v)
+	return Ref(name)
+}
+
+// Função que registra o esquema de autenticação em components/securitySchemes
+func (d *Document) AddSecurityScheme(name string, scheme *SecurityScheme) {
+	if d.Components.SecuritySchemes == nil {
+		d.Components.SecuritySchemes = map[string]*SecurityScheme{}
+	}
+	d.Components.SecuritySchemes[name] = scheme
+}
+
+// Retorna as operações do documento no formato "MÉTODO path"
+func (d *Document) Routes() []string {
+	var routes []string
+	for path, item := range d.Paths {
+		for method, op := range map[string]*Operation{
+			http.MethodGet:    item.Get,
+			http.MethodPost:   item.Post,
+			http.MethodPut:    item.Put,
+			http.MethodPatch:  item.Patch,
+			http.MethodDelete: item.Delete,
+		} {
+			if op != nil {
+				routes = append(routes, method+" "+path)
+			}
+		}
+	}
+	return routes
+}
+
+// Referência para um schema registrado em components/schemas
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// Função que gera o JSON Schema a partir do tipo Go, utilizando as tags json dos campos.
+// Campos sem omitempty são considerados obrigatórios.
+func SchemaOf(v any) *Schema {
+	return schemaOfType(reflect.TypeOf(v))
+}
+
+func schemaOfType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOfType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			schema.Properties[name] = schemaOfType(field.Type)
+			if !strings.Contains(opts, "omitempty") {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		return schema
+	}
+	return &Schema{}
+}
+
+// Handler que publica o documento em JSON
+func Handler(doc *Document) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(doc)
+	}
+}
+
+// Path em que os arquivos estáticos da página de documentação são publicados
+const AssetsPath = "/docs/assets/"
+
+// Arquivos do Swagger UI (versão fixa, ver assets/LICENSE) e o script que inicializa a página.
+// Os arquivos são publicados pelo próprio serviço, sem depender de um CDN.
+//
+//go:embed assets
+var assets embed.FS
+
+// Página do Swagger UI que renderiza o documento publicado em specURL
+const docsPage = `<!DOCTYPE html>
+<html>
+  <head>
+    <title>{{TITLE}}</title>
+    <meta charset="utf-8"/>
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <link rel="stylesheet" href="{{ASSETS}}swagger-ui.css">
+  </head>
+  <body>
+    <div id="swagger-ui" data-spec-url="{{SPEC_URL}}"></div>
+    <script src="{{ASSETS}}swagger-ui-bundle.js"></script>
+    <script src="{{ASSETS}}docs.js"></script>
+  </body>
+</html>
+`
+
+// Handler que publica a página de documentação (Swagger UI)
+func DocsHandler(title, specURL string) http.HandlerFunc {
+	page := strings.NewReplacer(
+		"{{TITLE}}", html.EscapeString(title),
+		"{{SPEC_URL}}", html.EscapeString(specURL),
+		"{{ASSETS}}", AssetsPath,
+	).Replace(docsPage)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}
+}
+
+// Handler que publica os arquivos estáticos da página de documentação em AssetsPath
+func AssetsHandler() http.HandlerFunc {
+	sub, err := fs.Sub(assets, "assets")
+	if err != nil {
+		panic(err)
+	}
+	files := http.StripPrefix(AssetsPath, http.FileServer(http.FS(sub)))
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		files.ServeHTTP(w, r)
+	}
+}
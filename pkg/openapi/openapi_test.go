@@ -0,0 +1,30 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// O schema deve ser gerado a partir das tags json, e os campos sem omitempty são obrigatórios
+func TestSchemaOf(t *testing.T) {
+	type endereco struct {
+		Cep         string   `json:"cep"`
+		Complemento string   `json:"complemento,omitempty"`
+		Temperatura float64  `json:"temp_C"`
+		Ddd         int      `json:"ddd"`
+		Erro        bool     `json:"erro,omitempty"`
+		Tags        []string `json:"tags,omitempty"`
+		Ignorado    string   `json:"-"`
+	}
+
+	schema := SchemaOf(&endereco{})
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"cep", "temp_C", "ddd"}, schema.Required)
+	assert.Equal(t, "string", schema.Properties["cep"].Type)
+	assert.Equal(t, "number", schema.Properties["temp_C"].Type)
+	assert.Equal(t, "integer", schema.Properties["ddd"].Type)
+	assert.Equal(t, "boolean", schema.Properties["erro"].Type)
+	assert.Equal(t, "string", schema.Properties["tags"].Items.Type)
+	assert.NotContains(t, schema.Properties, "Ignorado")
+}
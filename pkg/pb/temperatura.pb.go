@@ -0,0 +1,681 @@
+// Contrato gRPC entre o service-a e o service-b.
+//
+// O código Go é gerado no pacote pb do módulo comum (pkg), utilizado pelos dois serviços.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        v5.27.1
+// source: temperatura/v1/temperatura.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetByCEPRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cep string `protobuf:"bytes,1,opt,name=cep,proto3" json:"cep,omitempty"`
+	// País do código postal (BR, PT ou AR). Vazio é BR.
+	Country string `protobuf:"bytes,2,opt,name=country,proto3" json:"country,omitempty"`
+}
+
+func (x *GetByCEPRequest) Reset() {
+	*x = GetByCEPRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_temperatura_v1_temperatura_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetByCEPRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetByCEPRequest) ProtoMessage() {}
+
+func (x *GetByCEPRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_temperatura_v1_temperatura_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetByCEPRequest.ProtoReflect.Descriptor instead.
+func (*GetByCEPRequest) Descriptor() ([]byte, []int) {
+	return file_temperatura_v1_temperatura_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetByCEPRequest) GetCep() string {
+	if x != nil {
+		return x.Cep
+	}
+	return ""
+}
+
+func (x *GetByCEPRequest) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+type GetManyRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ceps []string `protobuf:"bytes,1,rep,name=ceps,proto3" json:"ceps,omitempty"`
+	// País de todos os códigos postais consultados (BR, PT ou AR). Vazio é BR.
+	Country string `protobuf:"bytes,2,opt,name=country,proto3" json:"country,omitempty"`
+}
+
+func (x *GetManyRequest) Reset() {
+	*x = GetManyRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_temperatura_v1_temperatura_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetManyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetManyRequest) ProtoMessage() {}
+
+func (x *GetManyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_temperatura_v1_temperatura_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetManyRequest.ProtoReflect.Descriptor instead.
+func (*GetManyRequest) Descriptor() ([]byte, []int) {
+	return file_temperatura_v1_temperatura_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetManyRequest) GetCeps() []string {
+	if x != nil {
+		return x.Ceps
+	}
+	return nil
+}
+
+func (x *GetManyRequest) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+type ClimaCidade struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	City      string    `protobuf:"bytes,1,opt,name=city,proto3" json:"city,omitempty"`
+	TempC     float64   `protobuf:"fixed64,2,opt,name=temp_c,json=tempC,proto3" json:"temp_c,omitempty"`
+	TempF     float64   `protobuf:"fixed64,3,opt,name=temp_f,json=tempF,proto3" json:"temp_f,omitempty"`
+	TempK     float64   `protobuf:"fixed64,4,opt,name=temp_k,json=tempK,proto3" json:"temp_k,omitempty"`
+	Address   *Endereco `protobuf:"bytes,5,opt,name=address,proto3" json:"address,omitempty"`
+	Condition string    `protobuf:"bytes,6,opt,name=condition,proto3" json:"condition,omitempty"`
+}
+
+func (x *ClimaCidade) Reset() {
+	*x = ClimaCidade{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_temperatura_v1_temperatura_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClimaCidade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClimaCidade) ProtoMessage() {}
+
+func (x *ClimaCidade) ProtoReflect() protoreflect.Message {
+	mi := &file_temperatura_v1_temperatura_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClimaCidade.ProtoReflect.Descriptor instead.
+func (*ClimaCidade) Descriptor() ([]byte, []int) {
+	return file_temperatura_v1_temperatura_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ClimaCidade) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *ClimaCidade) GetTempC() float64 {
+	if x != nil {
+		return x.TempC
+	}
+	return 0
+}
+
+func (x *ClimaCidade) GetTempF() float64 {
+	if x != nil {
+		return x.TempF
+	}
+	return 0
+}
+
+func (x *ClimaCidade) GetTempK() float64 {
+	if x != nil {
+		return x.TempK
+	}
+	return 0
+}
+
+func (x *ClimaCidade) GetAddress() *Endereco {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *ClimaCidade) GetCondition() string {
+	if x != nil {
+		return x.Condition
+	}
+	return ""
+}
+
+// Endereço do código postal consultado no provedor de endereços do país
+type Endereco struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cep          string `protobuf:"bytes,1,opt,name=cep,proto3" json:"cep,omitempty"`
+	Street       string `protobuf:"bytes,2,opt,name=street,proto3" json:"street,omitempty"`
+	Neighborhood string `protobuf:"bytes,3,opt,name=neighborhood,proto3" json:"neighborhood,omitempty"`
+	City         string `protobuf:"bytes,4,opt,name=city,proto3" json:"city,omitempty"`
+	State        string `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+	Country      string `protobuf:"bytes,6,opt,name=country,proto3" json:"country,omitempty"`
+}
+
+func (x *Endereco) Reset() {
+	*x = Endereco{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_temperatura_v1_temperatura_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Endereco) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Endereco) ProtoMessage() {}
+
+func (x *Endereco) ProtoReflect() protoreflect.Message {
+	mi := &file_temperatura_v1_temperatura_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Endereco.ProtoReflect.Descriptor instead.
+func (*Endereco) Descriptor() ([]byte, []int) {
+	return file_temperatura_v1_temperatura_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Endereco) GetCep() string {
+	if x != nil {
+		return x.Cep
+	}
+	return ""
+}
+
+func (x *Endereco) GetStreet() string {
+	if x != nil {
+		return x.Street
+	}
+	return ""
+}
+
+func (x *Endereco) GetNeighborhood() string {
+	if x != nil {
+		return x.Neighborhood
+	}
+	return ""
+}
+
+func (x *Endereco) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *Endereco) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Endereco) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+// Erro de um CEP consultado pelo GetMany
+type Error struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Código gRPC equivalente ao erro (ex.: NOT_FOUND)
+	Code    int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Detail  string `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
+	TraceId string `protobuf:"bytes,4,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_temperatura_v1_temperatura_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_temperatura_v1_temperatura_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_temperatura_v1_temperatura_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Error) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Error) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+func (x *Error) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+type GetManyResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Cep string `protobuf:"bytes,1,opt,name=cep,proto3" json:"cep,omitempty"`
+	// Types that are assignable to Result:
+	//	*GetManyResponse_Clima
+	//	*GetManyResponse_Error
+	Result isGetManyResponse_Result `protobuf_oneof:"result"`
+}
+
+func (x *GetManyResponse) Reset() {
+	*x = GetManyResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_temperatura_v1_temperatura_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetManyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetManyResponse) ProtoMessage() {}
+
+func (x *GetManyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_temperatura_v1_temperatura_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetManyResponse.ProtoReflect.Descriptor instead.
+func (*GetManyResponse) Descriptor() ([]byte, []int) {
+	return file_temperatura_v1_temperatura_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetManyResponse) GetCep() string {
+	if x != nil {
+		return x.Cep
+	}
+	return ""
+}
+
+func (m *GetManyResponse) GetResult() isGetManyResponse_Result {
+	if m != nil {
+		return m.Result
+	}
+	return nil
+}
+
+func (x *GetManyResponse) GetClima() *ClimaCidade {
+	if x, ok := x.GetResult().(*GetManyResponse_Clima); ok {
+		return x.Clima
+	}
+	return nil
+}
+
+func (x *GetManyResponse) GetError() *Error {
+	if x, ok := x.GetResult().(*GetManyResponse_Error); ok {
+		return x.Error
+	}
+	return nil
+}
+
+type isGetManyResponse_Result interface {
+	isGetManyResponse_Result()
+}
+
+type GetManyResponse_Clima struct {
+	Clima *ClimaCidade `protobuf:"bytes,2,opt,name=clima,proto3,oneof"`
+}
+
+type GetManyResponse_Error struct {
+	Error *Error `protobuf:"bytes,3,opt,name=error,proto3,oneof"`
+}
+
+func (*GetManyResponse_Clima) isGetManyResponse_Result() {}
+
+func (*GetManyResponse_Error) isGetManyResponse_Result() {}
+
+var File_temperatura_v1_temperatura_proto protoreflect.FileDescriptor
+
+var file_temperatura_v1_temperatura_proto_rawDesc = []byte{
+	0x0a, 0x20, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x61, 0x2f, 0x76, 0x31,
+	0x2f, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x61, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x0e, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x61, 0x2e,
+	0x76, 0x31, 0x22, 0x3d, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x42, 0x79, 0x43, 0x45, 0x50, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x65, 0x70, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x63, 0x65, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72,
+	0x79, 0x22, 0x3e, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x4d, 0x61, 0x6e, 0x79, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x65, 0x70, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x04, 0x63, 0x65, 0x70, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72,
+	0x79, 0x22, 0xb8, 0x01, 0x0a, 0x0b, 0x43, 0x6c, 0x69, 0x6d, 0x61, 0x43, 0x69, 0x64, 0x61, 0x64,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x69, 0x74, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x63, 0x69, 0x74, 0x79, 0x12, 0x15, 0x0a, 0x06, 0x74, 0x65, 0x6d, 0x70, 0x5f, 0x63, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x74, 0x65, 0x6d, 0x70, 0x43, 0x12, 0x15, 0x0a, 0x06,
+	0x74, 0x65, 0x6d, 0x70, 0x5f, 0x66, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x74, 0x65,
+	0x6d, 0x70, 0x46, 0x12, 0x15, 0x0a, 0x06, 0x74, 0x65, 0x6d, 0x70, 0x5f, 0x6b, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x05, 0x74, 0x65, 0x6d, 0x70, 0x4b, 0x12, 0x32, 0x0a, 0x07, 0x61, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x74, 0x65,
+	0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x6e, 0x64,
+	0x65, 0x72, 0x65, 0x63, 0x6f, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1c,
+	0x0a, 0x09, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x63, 0x6f, 0x6e, 0x64, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x9c, 0x01, 0x0a,
+	0x08, 0x45, 0x6e, 0x64, 0x65, 0x72, 0x65, 0x63, 0x6f, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x65, 0x70,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x65, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x74, 0x72, 0x65, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x74, 0x72,
+	0x65, 0x65, 0x74, 0x12, 0x22, 0x0a, 0x0c, 0x6e, 0x65, 0x69, 0x67, 0x68, 0x62, 0x6f, 0x72, 0x68,
+	0x6f, 0x6f, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6e, 0x65, 0x69, 0x67, 0x68,
+	0x62, 0x6f, 0x72, 0x68, 0x6f, 0x6f, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x69, 0x74, 0x79, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x69, 0x74, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x74, 0x61, 0x74,
+	0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x22, 0x68, 0x0a, 0x05, 0x45,
+	0x72, 0x72, 0x6f, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x72,
+	0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x72,
+	0x61, 0x63, 0x65, 0x49, 0x64, 0x22, 0x91, 0x01, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x4d, 0x61, 0x6e,
+	0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x65, 0x70,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x65, 0x70, 0x12, 0x33, 0x0a, 0x05, 0x63,
+	0x6c, 0x69, 0x6d, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x74, 0x65, 0x6d,
+	0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6c, 0x69, 0x6d,
+	0x61, 0x43, 0x69, 0x64, 0x61, 0x64, 0x65, 0x48, 0x00, 0x52, 0x05, 0x63, 0x6c, 0x69, 0x6d, 0x61,
+	0x12, 0x2d, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x61, 0x2e, 0x76, 0x31,
+	0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42,
+	0x08, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x32, 0xac, 0x01, 0x0a, 0x12, 0x54, 0x65,
+	0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x12, 0x48, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x42, 0x79, 0x43, 0x45, 0x50, 0x12, 0x1f, 0x2e, 0x74,
+	0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65,
+	0x74, 0x42, 0x79, 0x43, 0x45, 0x50, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e,
+	0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74, 0x75, 0x72, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x43,
+	0x6c, 0x69, 0x6d, 0x61, 0x43, 0x69, 0x64, 0x61, 0x64, 0x65, 0x12, 0x4c, 0x0a, 0x07, 0x47, 0x65,
+	0x74, 0x4d, 0x61, 0x6e, 0x79, 0x12, 0x1e, 0x2e, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74,
+	0x75, 0x72, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x4d, 0x61, 0x6e, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72, 0x61, 0x74,
+	0x75, 0x72, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x4d, 0x61, 0x6e, 0x79, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x42, 0x39, 0x5a, 0x37, 0x67, 0x69, 0x74, 0x68,
+	0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x77, 0x61, 0x6e, 0x64, 0x65, 0x72, 0x6d, 0x61, 0x69,
+	0x61, 0x2f, 0x64, 0x65, 0x73, 0x61, 0x66, 0x69, 0x6f, 0x2d, 0x74, 0x65, 0x6d, 0x70, 0x65, 0x72,
+	0x61, 0x74, 0x75, 0x72, 0x61, 0x2d, 0x63, 0x65, 0x70, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x70, 0x62,
+	0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_temperatura_v1_temperatura_proto_rawDescOnce sync.Once
+	file_temperatura_v1_temperatura_proto_rawDescData = file_temperatura_v1_temperatura_proto_rawDesc
+)
+
+func file_temperatura_v1_temperatura_proto_rawDescGZIP() []byte {
+	file_temperatura_v1_temperatura_proto_rawDescOnce.Do(func() {
+		file_temperatura_v1_temperatura_proto_rawDescData = protoimpl.X.CompressGZIP(file_temperatura_v1_temperatura_proto_rawDescData)
+	})
+	return file_temperatura_v1_temperatura_proto_rawDescData
+}
+
+var file_temperatura_v1_temperatura_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_temperatura_v1_temperatura_proto_goTypes = []interface{}{
+	(*GetByCEPRequest)(nil), // 0: temperatura.v1.GetByCEPRequest
+	(*GetManyRequest)(nil),  // 1: temperatura.v1.GetManyRequest
+	(*ClimaCidade)(nil),     // 2: temperatura.v1.ClimaCidade
+	(*Endereco)(nil),        // 3: temperatura.v1.Endereco
+	(*Error)(nil),           // 4: temperatura.v1.Error
+	(*GetManyResponse)(nil), // 5: temperatura.v1.GetManyResponse
+}
+var file_temperatura_v1_temperatura_proto_depIdxs = []int32{
+	3, // 0: temperatura.v1.ClimaCidade.address:type_name -> temperatura.v1.Endereco
+	2, // 1: temperatura.v1.GetManyResponse.clima:type_name -> temperatura.v1.ClimaCidade
+	4, // 2: temperatura.v1.GetManyResponse.error:type_name -> temperatura.v1.Error
+	0, // 3: temperatura.v1.TemperatureService.GetByCEP:input_type -> temperatura.v1.GetByCEPRequest
+	1, // 4: temperatura.v1.TemperatureService.GetMany:input_type -> temperatura.v1.GetManyRequest
+	2, // 5: temperatura.v1.TemperatureService.GetByCEP:output_type -> temperatura.v1.ClimaCidade
+	5, // 6: temperatura.v1.TemperatureService.GetMany:output_type -> temperatura.v1.GetManyResponse
+	5, // [5:7] is the sub-list for method output_type
+	3, // [3:5] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_temperatura_v1_temperatura_proto_init() }
+func file_temperatura_v1_temperatura_proto_init() {
+	if File_temperatura_v1_temperatura_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_temperatura_v1_temperatura_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetByCEPRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_temperatura_v1_temperatura_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetManyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_temperatura_v1_temperatura_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClimaCidade); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_temperatura_v1_temperatura_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Endereco); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_temperatura_v1_temperatura_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Error); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_temperatura_v1_temperatura_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetManyResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_temperatura_v1_temperatura_proto_msgTypes[5].OneofWrappers = []interface{}{
+		(*GetManyResponse_Clima)(nil),
+		(*GetManyResponse_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_temperatura_v1_temperatura_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_temperatura_v1_temperatura_proto_goTypes,
+		DependencyIndexes: file_temperatura_v1_temperatura_proto_depIdxs,
+		MessageInfos:      file_temperatura_v1_temperatura_proto_msgTypes,
+	}.Build()
+	File_temperatura_v1_temperatura_proto = out.File
+	file_temperatura_v1_temperatura_proto_rawDesc = nil
+	file_temperatura_v1_temperatura_proto_goTypes = nil
+	file_temperatura_v1_temperatura_proto_depIdxs = nil
+}
@@ -0,0 +1,194 @@
+// Contrato gRPC entre o service-a e o service-b.
+//
+// O código Go é gerado no pacote pb do módulo comum (pkg), utilizado pelos dois serviços.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             v5.27.1
+// source: temperatura/v1/temperatura.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	TemperatureService_GetByCEP_FullMethodName = "/temperatura.v1.TemperatureService/GetByCEP"
+	TemperatureService_GetMany_FullMethodName  = "/temperatura.v1.TemperatureService/GetMany"
+)
+
+// TemperatureServiceClient is the client API for TemperatureService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Serviço de consulta de temperatura por CEP
+type TemperatureServiceClient interface {
+	// Consulta a temperatura atual da cidade do CEP.
+	// Erros: INVALID_ARGUMENT (CEP com formato inválido), NOT_FOUND (CEP não encontrado),
+	// UNAVAILABLE, DEADLINE_EXCEEDED e INTERNAL (falhas no ViaCEP ou na WeatherAPI).
+	GetByCEP(ctx context.Context, in *GetByCEPRequest, opts ...grpc.CallOption) (*ClimaCidade, error)
+	// Consulta vários CEPs. Cada resultado é enviado no stream assim que fica pronto,
+	// e o erro de um CEP não interrompe os demais.
+	GetMany(ctx context.Context, in *GetManyRequest, opts ...grpc.CallOption) (TemperatureService_GetManyClient, error)
+}
+
+type temperatureServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTemperatureServiceClient(cc grpc.ClientConnInterface) TemperatureServiceClient {
+	return &temperatureServiceClient{cc}
+}
+
+func (c *temperatureServiceClient) GetByCEP(ctx context.Context, in *GetByCEPRequest, opts ...grpc.CallOption) (*ClimaCidade, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClimaCidade)
+	err := c.cc.Invoke(ctx, TemperatureService_GetByCEP_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *temperatureServiceClient) GetMany(ctx context.Context, in *GetManyRequest, opts ...grpc.CallOption) (TemperatureService_GetManyClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TemperatureService_ServiceDesc.Streams[0], TemperatureService_GetMany_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &temperatureServiceGetManyClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type TemperatureService_GetManyClient interface {
+	Recv() (*GetManyResponse, error)
+	grpc.ClientStream
+}
+
+type temperatureServiceGetManyClient struct {
+	grpc.ClientStream
+}
+
+func (x *temperatureServiceGetManyClient) Recv() (*GetManyResponse, error) {
+	m := new(GetManyResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// TemperatureServiceServer is the server API for TemperatureService service.
+// All implementations must embed UnimplementedTemperatureServiceServer
+// for forward compatibility
+//
+// Serviço de consulta de temperatura por CEP
+type TemperatureServiceServer interface {
+	// Consulta a temperatura atual da cidade do CEP.
+	// Erros: INVALID_ARGUMENT (CEP com formato inválido), NOT_FOUND (CEP não encontrado),
+	// UNAVAILABLE, DEADLINE_EXCEEDED e INTERNAL (falhas no ViaCEP ou na WeatherAPI).
+	GetByCEP(context.Context, *GetByCEPRequest) (*ClimaCidade, error)
+	// Consulta vários CEPs. Cada resultado é enviado no stream assim que fica pronto,
+	// e o erro de um CEP não interrompe os demais.
+	GetMany(*GetManyRequest, TemperatureService_GetManyServer) error
+	mustEmbedUnimplementedTemperatureServiceServer()
+}
+
+// UnimplementedTemperatureServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedTemperatureServiceServer struct {
+}
+
+func (UnimplementedTemperatureServiceServer) GetByCEP(context.Context, *GetByCEPRequest) (*ClimaCidade, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetByCEP not implemented")
+}
+func (UnimplementedTemperatureServiceServer) GetMany(*GetManyRequest, TemperatureService_GetManyServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetMany not implemented")
+}
+func (UnimplementedTemperatureServiceServer) mustEmbedUnimplementedTemperatureServiceServer() {}
+
+// UnsafeTemperatureServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TemperatureServiceServer will
+// result in compilation errors.
+type UnsafeTemperatureServiceServer interface {
+	mustEmbedUnimplementedTemperatureServiceServer()
+}
+
+func RegisterTemperatureServiceServer(s grpc.ServiceRegistrar, srv TemperatureServiceServer) {
+	s.RegisterService(&TemperatureService_ServiceDesc, srv)
+}
+
+func _TemperatureService_GetByCEP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetByCEPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TemperatureServiceServer).GetByCEP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TemperatureService_GetByCEP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TemperatureServiceServer).GetByCEP(ctx, req.(*GetByCEPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TemperatureService_GetMany_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetManyRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TemperatureServiceServer).GetMany(m, &temperatureServiceGetManyServer{ServerStream: stream})
+}
+
+type TemperatureService_GetManyServer interface {
+	Send(*GetManyResponse) error
+	grpc.ServerStream
+}
+
+type temperatureServiceGetManyServer struct {
+	grpc.ServerStream
+}
+
+func (x *temperatureServiceGetManyServer) Send(m *GetManyResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// TemperatureService_ServiceDesc is the grpc.ServiceDesc for TemperatureService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TemperatureService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "temperatura.v1.TemperatureService",
+	HandlerType: (*TemperatureServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetByCEP",
+			Handler:    _TemperatureService_GetByCEP_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetMany",
+			Handler:       _TemperatureService_GetMany_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "temperatura/v1/temperatura.proto",
+}
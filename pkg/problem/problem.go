@@ -0,0 +1,251 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Content-Type definido pela RFC 7807 para as respostas de erro
+const ContentType = "application/problem+json"
+
+// Prefixo utilizado na composição do campo "type" dos problemas
+const TypeBaseURL = "https://github.com/wandermaia/desafio-opentelemetry/problems/"
+
+// Tipos de problema conhecidos pelos serviços
+const (
+	TypeInvalidZipcode       = TypeBaseURL + "invalid-zipcode"
+	TypeZipcodeNotFound      = TypeBaseURL + "zipcode-not-found"
+	TypeBadRequest           = TypeBaseURL + "bad-request"
+	TypeNotFound             = TypeBaseURL + "not-found"
+	TypeNotAcceptable        = TypeBaseURL + "not-acceptable"
+	TypeUnauthorized         = TypeBaseURL + "unauthorized"
+	TypeForbidden            = TypeBaseURL + "forbidden"
+	TypeConflict             = TypeBaseURL + "conflict"
+	TypePayloadTooLarge      = TypeBaseURL + "payload-too-large"
+	TypeUnsupportedMediaType = TypeBaseURL + "unsupported-media-type"
+	TypeTooManyRequests      = TypeBaseURL + "too-many-requests"
+	TypeInternal             = TypeBaseURL + "internal-error"
+	TypeBadGateway           = TypeBaseURL + "bad-gateway"
+	TypeServiceUnavailable   = TypeBaseURL + "service-unavailable"
+	TypeGatewayTimeout       = TypeBaseURL + "gateway-timeout"
+)
+
+// Struct que representa uma resposta de erro no formato application/problem+json (RFC 7807).
+// O campo "message" foi mantido para compatibilidade com os clientes que já o utilizam.
+type Details struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	TraceID  string `json:"trace_id,omitempty"`
+	Message  string `json:"message"`
+
+	// Formato e argumentos do detalhe, utilizados na tradução (ver Detailf)
+	detailFormat string
+	detailArgs   []any
+}
+
+// Implementa a interface error para que o problema possa ser propagado entre as camadas
+func (p *Details) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%s: %s", p.Message, p.Detail)
+	}
+	return p.Message
+}
+
+// Função que cria um novo problema. A mensagem legada é igual ao título.
+func New(status int, typ, title, detail string) *Details {
+	return &Details{
+		Type:    typ,
+		Title:   title,
+		Status:  status,
+		Detail:  detail,
+		Message: title,
+	}
+}
+
+// Função que define o detalhe a partir de um formato do fmt. O formato em inglês é a chave do
+// catálogo do pacote i18n, então o detalhe pode ser traduzido mantendo os argumentos.
+func (p *Details) Detailf(format string, args ...any) *Details {
+	p.Detail = fmt.Sprintf(format, args...)
+	p.detailFormat, p.detailArgs = format, args
+	return p
+}
+
+// Função que traduz o título, a mensagem e o detalhe para o idioma informado. Os textos sem
+// tradução no catálogo são mantidos.
+func (p *Details) Localize(lang string) *Details {
+	p.Title = i18n.T(lang, p.Title)
+	p.Message = i18n.T(lang, p.Message)
+	if p.detailFormat != "" {
+		p.Detail = i18n.Sprintf(lang, p.detailFormat, p.detailArgs...)
+	} else {
+		p.Detail = i18n.T(lang, p.Detail)
+	}
+	return p
+}
+
+// CEP com formato inválido (422)
+func InvalidZipcode(detail string) *Details {
+	return New(http.StatusUnprocessableEntity, TypeInvalidZipcode, "invalid zipcode", detail)
+}
+
+// CEP com formato válido, mas não encontrado (404)
+func ZipcodeNotFound(detail string) *Details {
+	return New(http.StatusNotFound, TypeZipcodeNotFound, "can not find zipcode", detail)
+}
+
+// Requisição que não pôde ser interpretada (400)
+func BadRequest(detail string) *Details {
+	return New(http.StatusBadRequest, TypeBadRequest, "invalid request", detail)
+}
+
+// Recurso não encontrado (404)
+func NotFound(detail string) *Details {
+	return New(http.StatusNotFound, TypeNotFound, "resource not found", detail)
+}
+
+// Nenhum dos formatos aceitos pelo cliente no header Accept está disponível (406)
+func NotAcceptable(detail string) *Details {
+	return New(http.StatusNotAcceptable, TypeNotAcceptable, "not acceptable", detail)
+}
+
+// Requisição sem credenciais ou com credenciais inválidas (401)
+func Unauthorized(detail string) *Details {
+	return New(http.StatusUnauthorized, TypeUnauthorized, "unauthorized", detail)
+}
+
+// Cliente autenticado sem permissão para a operação (403)
+func Forbidden(detail string) *Details {
+	return New(http.StatusForbidden, TypeForbidden, "forbidden", detail)
+}
+
+// Recurso em um estado que não permite a operação (409)
+func Conflict(detail string) *Details {
+	return New(http.StatusConflict, TypeConflict, "conflict", detail)
+}
+
+// Body da requisição maior que o limite aceito (413)
+func PayloadTooLarge(detail string) *Details {
+	return New(http.StatusRequestEntityTooLarge, TypePayloadTooLarge, "payload too large", detail)
+}
+
+// Content-Type da requisição não suportado (415)
+func UnsupportedMediaType(detail string) *Details {
+	return New(http.StatusUnsupportedMediaType, TypeUnsupportedMediaType, "unsupported media type", detail)
+}
+
+// Cliente excedeu o limite de requisições (429)
+func TooManyRequests(detail string) *Details {
+	return New(http.StatusTooManyRequests, TypeTooManyRequests, "too many requests", detail)
+}
+
+// Erro interno do serviço (500). O detalhe nunca deve conter o erro original do Go.
+func Internal(detail string) *Details {
+	return New(http.StatusInternalServerError, TypeInternal, "internal server error", detail)
+}
+
+// Resposta inválida de um serviço externo (502)
+func BadGateway(detail string) *Details {
+	return New(http.StatusBadGateway, TypeBadGateway, "bad gateway", detail)
+}
+
+// Serviço externo indisponível (503)
+func ServiceUnavailable(detail string) *Details {
+	return New(http.StatusServiceUnavailable, TypeServiceUnavailable, "service unavailable", detail)
+}
+
+// Serviço externo não respondeu a tempo (504)
+func GatewayTimeout(detail string) *Details {
+	return New(http.StatusGatewayTimeout, TypeGatewayTimeout, "gateway timeout", detail)
+}
+
+// Struct que representa uma resposta inesperada de um serviço externo
+type UpstreamError struct {
+	Service    string
+	StatusCode int
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("%s respondeu com o status %d", e.Service, e.StatusCode)
+}
+
+// Função que converte a falha na chamada de um serviço externo no problema adequado:
+// timeouts viram 504, falhas de conexão viram 503 e os demais erros viram 502.
+func FromUpstreamError(service string, err error) *Details {
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		switch upstreamErr.StatusCode {
+		case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+			return ServiceUnavailable("").Detailf("%s is unavailable", service)
+		case http.StatusGatewayTimeout:
+			return GatewayTimeout("").Detailf("%s did not respond in time", service)
+		}
+		return BadGateway("").Detailf("%s responded with status %d", service, upstreamErr.StatusCode)
+	}
+
+	if isTimeout(err) {
+		return GatewayTimeout("").Detailf("%s did not respond in time", service)
+	}
+	if isUnavailable(err) {
+		return ServiceUnavailable("").Detailf("%s is unavailable", service)
+	}
+	return BadGateway("").Detailf("%s returned an invalid response", service)
+}
+
+// Verifica se o erro foi causado por estouro de tempo
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Verifica se o erro foi causado pela impossibilidade de conectar no serviço
+func isUnavailable(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// Função que escreve o problema na resposta. O instance recebe o path da requisição
+// e o trace_id é coletado do span presente no contexto. Os textos são traduzidos para o idioma
+// do contexto ou, sem idioma no contexto, para o idioma negociado no Accept-Language da requisição.
+func Write(ctx context.Context, w http.ResponseWriter, r *http.Request, p *Details) {
+	lang, ok := i18n.FromContext(ctx)
+	if !ok && r != nil {
+		lang = i18n.Negotiate(r.Header.Get(i18n.Header))
+	}
+	p.Localize(lang)
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+	if p.TraceID == "" {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+			p.TraceID = spanContext.TraceID().String()
+		}
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
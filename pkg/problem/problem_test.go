@@ -0,0 +1,83 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Os erros das chamadas externas devem ser convertidos em 502, 503 ou 504
+func TestFromUpstreamError(t *testing.T) {
+	testes := []struct {
+		nome   string
+		err    error
+		status int
+	}{
+		{"timeout do contexto", context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{"falha de dns", &net.DNSError{Err: "no such host", Name: "service-b"}, http.StatusServiceUnavailable},
+		{"conexão recusada", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, http.StatusServiceUnavailable},
+		{"status 500", &UpstreamError{Service: "viacep", StatusCode: 500}, http.StatusBadGateway},
+		{"status 503", &UpstreamError{Service: "viacep", StatusCode: 503}, http.StatusServiceUnavailable},
+		{"status 504", &UpstreamError{Service: "viacep", StatusCode: 504}, http.StatusGatewayTimeout},
+		{"json inválido", errors.New("unexpected end of JSON input"), http.StatusBadGateway},
+	}
+
+	for _, tt := range testes {
+		t.Run(tt.nome, func(t *testing.T) {
+			assert.Equal(t, tt.status, FromUpstreamError("viacep", tt.err).Status)
+		})
+	}
+}
+
+// O problema deve ser escrito com o content-type da RFC 7807, o path da requisição e o trace_id
+func TestWrite(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	req := httptest.NewRequest("GET", "/00000000", nil)
+	w := httptest.NewRecorder()
+	Write(ctx, w, req, ZipcodeNotFound(""))
+
+	var details Details
+	err := json.Unmarshal(w.Body.Bytes(), &details)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, TypeZipcodeNotFound, details.Type)
+	assert.Equal(t, "/00000000", details.Instance)
+	assert.Equal(t, traceID.String(), details.TraceID)
+	assert.Equal(t, "can not find zipcode", details.Message)
+}
+
+// O título, a mensagem e o detalhe são traduzidos para o idioma do contexto ou do Accept-Language
+func TestWriteIdioma(t *testing.T) {
+	req := httptest.NewRequest("GET", "/00000000", nil)
+	w := httptest.NewRecorder()
+	Write(i18n.WithLanguage(context.Background(), i18n.PortugueseBR), w, req, ZipcodeNotFound("").Detailf("zipcode %s does not exist", "00000000"))
+
+	var details Details
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+	assert.Equal(t, "CEP não encontrado", details.Title)
+	assert.Equal(t, "CEP não encontrado", details.Message)
+	assert.Equal(t, "o CEP 00000000 não existe", details.Detail)
+	assert.Equal(t, i18n.PortugueseBR, w.Header().Get("Content-Language"))
+
+	req.Header.Set(i18n.Header, "es-AR,es;q=0.9")
+	w = httptest.NewRecorder()
+	Write(context.Background(), w, req, FromUpstreamError("viacep", context.DeadlineExceeded))
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+	assert.Equal(t, "tiempo de respuesta agotado", details.Title)
+	assert.Equal(t, "viacep no respondió a tiempo", details.Detail)
+}
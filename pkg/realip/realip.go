@@ -0,0 +1,108 @@
+// Package realip identifica o IP de origem das requisições. Por padrão, o IP é o endereço da
+// conexão (RemoteAddr). Os headers X-Forwarded-For e X-Real-IP só são considerados quando a
+// conexão vem de um proxy confiável, pois qualquer cliente pode enviá-los.
+package realip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Erro retornado quando a lista de proxies confiáveis é inválida
+var ErrInvalidProxy = errors.New("invalid trusted proxy")
+
+// Função que interpreta a lista de proxies confiáveis, separados por vírgula. Cada item pode ser
+// um IP ou uma rede no formato CIDR. Exemplo: "10.0.0.0/8,192.168.1.10".
+func ParseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.Contains(item, "/") {
+			prefix, err := netip.ParsePrefix(item)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidProxy, item)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidProxy, item)
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies, nil
+}
+
+// Função que verifica se o endereço pertence a algum dos proxies confiáveis
+func trusted(addr netip.Addr, proxies []netip.Prefix) bool {
+	for _, prefix := range proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Função que retorna o IP do endereço informado, com ou sem a porta
+func parseAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// Função que retorna o IP de origem da requisição. Quando a conexão vem de um proxy confiável, o
+// X-Forwarded-For é percorrido da direita para a esquerda e o IP de origem é o primeiro endereço
+// que não é de um proxy confiável. Sem X-Forwarded-For, é utilizado o X-Real-IP. Um endereço
+// inválido nos headers interrompe a busca, mantendo o último endereço confiável encontrado.
+func ClientIP(r *http.Request, proxies []netip.Prefix) (netip.Addr, bool) {
+	addr, ok := parseAddr(r.RemoteAddr)
+	if !ok || !trusted(addr, proxies) {
+		return addr, ok
+	}
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, ok := parseAddr(hops[i])
+			if !ok {
+				break
+			}
+			addr = hop
+			if !trusted(hop, proxies) {
+				break
+			}
+		}
+		return addr, true
+	}
+	if hop, ok := parseAddr(r.Header.Get("X-Real-IP")); ok {
+		return hop, true
+	}
+	return addr, true
+}
+
+// Middleware que substitui o RemoteAddr pelo IP de origem retornado pelo ClientIP. A requisição
+// só é alterada quando a conexão vem de um proxy confiável.
+func Middleware(proxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if peer, ok := parseAddr(r.RemoteAddr); ok && trusted(peer, proxies) {
+				addr, _ := ClientIP(r, proxies)
+				r.RemoteAddr = addr.String()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package realip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies(" 10.0.0.0/8, 192.168.1.10 ,,::1")
+	require.NoError(t, err)
+	require.Len(t, proxies, 3)
+	assert.Equal(t, "10.0.0.0/8", proxies[0].String())
+	assert.Equal(t, "192.168.1.10/32", proxies[1].String())
+	assert.Equal(t, "::1/128", proxies[2].String())
+
+	proxies, err = ParseTrustedProxies("")
+	require.NoError(t, err)
+	assert.Empty(t, proxies)
+
+	_, err = ParseTrustedProxies("10.0.0.0/33")
+	assert.ErrorIs(t, err, ErrInvalidProxy)
+	_, err = ParseTrustedProxies("proxy.local")
+	assert.ErrorIs(t, err, ErrInvalidProxy)
+}
+
+// Os headers só são considerados quando a conexão vem de um proxy confiável
+func TestMiddleware(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8")
+	require.NoError(t, err)
+
+	cases := []struct {
+		nome       string
+		remoteAddr string
+		header     http.Header
+		proxies    bool
+		esperado   string
+	}{
+		{"sem proxies confiáveis", "203.0.113.7:1234", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, false, "203.0.113.7:1234"},
+		{"conexão que não é de proxy", "203.0.113.7:1234", http.Header{"X-Forwarded-For": {"198.51.100.1"}, "X-Real-Ip": {"198.51.100.2"}}, true, "203.0.113.7:1234"},
+		{"proxy confiável", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.1"}}, true, "198.51.100.1"},
+		{"endereço forjado à esquerda", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.2.3.4, 198.51.100.1, 10.0.0.2"}}, true, "198.51.100.1"},
+		{"vários headers", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"1.2.3.4", "198.51.100.1"}}, true, "198.51.100.1"},
+		{"endereço inválido", "10.0.0.1:1234", http.Header{"X-Forwarded-For": {"198.51.100.1, lixo, 10.0.0.2"}}, true, "10.0.0.2"},
+		{"x-real-ip", "10.0.0.1:1234", http.Header{"X-Real-Ip": {"198.51.100.2"}}, true, "198.51.100.2"},
+		{"true-client-ip ignorado", "10.0.0.1:1234", http.Header{"True-Client-Ip": {"198.51.100.3"}}, true, "10.0.0.1"},
+	}
+	for _, c := range cases {
+		t.Run(c.nome, func(t *testing.T) {
+			var trustedProxies = proxies
+			if !c.proxies {
+				trustedProxies = nil
+			}
+			var remoteAddr string
+			handler := Middleware(trustedProxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				remoteAddr = r.RemoteAddr
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = c.remoteAddr
+			req.Header = c.header
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, c.esperado, remoteAddr)
+		})
+	}
+}
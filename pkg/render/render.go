@@ -0,0 +1,258 @@
+// Package render serializa as respostas dos serviços no formato negociado com o cliente no header
+// Accept (ou no parâmetro format da URL): JSON (padrão), XML, CSV ou protobuf. Os handlers chamam
+// somente o Write; um novo formato é adicionado em Formats, sem alterar os handlers.
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"google.golang.org/protobuf/proto"
+)
+
+// Tipos de conteúdo dos formatos suportados
+const (
+	MediaTypeJSON     = "application/json"
+	MediaTypeXML      = "application/xml"
+	MediaTypeCSV      = "text/csv"
+	MediaTypeProtobuf = "application/x-protobuf"
+)
+
+// Erro retornado quando nenhum dos formatos aceitos pelo cliente pode representar a resposta
+var ErrNotAcceptable = errors.New("not acceptable")
+
+// Interface dos valores que podem ser representados em CSV: o cabeçalho e uma linha por registro
+type CSVMarshaler interface {
+	MarshalCSV() (header []string, records [][]string)
+}
+
+// Interface dos valores que possuem uma mensagem protobuf equivalente
+type ProtoMarshaler interface {
+	ToProto() proto.Message
+}
+
+// Struct que descreve um formato de resposta
+type Format struct {
+	// Nome utilizado no parâmetro format da URL (ex.: ?format=csv)
+	Name string
+	// Tipos aceitos no header Accept. O primeiro é o tipo principal do formato.
+	MediaTypes []string
+	// Content-Type enviado na resposta
+	ContentType string
+	// Indica se o valor pode ser representado no formato. Nil indica que todos os valores podem.
+	Supports func(v any) bool
+	Encode   func(w io.Writer, v any) error
+}
+
+// Formatos disponíveis
+var (
+	JSON = Format{
+		Name:        "json",
+		MediaTypes:  []string{MediaTypeJSON},
+		ContentType: MediaTypeJSON,
+		Encode: func(w io.Writer, v any) error {
+			return json.NewEncoder(w).Encode(v)
+		},
+	}
+	XML = Format{
+		Name:        "xml",
+		MediaTypes:  []string{MediaTypeXML, "text/xml"},
+		ContentType: MediaTypeXML + "; charset=utf-8",
+		Encode:      encodeXML,
+	}
+	CSV = Format{
+		Name:        "csv",
+		MediaTypes:  []string{MediaTypeCSV},
+		ContentType: MediaTypeCSV + "; charset=utf-8",
+		Supports: func(v any) bool {
+			_, ok := v.(CSVMarshaler)
+			return ok
+		},
+		Encode: func(w io.Writer, v any) error {
+			header, records := v.(CSVMarshaler).MarshalCSV()
+			writer := csv.NewWriter(w)
+			writer.Write(header)
+			writer.WriteAll(records)
+			return writer.Error()
+		},
+	}
+	Protobuf = Format{
+		Name:        "protobuf",
+		MediaTypes:  []string{MediaTypeProtobuf, "application/protobuf"},
+		ContentType: MediaTypeProtobuf,
+		Supports: func(v any) bool {
+			return protoMessage(v) != nil
+		},
+		Encode: func(w io.Writer, v any) error {
+			data, err := proto.Marshal(protoMessage(v))
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		},
+	}
+)
+
+// Formatos na ordem de preferência. O primeiro é utilizado quando o cliente aceita qualquer formato.
+var Formats = []Format{JSON, XML, CSV, Protobuf}
+
+// Retorna a mensagem protobuf do valor, ou nil quando não há mensagem equivalente
+func protoMessage(v any) proto.Message {
+	switch m := v.(type) {
+	case proto.Message:
+		return m
+	case ProtoMarshaler:
+		return m.ToProto()
+	}
+	return nil
+}
+
+// Função que retorna os formatos que podem representar o valor, na ordem de preferência
+func Available(v any) []Format {
+	var formats []Format
+	for _, format := range Formats {
+		if format.Supports == nil || format.Supports(v) {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// Função que escolhe o formato da resposta. O parâmetro format da URL tem prioridade sobre o
+// header Accept, que é avaliado com os pesos q e os curingas (*/* e tipo/*). Sem o header, o
+// primeiro formato disponível é utilizado. Sem formato aceitável, retorna ErrNotAcceptable.
+func Negotiate(r *http.Request, v any) (Format, error) {
+	available := Available(v)
+	if name := r.URL.Query().Get("format"); name != "" {
+		for _, format := range available {
+			if format.Name == name {
+				return format, nil
+			}
+		}
+		return Format{}, ErrNotAcceptable
+	}
+
+	ranges := parseAccept(r.Header.Get("Accept"))
+	if len(ranges) == 0 && len(available) > 0 {
+		return available[0], nil
+	}
+	best, bestQ := Format{}, 0.0
+	for _, format := range available {
+		if q := quality(format, ranges); q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	if bestQ == 0 {
+		return Format{}, ErrNotAcceptable
+	}
+	return best, nil
+}
+
+// Função que verifica se a resposta com valores do tipo informado pode ser enviada em algum dos
+// formatos aceitos pelo cliente. Permite responder 406 antes de executar a consulta.
+func Acceptable(r *http.Request, v any) *problem.Details {
+	if _, err := Negotiate(r, v); err != nil {
+		return notAcceptable(v)
+	}
+	return nil
+}
+
+// Função que escreve a resposta no formato negociado. Sem formato aceitável, responde 406 com
+// os formatos disponíveis. O valor é serializado antes do envio do status, então uma falha na
+// serialização ainda pode ser respondida com 500.
+func Write(w http.ResponseWriter, r *http.Request, status int, v any) {
+	format, err := Negotiate(r, v)
+	if err != nil {
+		problem.Write(r.Context(), w, r, notAcceptable(v))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := format.Encode(&buf, v); err != nil {
+		log.Printf("Erro ao serializar a resposta em %s: %s", format.Name, err)
+		problem.Write(r.Context(), w, r, problem.Internal(""))
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// Problema 406 com os tipos de conteúdo disponíveis para o valor
+func notAcceptable(v any) *problem.Details {
+	var mediaTypes []string
+	for _, format := range Available(v) {
+		mediaTypes = append(mediaTypes, format.MediaTypes[0])
+	}
+	return problem.NotAcceptable("").Detailf("the response is available as %s", strings.Join(mediaTypes, ", "))
+}
+
+// Intervalo de tipos do header Accept (ex.: text/*;q=0.5)
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// Função que interpreta o header Accept. Os itens inválidos são ignorados.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{typ, subtype, q})
+	}
+	return ranges
+}
+
+// Peso do formato no header Accept: o peso do intervalo mais específico que corresponde a um
+// dos tipos do formato. Os curingas correspondem somente ao tipo principal (ex.: text/* não
+// seleciona o XML pelo text/xml). Zero indica que o formato não é aceito.
+func quality(format Format, ranges []mediaRange) float64 {
+	q, specificity := 0.0, -1
+	for i, mediaType := range format.MediaTypes {
+		typ, subtype, _ := strings.Cut(mediaType, "/")
+		for _, r := range ranges {
+			var s int
+			switch {
+			case r.typ == typ && r.subtype == subtype:
+				s = 2
+			case i == 0 && r.typ == typ && r.subtype == "*":
+				s = 1
+			case i == 0 && r.typ == "*" && r.subtype == "*":
+				s = 0
+			default:
+				continue
+			}
+			if s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+	}
+	return q
+}
@@ -0,0 +1,149 @@
+package render
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion/v1"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/pb"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"google.golang.org/protobuf/proto"
+)
+
+// Contrato da v1, que possui as representações em CSV e protobuf
+var clima = v1.FromDomain(&domain.ClimaCidade{
+	Cidade:   "Ibirité",
+	TempC:    28.5,
+	TempF:    83.3,
+	TempK:    301.5,
+	Endereco: &domain.Endereco{Cep: "32450-000", Cidade: "Ibirité", Uf: "MG", Pais: "BR"},
+})
+
+func requisicao(accept string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	return req
+}
+
+func TestNegotiate(t *testing.T) {
+	testes := []struct {
+		accept string
+		format string
+	}{
+		{"", "json"},
+		{"*/*", "json"},
+		{"application/json", "json"},
+		{"application/xml", "xml"},
+		{"text/xml", "xml"},
+		{"text/csv", "csv"},
+		{"application/x-protobuf", "protobuf"},
+		{"application/protobuf", "protobuf"},
+		{"text/*", "csv"},
+		{"application/xml;q=0.9, text/csv", "csv"},
+		{"application/json;q=0.1, */*;q=0.5", "xml"},
+		{"text/html, application/xml;q=0.9, */*;q=0.8", "xml"},
+		{"application/json;q=0, */*", "xml"},
+		{"inválido, text/csv", "csv"},
+	}
+	for _, tt := range testes {
+		t.Run(tt.accept, func(t *testing.T) {
+			format, err := Negotiate(requisicao(tt.accept), clima)
+			require.NoError(t, err)
+			assert.Equal(t, tt.format, format.Name)
+		})
+	}
+
+	// Somente os formatos que conseguem representar o valor são considerados
+	_, err := Negotiate(requisicao("text/csv"), map[string]int{"a": 1})
+	assert.ErrorIs(t, err, ErrNotAcceptable)
+	_, err = Negotiate(requisicao("text/html"), clima)
+	assert.ErrorIs(t, err, ErrNotAcceptable)
+
+	// O parâmetro format tem prioridade sobre o header
+	req := requisicao("application/json")
+	req.URL.RawQuery = "format=csv"
+	format, err := Negotiate(req, clima)
+	require.NoError(t, err)
+	assert.Equal(t, "csv", format.Name)
+	req.URL.RawQuery = "format=yaml"
+	_, err = Negotiate(req, clima)
+	assert.ErrorIs(t, err, ErrNotAcceptable)
+}
+
+func TestWrite(t *testing.T) {
+	w := httptest.NewRecorder()
+	Write(w, requisicao(""), http.StatusOK, clima)
+	assert.Equal(t, MediaTypeJSON, w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	var decoded v1.ClimaCidade
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &decoded))
+	assert.Equal(t, *clima, decoded)
+
+	w = httptest.NewRecorder()
+	Write(w, requisicao("text/csv"), http.StatusOK, clima)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "cep,country,city,state,temp_C,temp_F,temp_K,condition\n32450-000,BR,Ibirité,MG,28.5,83.3,301.5,\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	Write(w, requisicao("application/x-protobuf"), http.StatusOK, clima)
+	assert.Equal(t, MediaTypeProtobuf, w.Header().Get("Content-Type"))
+	var message pb.ClimaCidade
+	assert.NoError(t, proto.Unmarshal(w.Body.Bytes(), &message))
+	assert.Equal(t, "Ibirité", message.GetCity())
+	assert.Equal(t, "MG", message.GetAddress().GetState())
+
+	// Formato não aceito: 406 com os formatos disponíveis
+	w = httptest.NewRecorder()
+	Write(w, requisicao("text/html"), http.StatusOK, clima)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	var details problem.Details
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+	assert.Equal(t, "the response is available as application/json, application/xml, text/csv, application/x-protobuf", details.Detail)
+	assert.NotNil(t, Acceptable(requisicao("text/html"), clima))
+	assert.Nil(t, Acceptable(requisicao("application/xml"), clima))
+}
+
+// O XML utiliza os nomes das tags json, com item para os elementos das listas
+func TestWriteXML(t *testing.T) {
+	type entrada struct {
+		ID        int                 `json:"id"`
+		Tags      []string            `json:"tags,omitempty"`
+		Vazio     string              `json:"empty,omitempty"`
+		Oculto    string              `json:"-"`
+		CreatedAt time.Time           `json:"created_at"`
+		Clima     *domain.ClimaCidade `json:"result,omitempty"`
+	}
+	type pagina struct {
+		Items      []entrada `json:"items"`
+		NextCursor int64     `json:"next_cursor,omitempty"`
+	}
+	tempC := 20.0
+	v := pagina{Items: []entrada{
+		{ID: 1, Tags: []string{"a", "b"}, Oculto: "x", CreatedAt: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)},
+		{ID: 2, Clima: &domain.ClimaCidade{Cidade: "Lisboa", TempC: 18.5, Consenso: &domain.Consenso{Estrategia: "median", Provedores: []domain.LeituraProvedor{{Provedor: "openmeteo", TempC: &tempC}}}}},
+	}}
+
+	w := httptest.NewRecorder()
+	Write(w, requisicao("application/xml"), http.StatusOK, v)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, body, `<response><items><item><id>1</id><tags><item>a</item><item>b</item></tags><created_at>2024-06-01T12:00:00Z</created_at></item>`)
+	assert.Contains(t, body, `<result><city>Lisboa</city><temp_C>18.5</temp_C><temp_F>0</temp_F><temp_K>0</temp_K><consensus><strategy>median</strategy><providers><item><provider>openmeteo</provider><temp_C>20</temp_C></item></providers>`)
+	assert.NotContains(t, body, "empty")
+	assert.NotContains(t, body, "Oculto")
+
+	// Mapas utilizam o atributo key
+	w = httptest.NewRecorder()
+	Write(w, requisicao("application/xml"), http.StatusOK, map[string]int{"b": 2, "a": 1})
+	assert.Contains(t, w.Body.String(), `<response><item key="a">1</item><item key="b">2</item></response>`)
+}
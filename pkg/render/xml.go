@@ -0,0 +1,166 @@
+package render
+
+import (
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Elementos utilizados na representação XML: a raiz do documento e os itens das listas
+const (
+	xmlRoot = "response"
+	xmlItem = "item"
+)
+
+// Função que escreve o valor em XML. Os nomes dos elementos são os mesmos das tags json dos
+// campos, então os tipos não precisam de tags xml e o XML segue o contrato do JSON. As listas
+// são representadas com um elemento item por valor e os mapas com o atributo key.
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	value := reflect.ValueOf(v)
+	if isNil(value) {
+		// A raiz é obrigatória, mesmo sem conteúdo
+		enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: xmlRoot}})
+		enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: xmlRoot}})
+		return enc.Flush()
+	}
+	if err := writeXML(enc, xml.StartElement{Name: xml.Name{Local: xmlRoot}}, value); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+// Escreve o valor dentro do elemento informado. Ponteiros e interfaces nulos não são escritos.
+func writeXML(enc *xml.Encoder, start xml.StartElement, v reflect.Value) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if marshaler, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		if err != nil {
+			return err
+		}
+		return enc.EncodeElement(string(text), start)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		if err := writeXMLFields(enc, v); err != nil {
+			return err
+		}
+		return enc.EncodeToken(start.End())
+	case reflect.Slice, reflect.Array:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := writeXML(enc, xml.StartElement{Name: xml.Name{Local: xmlItem}}, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	case reflect.Map:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			item := xml.StartElement{
+				Name: xml.Name{Local: xmlItem},
+				Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: fmt.Sprint(key)}},
+			}
+			if err := writeXML(enc, item, v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	case reflect.Func, reflect.Chan:
+		return fmt.Errorf("type %s cannot be represented in XML", v.Type())
+	}
+	return enc.EncodeElement(v.Interface(), start)
+}
+
+// Escreve os campos exportados da struct, com os nomes e o omitempty das tags json. Os campos
+// embutidos sem tag são escritos no mesmo nível, assim como no JSON.
+func writeXMLFields(enc *xml.Encoder, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		value := v.Field(i)
+		if field.Anonymous && name == "" {
+			for value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					break
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				if err := writeXMLFields(enc, value); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "omitempty") && isEmpty(value) {
+			continue
+		}
+		if err := writeXML(enc, xml.StartElement{Name: xml.Name{Local: name}}, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Mesma regra do omitempty do encoding/json
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+func isNil(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
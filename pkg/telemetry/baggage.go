@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Chave do baggage e do atributo dos spans com a identificação do cliente autenticado
+const ClientIDKey = "client.id"
+
+// Função que adiciona a identificação do cliente ao baggage do contexto. O baggage é
+// propagado nas chamadas HTTP e gRPC, então o client.id também chega ao service-b.
+func WithClientID(ctx context.Context, clientID string) context.Context {
+	member, err := baggage.NewMemberRaw(ClientIDKey, clientID)
+	if err != nil {
+		return ctx
+	}
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// Retorna a identificação do cliente presente no baggage, ou vazio se não houver
+func ClientID(ctx context.Context) string {
+	return baggage.FromContext(ctx).Member(ClientIDKey).Value()
+}
+
+// Middleware que extrai o contexto de trace e o baggage dos headers, uma única vez, na entrada
+// do serviço. O client.id recebido é removido, pois somente a autenticação do próprio serviço
+// pode defini-lo. Os handlers utilizam o contexto da requisição, sem extrair os headers novamente.
+func ExtractHTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		if bag := baggage.FromContext(ctx); bag.Member(ClientIDKey).Key() != "" {
+			ctx = baggage.ContextWithBaggage(ctx, bag.DeleteMember(ClientIDKey))
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Span processor que adiciona o atributo client.id a todos os spans iniciados em um
+// contexto com a identificação do cliente no baggage.
+type ClientIDSpanProcessor struct{}
+
+func (ClientIDSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if clientID := ClientID(parent); clientID != "" {
+		s.SetAttributes(attribute.String(ClientIDKey, clientID))
+	}
+}
+
+func (ClientIDSpanProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (ClientIDSpanProcessor) Shutdown(context.Context) error   { return nil }
+func (ClientIDSpanProcessor) ForceFlush(context.Context) error { return nil }
@@ -0,0 +1,66 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// O client.id deve ser propagado no header baggage e adicionado aos spans do outro lado
+func TestClientIDBaggage(t *testing.T) {
+	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+	ctx := WithClientID(context.Background(), "app mobile")
+	assert.Equal(t, "app mobile", ClientID(ctx))
+
+	header := http.Header{}
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+	assert.Equal(t, "client.id=app%20mobile", header.Get("baggage"))
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(ClientIDSpanProcessor{}),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	remote := propagator.Extract(context.Background(), propagation.HeaderCarrier(header))
+	_, span := provider.Tracer("test").Start(remote, "Início Processamento")
+	span.End()
+	_, span = provider.Tracer("test").Start(context.Background(), "Sem cliente")
+	span.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Contains(t, spans[0].Attributes(), attribute.String(ClientIDKey, "app mobile"))
+	assert.Empty(t, spans[1].Attributes())
+	assert.Empty(t, ClientID(context.Background()))
+}
+
+// O client.id recebido nos headers é descartado na entrada do serviço; os demais membros e o trace são mantidos
+func TestExtractHTTP(t *testing.T) {
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	defer otel.SetTextMapPropagator(propagator)
+
+	var ctx context.Context
+	handler := ExtractHTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("baggage", "client.id=victim,tenant=acme")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Empty(t, ClientID(ctx))
+	assert.Equal(t, "acme", baggage.FromContext(ctx).Member("tenant").Value())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", trace.SpanContextFromContext(ctx).TraceID().String())
+}
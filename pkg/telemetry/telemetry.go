@@ -0,0 +1,67 @@
+// Package telemetry configura o provider do OpenTelemetry utilizado pelos serviços.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Função que cria o tracer provider, exportando os spans para o collector via OTLP/gRPC.
+// Retorna a função de shutdown do provider, que deve ser chamada no encerramento do serviço.
+func InitProvider(serviceName, collectorURL string) (func(context.Context) error, error) {
+	ctx := context.Background()
+
+	// Criando nome do recurso para ser utilizando no jaeger, por exemplo
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(serviceName),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource: %w", err)
+	}
+
+	// Alterado para contexto com timeout
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	//Criando a chamada grpc
+	conn, err := grpc.NewClient(collectorURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC connection to collector: %w", err)
+	}
+
+	// Configurar o exporter do trace com grpc (poderia ser http também)
+	traceExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	// Criar o span em formato batch
+	bsp := sdktrace.NewBatchSpanProcessor(traceExporter)
+
+	// Vai fazer a consolidação das informações
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()), // A amostragem que será enviada no trace.
+		sdktrace.WithResource(res),
+		sdktrace.WithSpanProcessor(ClientIDSpanProcessor{}),
+		sdktrace.WithSpanProcessor(bsp),
+	)
+	otel.SetTracerProvider(tracerProvider)
+
+	// Propagar a informação utilizando os dados de tracing e o baggage (identificação do cliente)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	// Shutdown graceful
+	return tracerProvider.Shutdown, nil
+}
@@ -0,0 +1,203 @@
+// Package tlsconfig cria as configurações TLS dos servidores e clients a partir de arquivos PEM,
+// recarregando os certificados e o CA quando os arquivos são alterados (rotação).
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Intervalo padrão da verificação de alteração dos arquivos
+const DefaultReloadInterval = 30 * time.Second
+
+// Erro retornado quando a combinação de arquivos é inválida
+var ErrInvalidConfig = errors.New("invalid tls config")
+
+// Arquivos PEM utilizados na conexão. O CA valida o outro lado da conexão: os certificados
+// dos clientes, no servidor, e o certificado do servidor, no client.
+type Files struct {
+	Cert string
+	Key  string
+	CA   string
+}
+
+// Certificado e CA carregados, com a data de modificação dos arquivos
+type state struct {
+	cert     *tls.Certificate
+	pool     *x509.CertPool
+	modTimes []time.Time
+}
+
+// Struct que mantém o certificado e o CA atuais. As configurações geradas consultam o
+// Reloader a cada handshake, então as conexões novas utilizam os arquivos recarregados.
+type Reloader struct {
+	files Files
+	state atomic.Pointer[state]
+}
+
+// Função que cria o Reloader e carrega os arquivos
+func NewReloader(files Files) (*Reloader, error) {
+	if (files.Cert == "") != (files.Key == "") {
+		return nil, fmt.Errorf("%w: the certificate and the key must be informed together", ErrInvalidConfig)
+	}
+	if files.Cert == "" && files.CA == "" {
+		return nil, fmt.Errorf("%w: no certificate or CA informed", ErrInvalidConfig)
+	}
+	r := &Reloader{files: files}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Função que carrega novamente os arquivos. Em caso de erro, os dados anteriores são mantidos.
+func (r *Reloader) Reload() error {
+	modTimes, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	s := &state{modTimes: modTimes}
+	if r.files.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(r.files.Cert, r.files.Key)
+		if err != nil {
+			return err
+		}
+		s.cert = &cert
+	}
+	if r.files.CA != "" {
+		data, err := os.ReadFile(r.files.CA)
+		if err != nil {
+			return err
+		}
+		s.pool = x509.NewCertPool()
+		if !s.pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("%w: no certificate found in %s", ErrInvalidConfig, r.files.CA)
+		}
+	}
+	r.state.Store(s)
+	return nil
+}
+
+// Data de modificação de cada arquivo informado
+func (r *Reloader) modTimes() ([]time.Time, error) {
+	var modTimes []time.Time
+	for _, path := range []string{r.files.Cert, r.files.Key, r.files.CA} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	return modTimes, nil
+}
+
+// Função que verifica se algum arquivo foi alterado desde o último carregamento
+func (r *Reloader) changed() bool {
+	modTimes, err := r.modTimes()
+	if err != nil {
+		// Durante a rotação, o arquivo pode não existir por um instante. Será verificado novamente.
+		return false
+	}
+	current := r.state.Load().modTimes
+	for i := range modTimes {
+		if !modTimes[i].Equal(current[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Função que verifica os arquivos a cada intervalo e os recarrega quando alterados, até o contexto ser cancelado
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				log.Printf("Erro ao recarregar os certificados TLS: %s", err)
+				continue
+			}
+			log.Printf("Certificados TLS recarregados: %s", r.files.Cert)
+		}
+	}
+}
+
+func (r *Reloader) certificate() (*tls.Certificate, error) {
+	cert := r.state.Load().cert
+	if cert == nil {
+		return nil, fmt.Errorf("%w: no certificate configured", ErrInvalidConfig)
+	}
+	return cert, nil
+}
+
+// Função que valida a cadeia do certificado recebido com o CA atual
+func (r *Reloader) verify(certs []*x509.Certificate, dnsName string, usage x509.ExtKeyUsage) error {
+	if len(certs) == 0 {
+		return errors.New("tls: no certificate received")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         r.state.Load().pool,
+		Intermediates: intermediates,
+		DNSName:       dnsName,
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	})
+	return err
+}
+
+// Configuração TLS do servidor. Quando o CA é informado, o certificado do cliente é obrigatório (mTLS).
+func (r *Reloader) ServerConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.certificate()
+		},
+	}
+	if r.files.CA != "" {
+		// A validação padrão utilizaria um pool fixo. A cadeia é validada no VerifyConnection com o CA atual.
+		config.ClientAuth = tls.RequireAnyClientCert
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			return r.verify(cs.PeerCertificates, "", x509.ExtKeyUsageClientAuth)
+		}
+	}
+	return config
+}
+
+// Configuração TLS do client. O certificado, quando informado, é enviado para a autenticação
+// do client (mTLS). Sem o CA, o certificado do servidor é validado com os CAs do sistema.
+func (r *Reloader) ClientConfig() *tls.Config {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if r.files.Cert != "" {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.certificate()
+		}
+	}
+	if r.files.CA != "" {
+		// A validação padrão utilizaria um pool fixo. A cadeia e o nome do servidor são
+		// validados no VerifyConnection com o CA atual.
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			return r.verify(cs.PeerCertificates, cs.ServerName, x509.ExtKeyUsageServerAuth)
+		}
+	}
+	return config
+}
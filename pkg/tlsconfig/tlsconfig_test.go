@@ -0,0 +1,202 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Grava o certificado, a chave e o CA em arquivos temporários
+func writeFiles(t *testing.T, dir, name string, pair PEM, ca *CA) Files {
+	files := Files{
+		Cert: filepath.Join(dir, name+".pem"),
+		Key:  filepath.Join(dir, name+"-key.pem"),
+		CA:   filepath.Join(dir, name+"-ca.pem"),
+	}
+	require.NoError(t, os.WriteFile(files.Cert, pair.Cert, 0o600))
+	require.NoError(t, os.WriteFile(files.Key, pair.Key, 0o600))
+	require.NoError(t, os.WriteFile(files.CA, ca.Cert, 0o600))
+	return files
+}
+
+func newCA(t *testing.T) *CA {
+	ca, err := NewCA("desafio-opentelemetry dev CA", time.Hour)
+	require.NoError(t, err)
+	return ca
+}
+
+func issue(t *testing.T, ca *CA, name string) PEM {
+	pair, err := ca.Issue(name, []string{name, "localhost", "127.0.0.1"}, time.Hour)
+	require.NoError(t, err)
+	return pair
+}
+
+// Inicia um servidor HTTPS que responde o CN do certificado do cliente
+func startServer(t *testing.T, config *tls.Config) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := &http.Server{
+		TLSConfig: config,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+		}),
+	}
+	go server.ServeTLS(listener, "", "")
+	t.Cleanup(func() { server.Close() })
+	return "https://localhost:" + strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func get(config *tls.Config, url string) (string, *tls.ConnectionState, error) {
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), resp.TLS, err
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t)
+	serverFiles := writeFiles(t, dir, "service-b", issue(t, ca, "service-b"), ca)
+	clientFiles := writeFiles(t, dir, "service-a", issue(t, ca, "service-a"), ca)
+
+	server, err := NewReloader(serverFiles)
+	require.NoError(t, err)
+	url := startServer(t, server.ServerConfig())
+
+	client, err := NewReloader(clientFiles)
+	require.NoError(t, err)
+	body, state, err := get(client.ClientConfig(), url)
+	require.NoError(t, err)
+	assert.Equal(t, "service-a", body)
+	assert.Equal(t, "service-b", state.PeerCertificates[0].Subject.CommonName)
+
+	// Client sem certificado
+	withoutCert, err := NewReloader(Files{CA: clientFiles.CA})
+	require.NoError(t, err)
+	_, _, err = get(withoutCert.ClientConfig(), url)
+	assert.Error(t, err)
+
+	// Client com certificado de outro CA
+	other := newCA(t)
+	otherFiles := writeFiles(t, dir, "intruso", issue(t, other, "intruso"), ca)
+	intruder, err := NewReloader(otherFiles)
+	require.NoError(t, err)
+	_, _, err = get(intruder.ClientConfig(), url)
+	assert.Error(t, err)
+
+	// Servidor com certificado de outro CA
+	fakeServer, err := NewReloader(writeFiles(t, dir, "falso", issue(t, other, "service-b"), ca))
+	require.NoError(t, err)
+	_, _, err = get(client.ClientConfig(), startServer(t, fakeServer.ServerConfig()))
+	assert.Error(t, err)
+
+	// Nome do servidor diferente do certificado
+	config := client.ClientConfig()
+	config.ServerName = "service-x"
+	_, _, err = get(config, url)
+	assert.Error(t, err)
+}
+
+// Os certificados rotacionados são utilizados nas conexões novas, sem reiniciar o servidor
+func TestReloaderRotacao(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t)
+	serverFiles := writeFiles(t, dir, "service-b", issue(t, ca, "service-b"), ca)
+	clientFiles := writeFiles(t, dir, "service-a", issue(t, ca, "service-a"), ca)
+
+	server, err := NewReloader(serverFiles)
+	require.NoError(t, err)
+	url := startServer(t, server.ServerConfig())
+	client, err := NewReloader(clientFiles)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Watch(ctx, 10*time.Millisecond)
+	go client.Watch(ctx, 10*time.Millisecond)
+
+	// Rotação do CA e dos dois certificados. A data de modificação é alterada explicitamente
+	// para não depender da resolução do relógio do sistema de arquivos.
+	rotated := newCA(t)
+	later := time.Now().Add(time.Minute)
+	for name, files := range map[string]Files{"service-b-v2": serverFiles, "service-a-v2": clientFiles} {
+		pair := issue(t, rotated, name)
+		require.NoError(t, os.WriteFile(files.Cert, pair.Cert, 0o600))
+		require.NoError(t, os.WriteFile(files.Key, pair.Key, 0o600))
+		require.NoError(t, os.WriteFile(files.CA, rotated.Cert, 0o600))
+		for _, path := range []string{files.Cert, files.Key, files.CA} {
+			require.NoError(t, os.Chtimes(path, later, later))
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		body, state, err := get(client.ClientConfig(), url)
+		return err == nil && body == "service-a-v2" && state.PeerCertificates[0].Subject.CommonName == "service-b-v2"
+	}, 5*time.Second, 20*time.Millisecond)
+
+	// Arquivo inválido mantém os certificados anteriores
+	require.NoError(t, os.WriteFile(serverFiles.Cert, []byte("inválido"), 0o600))
+	assert.Error(t, server.Reload())
+	_, _, err = get(client.ClientConfig(), url)
+	assert.NoError(t, err)
+}
+
+// A mesma configuração é utilizada nas credenciais do gRPC
+func TestMutualTLSGRPC(t *testing.T) {
+	dir := t.TempDir()
+	ca := newCA(t)
+	server, err := NewReloader(writeFiles(t, dir, "service-b", issue(t, ca, "service-b"), ca))
+	require.NoError(t, err)
+	client, err := NewReloader(writeFiles(t, dir, "service-a", issue(t, ca, "service-a"), ca))
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	grpcServer := grpc.NewServer(grpc.Creds(credentials.NewTLS(server.ServerConfig())))
+	grpc_health_v1.RegisterHealthServer(grpcServer, health.NewServer())
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	target := "localhost:" + strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	check := func(config *tls.Config) error {
+		conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+		require.NoError(t, err)
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+		return err
+	}
+	assert.NoError(t, check(client.ClientConfig()))
+
+	withoutCert, err := NewReloader(Files{CA: filepath.Join(dir, "service-a-ca.pem")})
+	require.NoError(t, err)
+	assert.Error(t, check(withoutCert.ClientConfig()))
+}
+
+func TestNewReloaderInvalido(t *testing.T) {
+	_, err := NewReloader(Files{})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewReloader(Files{Cert: "cert.pem"})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewReloader(Files{CA: filepath.Join(t.TempDir(), "inexistente.pem")})
+	assert.Error(t, err)
+}
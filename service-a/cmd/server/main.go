@@ -2,92 +2,110 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"log"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
+	"strings"
 	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/spf13/viper"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/bootstrap"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/realip"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/telemetry"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/tlsconfig"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/auth"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/history"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/ratelimit"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
 	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/webserver/handlers"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/webserver/httpmw"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
 )
 
 // load env vars cfg
 func init() {
-	//viper.AutomaticEnv()
+	// As variáveis de ambiente têm prioridade sobre os valores padrão abaixo
+	viper.AutomaticEnv()
 	viper.SetDefault("SERVICE_B_URL", "http://service-b:8282/")
-	viper.SetDefault("OTEL_SERVICE_NAME", "service-a")
-	viper.SetDefault("REQUEST_NAME_OTEL", "service-a-request")
-	viper.SetDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "otel-collector:4317")
-	viper.SetDefault("HTTP_PORT", ":8181")
-}
-
-func initProvider(serviceName, collectorURL string) (func(context.Context) error, error) {
-	ctx := context.Background()
-
-	// Criando nome do recurso para ser utilizando no jaeger, por exemplo
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName(serviceName),
-		),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
-	}
-
-	// Alterado para contexto com timeout
-	ctx, cancel := context.WithTimeout(ctx, time.Second)
-	defer cancel()
-
-	//Criando a chamada grpc
-	conn, err := grpc.NewClient(collectorURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create gRPC connection to collector: %w", err)
-	}
-
-	// Configurar o exporter do trace com grpc (poderia ser http também)
-	traceExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
-	}
-
-	// Criar o span em formato batch
-	bsp := sdktrace.NewBatchSpanProcessor(traceExporter)
-
-	// Vai fazer a consolidação das informações
-	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()), // A amostragem que será enviada no trace.
-		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(bsp),
-	)
-	otel.SetTracerProvider(tracerProvider)
-
-	// Propagar a informação utilizando os dados de tracing
-	otel.SetTextMapPropagator(propagation.TraceContext{})
-
-	// Shutdown graceful
-	return tracerProvider.Shutdown, nil
+	viper.SetDefault("SERVICE_B_PROTOCOL", "http")
+	viper.SetDefault("SERVICE_B_GRPC_ADDR", "service-b:50051")
+	viper.SetDefault("SERVICE_B_TIMEOUT", handlers.DefaultServiceBTimeout)
+	// Balanceamento entre várias instâncias do service-b: lista de endereços (URLs no protocolo
+	// http e host:porta no grpc) ou nome DNS no formato host:porta, resolvido em todos os IPs.
+	viper.SetDefault("SERVICE_B_ENDPOINTS", "")
+	viper.SetDefault("SERVICE_B_DNS", "")
+	viper.SetDefault("SERVICE_B_DNS_REFRESH", 30*time.Second)
+	viper.SetDefault("SERVICE_B_LB_POLICY", serviceb.PolicyRoundRobin)
+	viper.SetDefault("SERVICE_B_LB_MAX_FAILURES", serviceb.DefaultMaxFailures)
+	viper.SetDefault("SERVICE_B_LB_EJECTION_TIME", serviceb.DefaultEjectionTime)
+	// Hedge das consultas para outra instância do service-b. Sem endereço próprio, o hedge
+	// utiliza o mesmo endereço em uma conexão separada (o balanceador escolhe a instância).
+	viper.SetDefault("SERVICE_B_HEDGE", false)
+	viper.SetDefault("SERVICE_B_HEDGE_URL", "")
+	viper.SetDefault("SERVICE_B_HEDGE_GRPC_ADDR", "")
+	viper.SetDefault("SERVICE_B_HEDGE_PERCENTILE", serviceb.DefaultHedgePercentile)
+	viper.SetDefault("SERVICE_B_HEDGE_MIN_DELAY", serviceb.DefaultHedgeMinDelay)
+	bootstrap.SetDefaults("service-a", ":8181")
+	viper.SetDefault("CEP_MAX_BODY_SIZE", handlers.DefaultCepMaxBodySize)
+	viper.SetDefault("GRAPHQL_MAX_DEPTH", handlers.DefaultGraphQLMaxDepth)
+	viper.SetDefault("GRAPHQL_MAX_COMPLEXITY", handlers.DefaultGraphQLMaxComplexity)
+	viper.SetDefault("STREAM_POLL_INTERVAL", handlers.DefaultStreamPollInterval)
+	viper.SetDefault("STREAM_HEARTBEAT_INTERVAL", handlers.DefaultStreamHeartbeatInterval)
+	viper.SetDefault("ALERT_INTERVAL", handlers.DefaultAlertInterval)
+	viper.SetDefault("ALERT_MAX_ATTEMPTS", handlers.DefaultAlertMaxAttempts)
+	viper.SetDefault("ALERT_BACKOFF", handlers.DefaultAlertBackoff)
+	viper.SetDefault("JOB_WORKERS", handlers.DefaultJobWorkers)
+	viper.SetDefault("JOB_MAX_CEPS", handlers.DefaultJobMaxCeps)
+	viper.SetDefault("JOB_RETENTION", handlers.DefaultJobRetention)
+	viper.SetDefault("JOB_MAX_JOBS", handlers.DefaultJobMaxJobs)
+	viper.SetDefault("JOB_MAX_PENDING", handlers.DefaultJobMaxPending)
+	// Caminho do banco SQLite do histórico. Vazio desabilita o histórico.
+	viper.SetDefault("HISTORY_DB_PATH", "")
+	viper.SetDefault("HISTORY_RETENTION", handlers.DefaultHistoryRetention)
+	viper.SetDefault("HISTORY_PURGE_INTERVAL", handlers.DefaultHistoryPurgeInterval)
+	// Backend do rate limit: memory, redis ou none.
+	viper.SetDefault("RATE_LIMIT_BACKEND", "memory")
+	viper.SetDefault("RATE_LIMIT_PLANS", handlers.DefaultRateLimitPlans)
+	viper.SetDefault("RATE_LIMIT_CLIENTS", "")
+	viper.SetDefault("REDIS_ADDR", "redis:6379")
+	// Autenticação. Sem chaves de API e sem JWKS, as rotas ficam abertas.
+	viper.SetDefault("AUTH_API_KEYS_FILE", "")
+	viper.SetDefault("AUTH_API_KEYS", "")
+	viper.SetDefault("AUTH_JWKS_FILE", "")
+	viper.SetDefault("AUTH_JWT_ISSUER", "")
+	viper.SetDefault("AUTH_JWT_AUDIENCE", "")
+	// TLS das chamadas ao service-b. O CA valida o certificado do service-b e o certificado
+	// do service-a é enviado para a autenticação do client (mTLS).
+	viper.SetDefault("SERVICE_B_CA_FILE", "")
+	viper.SetDefault("SERVICE_B_CERT_FILE", "")
+	viper.SetDefault("SERVICE_B_KEY_FILE", "")
+	// Middlewares aplicados a todas as rotas, na ordem de execução
+	viper.SetDefault("MIDDLEWARES", strings.Join(handlers.DefaultMiddlewares, ","))
+	// CORS. Sem origens, os navegadores não podem chamar o service-a diretamente.
+	viper.SetDefault("CORS_ALLOWED_ORIGINS", "")
+	viper.SetDefault("CORS_ALLOWED_METHODS", handlers.DefaultCORSAllowedMethods)
+	viper.SetDefault("CORS_ALLOWED_HEADERS", handlers.DefaultCORSAllowedHeaders)
+	viper.SetDefault("CORS_EXPOSED_HEADERS", handlers.DefaultCORSExposedHeaders)
+	viper.SetDefault("CORS_MAX_AGE", handlers.DefaultCORSMaxAge)
+	viper.SetDefault("HSTS_MAX_AGE", handlers.DefaultHSTSMaxAge)
+	viper.SetDefault("COMPRESSION_LEVEL", handlers.DefaultCompressionLevel)
+	viper.SetDefault("ROUTE_TIMEOUT", handlers.DefaultRouteTimeout)
+	viper.SetDefault("ROUTE_TIMEOUTS", handlers.DefaultRouteTimeouts)
 }
 
 func main() {
 
 	// Sinais para graceful shutdown
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt)
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := bootstrap.SignalContext()
 	defer cancel()
 
 	// Shutdown do provider
-	shutdown, err := initProvider(viper.GetString("OTEL_SERVICE_NAME"), viper.GetString("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	shutdown, err := telemetry.InitProvider(viper.GetString("OTEL_SERVICE_NAME"), viper.GetString("OTEL_EXPORTER_OTLP_ENDPOINT"))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -101,36 +119,301 @@ func main() {
 	tracer := otel.Tracer("microservice-tracer")
 
 	// Dados para a criação do servidor
-	templateData := &handlers.TemplateData{
-		ExternalCallURL: viper.GetString("SERVICE_B_URL"),
-		RequestNameOTEL: viper.GetString("REQUEST_NAME_OTEL"),
-		OTELTracer:      tracer,
+	templateData := newTemplateData(tracer)
+
+	// Lista de middlewares e timeouts por rota, validados antes da criação do server
+	if err := handlers.ValidateMiddlewares(templateData.Middlewares); err != nil {
+		log.Fatal(err)
+	}
+	// Proxies dos quais os headers com o IP de origem são aceitos
+	templateData.TrustedProxies, err = realip.ParseTrustedProxies(viper.GetString("TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	templateData.APIDeprecation, err = apiversion.ParseDeprecation(viper.GetString("API_DEPRECATION_DATE"))
+	if err != nil {
+		log.Fatalf("invalid API_DEPRECATION_DATE: %s", viper.GetString("API_DEPRECATION_DATE"))
+	}
+	templateData.RouteTimeouts, err = httpmw.ParseRouteTimeouts(viper.GetString("ROUTE_TIMEOUTS"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Histórico das consultas no SQLite, com a remoção periódica dos registros antigos
+	if path := viper.GetString("HISTORY_DB_PATH"); path != "" {
+		repo, err := history.NewSQLiteRepository(path, tracer)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer repo.Close()
+		templateData.History = repo
+		go history.RunRetention(ctx, repo, viper.GetDuration("HISTORY_RETENTION"), viper.GetDuration("HISTORY_PURGE_INTERVAL"))
+	}
+
+	// TLS das chamadas ao service-b, com os certificados recarregados durante a execução
+	var grpcOptions []grpc.DialOption
+	if viper.GetString("SERVICE_B_CA_FILE") != "" || viper.GetString("SERVICE_B_CERT_FILE") != "" {
+		reloader, err := tlsconfig.NewReloader(tlsconfig.Files{
+			Cert: viper.GetString("SERVICE_B_CERT_FILE"),
+			Key:  viper.GetString("SERVICE_B_KEY_FILE"),
+			CA:   viper.GetString("SERVICE_B_CA_FILE"),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		go reloader.Watch(ctx, viper.GetDuration("TLS_RELOAD_INTERVAL"))
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = reloader.ClientConfig()
+		templateData.HTTPClient = &http.Client{Transport: transport}
+		grpcOptions = append(grpcOptions, grpc.WithTransportCredentials(credentials.NewTLS(reloader.ClientConfig())))
+	}
+
+	// Client gRPC do service-b. Com o protocolo http, o client HTTP é criado pelo NewServer.
+	switch viper.GetString("SERVICE_B_PROTOCOL") {
+	case "grpc":
+		grpcClient, err := serviceb.NewGRPCClient(viper.GetString("SERVICE_B_GRPC_ADDR"), grpcOptions...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer grpcClient.Close()
+		templateData.ServiceBClient = grpcClient
+	case "http":
+	default:
+		log.Fatalf("invalid SERVICE_B_PROTOCOL: %s", viper.GetString("SERVICE_B_PROTOCOL"))
+	}
+
+	// Balanceamento entre as instâncias do service-b, quando configurado
+	balancer, err := newServiceBBalancer(ctx, templateData, grpcOptions, tracer)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if balancer != nil {
+		defer balancer.Close()
+		templateData.ServiceBClient = balancer
+	}
+
+	// Client do hedge, com conexões separadas do client principal para alcançar outra instância.
+	// Com o balanceamento, o hedge utiliza o próprio balanceador, que escolhe outra instância.
+	if viper.GetBool("SERVICE_B_HEDGE") {
+		explicit := viper.GetString("SERVICE_B_HEDGE_URL") != "" || viper.GetString("SERVICE_B_HEDGE_GRPC_ADDR") != ""
+		if balancer != nil && !explicit {
+			templateData.ServiceBHedgeClient = balancer
+		} else if viper.GetString("SERVICE_B_PROTOCOL") == "grpc" {
+			addr := viper.GetString("SERVICE_B_HEDGE_GRPC_ADDR")
+			if addr == "" {
+				addr = viper.GetString("SERVICE_B_GRPC_ADDR")
+			}
+			hedgeClient, err := serviceb.NewGRPCClient(addr, grpcOptions...)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer hedgeClient.Close()
+			templateData.ServiceBHedgeClient = hedgeClient
+		} else {
+			url := viper.GetString("SERVICE_B_HEDGE_URL")
+			if url == "" {
+				url = viper.GetString("SERVICE_B_URL")
+			}
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			if templateData.HTTPClient != nil {
+				transport = templateData.HTTPClient.Transport.(*http.Transport).Clone()
+			}
+			templateData.ServiceBHedgeClient = serviceb.NewClient(url, &http.Client{Transport: transport})
+		}
+	}
+
+	// Rate limit por cliente. Os clientes autenticados são associados aos planos configurados.
+	plans, err := ratelimit.ParsePlans(viper.GetString("RATE_LIMIT_PLANS"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	clients, err := ratelimit.ParseClients(viper.GetString("RATE_LIMIT_CLIENTS"), plans)
+	if err != nil {
+		log.Fatal(err)
 	}
+	templateData.RateLimitPlans = plans
+	templateData.RateLimitClients = clients
+	switch viper.GetString("RATE_LIMIT_BACKEND") {
+	case "memory":
+		templateData.RateLimiter = ratelimit.NewMemory()
+	case "redis":
+		redisClient := redis.NewClient(&redis.Options{Addr: viper.GetString("REDIS_ADDR")})
+		defer redisClient.Close()
+		templateData.RateLimiter = ratelimit.NewRedis(redisClient, "ratelimit:")
+	case "none":
+	default:
+		log.Fatalf("invalid RATE_LIMIT_BACKEND: %s", viper.GetString("RATE_LIMIT_BACKEND"))
+	}
+
+	// Autenticação por chave de API (arquivo JSON e/ou variável) e por token JWT validado com o JWKS local
+	authenticator, err := newAuthenticator()
+	if err != nil {
+		log.Fatal(err)
+	}
+	templateData.Authenticator = authenticator
 
 	// Criação do server
 	server := handlers.NewServer(templateData)
 	router := server.CreateServer()
+	tlsConfig, err := bootstrap.ServerTLS(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	httpServer := &http.Server{
+		Addr:      viper.GetString("HTTP_PORT"),
+		Handler:   router,
+		TLSConfig: tlsConfig,
+	}
+	// As assinaturas (SSE e WebSocket) são encerradas no início do shutdown, liberando as conexões
+	httpServer.RegisterOnShutdown(server.Stream.Close)
 
-	// Servidor iniciando em outra thread
-	go func() {
-		log.Println("Starting server on port", viper.GetString("HTTP_PORT"))
-		if err := http.ListenAndServe(viper.GetString("HTTP_PORT"), router); err != nil {
-			log.Fatal(err)
-		}
-	}()
+	// Scheduler dos alertas de temperatura, executado até o shutdown
+	go server.Alerts.Run(ctx)
 
-	// Select para realizar o gracefull shutdown
-	select {
-	case <-sigCh:
-		log.Println("Shutting down gracefully, CTRL+C pressed...")
-	case <-ctx.Done():
-		log.Println("Shutting down due to other reason...")
+	// Servidor executado até o sinal de término, seguido do graceful shutdown
+	if err := bootstrap.Run(ctx, bootstrap.HTTP(httpServer)); err != nil {
+		log.Println(err)
 	}
+	server.Alerts.Stop()
+	server.Jobs.Stop()
 
-	// Create a timeout context for the graceful shutdown
-	_, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer shutdownCancel()
+}
 
+// Função que monta os dados para a criação do servidor a partir das variáveis de ambiente
+func newTemplateData(tracer trace.Tracer) *handlers.TemplateData {
+	return &handlers.TemplateData{
+		ExternalCallURL:         viper.GetString("SERVICE_B_URL"),
+		RequestNameOTEL:         viper.GetString("REQUEST_NAME_OTEL"),
+		OTELTracer:              tracer,
+		ServiceBTimeout:         viper.GetDuration("SERVICE_B_TIMEOUT"),
+		HedgePercentile:         viper.GetFloat64("SERVICE_B_HEDGE_PERCENTILE"),
+		HedgeMinDelay:           viper.GetDuration("SERVICE_B_HEDGE_MIN_DELAY"),
+		CepMaxBodySize:          viper.GetInt64("CEP_MAX_BODY_SIZE"),
+		GraphQLMaxDepth:         viper.GetInt("GRAPHQL_MAX_DEPTH"),
+		GraphQLMaxComplexity:    viper.GetInt("GRAPHQL_MAX_COMPLEXITY"),
+		StreamPollInterval:      viper.GetDuration("STREAM_POLL_INTERVAL"),
+		StreamHeartbeatInterval: viper.GetDuration("STREAM_HEARTBEAT_INTERVAL"),
+		AlertInterval:           viper.GetDuration("ALERT_INTERVAL"),
+		AlertMaxAttempts:        viper.GetInt("ALERT_MAX_ATTEMPTS"),
+		AlertBackoff:            viper.GetDuration("ALERT_BACKOFF"),
+		JobWorkers:              viper.GetInt("JOB_WORKERS"),
+		JobMaxCeps:              viper.GetInt("JOB_MAX_CEPS"),
+		JobRetention:            viper.GetDuration("JOB_RETENTION"),
+		JobMaxJobs:              viper.GetInt("JOB_MAX_JOBS"),
+		JobMaxPending:           viper.GetInt("JOB_MAX_PENDING"),
+		Middlewares:             httpmw.SplitList(viper.GetString("MIDDLEWARES")),
+		CORS: httpmw.CORSConfig{
+			AllowedOrigins: httpmw.SplitList(viper.GetString("CORS_ALLOWED_ORIGINS")),
+			AllowedMethods: httpmw.SplitList(viper.GetString("CORS_ALLOWED_METHODS")),
+			AllowedHeaders: httpmw.SplitList(viper.GetString("CORS_ALLOWED_HEADERS")),
+			ExposedHeaders: httpmw.SplitList(viper.GetString("CORS_EXPOSED_HEADERS")),
+			MaxAge:         viper.GetDuration("CORS_MAX_AGE"),
+		},
+		HSTSMaxAge:       viper.GetDuration("HSTS_MAX_AGE"),
+		CompressionLevel: viper.GetInt("COMPRESSION_LEVEL"),
+		RouteTimeout:     viper.GetDuration("ROUTE_TIMEOUT"),
+	}
 }
 
 // docker rm -f $(docker ps -a -q)
+
+// Função que cria o client balanceado do service-b a partir das variáveis de ambiente. Retorna nil
+// quando nenhuma lista de endereços e nenhum nome DNS foram configurados. Com o nome DNS, as
+// instâncias são atualizadas periodicamente até o contexto ser cancelado.
+func newServiceBBalancer(ctx context.Context, templateData *handlers.TemplateData, grpcOptions []grpc.DialOption, tracer trace.Tracer) (*serviceb.BalancedClient, error) {
+	addresses := httpmw.SplitList(viper.GetString("SERVICE_B_ENDPOINTS"))
+	target := viper.GetString("SERVICE_B_DNS")
+	if len(addresses) == 0 && target == "" {
+		return nil, nil
+	}
+
+	grpcProtocol := viper.GetString("SERVICE_B_PROTOCOL") == "grpc"
+	httpClient := templateData.HTTPClient
+	// No protocolo http, os IPs resolvidos utilizam o mesmo esquema do SERVICE_B_URL
+	scheme, _, _ := strings.Cut(viper.GetString("SERVICE_B_URL"), "://")
+	var resolve func(ctx context.Context) ([]string, error)
+	if target != "" {
+		host, _, err := net.SplitHostPort(target)
+		if err != nil {
+			return nil, err
+		}
+		// Os endereços resolvidos são IPs, então o certificado do service-b é validado com o nome DNS
+		if grpcProtocol {
+			grpcOptions = append(grpcOptions, grpc.WithAuthority(host))
+		} else if httpClient != nil {
+			transport := httpClient.Transport.(*http.Transport).Clone()
+			transport.TLSClientConfig.ServerName = host
+			httpClient = &http.Client{Transport: transport}
+		}
+		resolve = func(ctx context.Context) ([]string, error) {
+			addresses, err := serviceb.LookupEndpoints(ctx, target)
+			if err != nil || grpcProtocol {
+				return addresses, err
+			}
+			for i, address := range addresses {
+				addresses[i] = scheme + "://" + address + "/"
+			}
+			return addresses, nil
+		}
+		if addresses, err = resolve(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	factory := func(address string) (serviceb.TemperaturaClient, error) {
+		return serviceb.NewClient(address, httpClient), nil
+	}
+	if grpcProtocol {
+		factory = func(address string) (serviceb.TemperaturaClient, error) {
+			return serviceb.NewGRPCClient(address, grpcOptions...)
+		}
+	}
+	balancer, err := serviceb.NewBalancedClient(factory, addresses, serviceb.BalancerConfig{
+		Policy:       viper.GetString("SERVICE_B_LB_POLICY"),
+		MaxFailures:  viper.GetInt("SERVICE_B_LB_MAX_FAILURES"),
+		EjectionTime: viper.GetDuration("SERVICE_B_LB_EJECTION_TIME"),
+		Tracer:       tracer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resolve != nil {
+		go balancer.Watch(ctx, resolve, viper.GetDuration("SERVICE_B_DNS_REFRESH"))
+	}
+	return balancer, nil
+}
+
+// Função que cria o autenticador a partir das variáveis de ambiente. Retorna nil quando
+// nenhuma chave de API e nenhum JWKS foram configurados.
+func newAuthenticator() (*auth.Authenticator, error) {
+	keys, err := auth.ParseAPIKeys(viper.GetString("AUTH_API_KEYS"))
+	if err != nil {
+		return nil, err
+	}
+	if path := viper.GetString("AUTH_API_KEYS_FILE"); path != "" {
+		fileKeys, err := auth.LoadAPIKeysFile(path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+
+	authenticator := &auth.Authenticator{}
+	if len(keys) > 0 {
+		authenticator.APIKeys, err = auth.NewAPIKeys(keys)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if path := viper.GetString("AUTH_JWKS_FILE"); path != "" {
+		jwks, err := auth.LoadJWKSFile(path)
+		if err != nil {
+			return nil, err
+		}
+		authenticator.JWT = auth.NewJWTValidator(jwks, viper.GetString("AUTH_JWT_ISSUER"), viper.GetString("AUTH_JWT_AUDIENCE"))
+	}
+	if authenticator.APIKeys == nil && authenticator.JWT == nil {
+		return nil, nil
+	}
+	return authenticator, nil
+}
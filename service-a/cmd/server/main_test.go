@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+// As variáveis de ambiente devem sobrescrever os valores padrão definidos no init
+func TestConfiguracaoPorVariaveisDeAmbiente(t *testing.T) {
+	t.Setenv("SERVICE_B_URL", "http://service-b.interno:9090/")
+	t.Setenv("SERVICE_B_TIMEOUT", "3s")
+	t.Setenv("JOB_MAX_CEPS", "7")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com, https://admin.example.com")
+	t.Setenv("MIDDLEWARES", "requestid,recoverer")
+
+	templateData := newTemplateData(otel.Tracer("microservice-tracer-mock"))
+	assert.Equal(t, "http://service-b.interno:9090/", templateData.ExternalCallURL)
+	assert.Equal(t, 3*time.Second, templateData.ServiceBTimeout)
+	assert.Equal(t, 7, templateData.JobMaxCeps)
+	assert.Equal(t, []string{"https://app.example.com", "https://admin.example.com"}, templateData.CORS.AllowedOrigins)
+	assert.Equal(t, []string{"requestid", "recoverer"}, templateData.Middlewares)
+}
+
+// Sem as variáveis de ambiente, os valores padrão são utilizados
+func TestConfiguracaoPadrao(t *testing.T) {
+	templateData := newTemplateData(otel.Tracer("microservice-tracer-mock"))
+	assert.Equal(t, "http://service-b:8282/", templateData.ExternalCallURL)
+	assert.Empty(t, templateData.CORS.AllowedOrigins)
+}
+
+// Sem a variável AUTH_API_KEYS a autenticação fica desabilitada; com ela, as API keys são carregadas
+func TestAutenticacaoPorVariaveisDeAmbiente(t *testing.T) {
+	authenticator, err := newAuthenticator()
+	require.NoError(t, err)
+	assert.Nil(t, authenticator)
+
+	hash := sha256.Sum256([]byte("segredo"))
+	t.Setenv("AUTH_API_KEYS", "app:"+hex.EncodeToString(hash[:])+":current")
+	authenticator, err = newAuthenticator()
+	require.NoError(t, err)
+	require.NotNil(t, authenticator)
+	assert.NotNil(t, authenticator.APIKeys)
+}
+
+// A variável SERVICE_B_ENDPOINTS habilita o balanceamento entre as instâncias do service-b
+func TestBalanceamentoPorVariaveisDeAmbiente(t *testing.T) {
+	t.Setenv("SERVICE_B_ENDPOINTS", "http://service-b-1:8282/,http://service-b-2:8282/")
+	templateData := newTemplateData(otel.Tracer("microservice-tracer-mock"))
+
+	balancer, err := newServiceBBalancer(context.Background(), templateData, nil, otel.Tracer("microservice-tracer-mock"))
+	require.NoError(t, err)
+	require.NotNil(t, balancer)
+	balancer.Close()
+}
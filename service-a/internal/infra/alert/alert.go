@@ -0,0 +1,270 @@
+package alert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Direções possíveis do cruzamento do limite
+const (
+	DirectionAbove = "above"
+	DirectionBelow = "below"
+)
+
+// Erros de validação e de consulta das assinaturas
+var (
+	ErrNotFound         = errors.New("subscription not found")
+	ErrInvalidDirection = errors.New(`direction must be "above" or "below"`)
+	ErrInvalidURL       = errors.New("url must be an absolute http or https URL")
+	ErrForbiddenAddress = errors.New("url must not point to a loopback, private, link-local, multicast or reserved address")
+	ErrMissingThreshold = errors.New("threshold is required")
+)
+
+// Struct da assinatura de alerta. O webhook é chamado quando a temperatura (em Celsius)
+// do código postal do país (padrão BR) cruza o threshold na direção informada.
+type Subscription struct {
+	ID string `json:"id"`
+	// Cliente autenticado que criou a assinatura. Somente ele pode consultá-la e alterá-la.
+	Owner     string    `json:"owner,omitempty"`
+	Country   string    `json:"country"`
+	Cep       string    `json:"cep"`
+	Threshold float64   `json:"threshold"`
+	Direction string    `json:"direction"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Última temperatura avaliada, utilizada para detectar o cruzamento
+	LastTemperature *float64 `json:"last_temperature,omitempty"`
+}
+
+// Função que verifica se a mudança de temperatura cruzou o threshold na direção da assinatura
+func (s *Subscription) Crossed(previous, current float64) bool {
+	switch s.Direction {
+	case DirectionAbove:
+		return previous <= s.Threshold && current > s.Threshold
+	case DirectionBelow:
+		return previous >= s.Threshold && current < s.Threshold
+	}
+	return false
+}
+
+// Função que valida os campos informados pelo usuário. O CEP é validado pelo handler.
+func (s *Subscription) Validate() error {
+	if s.Direction != DirectionAbove && s.Direction != DirectionBelow {
+		return ErrInvalidDirection
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	// Os nomes DNS são verificados novamente na conexão (ver NewWebhookClient), pois podem
+	// resolver para outro IP depois do cadastro
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenAddress
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && forbiddenIP(ip) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// Redes que não podem ser alcançadas pelos webhooks: a própria máquina, as redes internas e os
+// endereços reservados, que não são roteados na internet
+var forbiddenPrefixes = []netip.Prefix{
+	// "Esta" rede, loopback, privadas, CGNAT e link-local
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	// Testes de desempenho de rede, multicast, reservados e broadcast
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	// Não especificado, loopback e IPv4 compatível (obsoleto)
+	netip.MustParsePrefix("::/96"),
+	// NAT64 de uso local, descarte, únicos locais, link-local, site-local (obsoleto) e multicast
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("fec0::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// Redes IPv6 que carregam um IPv4, verificado na lista de redes proibidas: o NAT64 (64:ff9b::/96,
+// IPv4 nos últimos 4 bytes) e o 6to4 (2002::/16, IPv4 nos bytes 2 a 5)
+var (
+	nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFour   = netip.MustParsePrefix("2002::/16")
+)
+
+// Função que verifica se o IP pertence a alguma das redes proibidas. Os IPv4 mapeados em IPv6
+// (::ffff:0:0/96) são convertidos antes da verificação, e os endereços NAT64 e 6to4 são verificados
+// pelo IPv4 que carregam.
+func forbiddenIP(ip netip.Addr) bool {
+	// Os prefixos não contêm endereços com zona (ex.: fe80::1%eth0)
+	ip = ip.WithZone("").Unmap()
+	b := ip.As16()
+	switch {
+	case nat64Prefix.Contains(ip):
+		return forbiddenIP(netip.AddrFrom4([4]byte(b[12:16])))
+	case sixToFour.Contains(ip):
+		return forbiddenIP(netip.AddrFrom4([4]byte(b[2:6])))
+	}
+	for _, prefix := range forbiddenPrefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Struct que armazena as assinaturas em memória
+type Store struct {
+	mu            sync.RWMutex
+	subscriptions map[string]*Subscription
+}
+
+// Função que cria um novo store vazio
+func NewStore() *Store {
+	return &Store{subscriptions: map[string]*Subscription{}}
+}
+
+// Função que cria a assinatura, gerando o ID e o segredo utilizado na assinatura HMAC quando não informado
+func (st *Store) Create(s Subscription) *Subscription {
+	s.ID = newID()
+	if s.Secret == "" {
+		s.Secret = newID() + newID()
+	}
+	s.CreatedAt = time.Now().UTC()
+	s.LastTemperature = nil
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.subscriptions[s.ID] = &s
+	return s.copy()
+}
+
+// Função que retorna a assinatura do ID informado. As assinaturas de outro dono são
+// tratadas como inexistentes, para não revelar os IDs de outros clientes.
+func (st *Store) Get(owner, id string) (*Subscription, error) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	s, ok := st.owned(owner, id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return s.copy(), nil
+}
+
+// Função que retorna todas as assinaturas, ordenadas pela data de criação
+func (st *Store) List() []*Subscription {
+	return st.list(func(*Subscription) bool { return true })
+}
+
+// Função que retorna as assinaturas do dono informado, ordenadas pela data de criação
+func (st *Store) ListByOwner(owner string) []*Subscription {
+	return st.list(func(s *Subscription) bool { return s.Owner == owner })
+}
+
+func (st *Store) list(filter func(*Subscription) bool) []*Subscription {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	list := make([]*Subscription, 0, len(st.subscriptions))
+	for _, s := range st.subscriptions {
+		if filter(s) {
+			list = append(list, s.copy())
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// Função que atualiza o CEP, o threshold, a direção e a URL da assinatura.
+// A última temperatura é descartada quando o CEP muda.
+func (st *Store) Update(owner, id string, s Subscription) (*Subscription, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	current, ok := st.owned(owner, id)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if current.Cep != s.Cep || current.Country != s.Country {
+		current.LastTemperature = nil
+	}
+	current.Country = s.Country
+	current.Cep = s.Cep
+	current.Threshold = s.Threshold
+	current.Direction = s.Direction
+	current.URL = s.URL
+	if s.Secret != "" {
+		current.Secret = s.Secret
+	}
+	return current.copy(), nil
+}
+
+// Função que remove a assinatura
+func (st *Store) Delete(owner, id string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, ok := st.owned(owner, id); !ok {
+		return ErrNotFound
+	}
+	delete(st.subscriptions, id)
+	return nil
+}
+
+// Função que busca a assinatura do ID informado pertencente ao dono. Deve ser chamada com o lock.
+func (st *Store) owned(owner, id string) (*Subscription, bool) {
+	s, ok := st.subscriptions[id]
+	if !ok || s.Owner != owner {
+		return nil, false
+	}
+	return s, true
+}
+
+// Função que registra a temperatura avaliada e retorna a anterior (nil na primeira avaliação)
+func (st *Store) setLastTemperature(id string, temperature float64) (*float64, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s, ok := st.subscriptions[id]
+	if !ok {
+		return nil, false
+	}
+	previous := s.LastTemperature
+	s.LastTemperature = &temperature
+	return previous, true
+}
+
+func (s *Subscription) copy() *Subscription {
+	c := *s
+	if s.LastTemperature != nil {
+		temperature := *s.LastTemperature
+		c.LastTemperature = &temperature
+	}
+	return &c
+}
+
+// Gera um identificador aleatório
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,242 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Erro retornado ao reenviar uma entrega que não está na lista de falhas
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// Struct de uma entrega que falhou em todas as tentativas
+type DeadLetter struct {
+	Event     Event     `json:"event"`
+	URL       string    `json:"url"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	FailedAt  time.Time `json:"failed_at"`
+	secret    string
+}
+
+// Configuração do scheduler
+type Config struct {
+	// Intervalo entre as avaliações das assinaturas
+	Interval time.Duration
+	// Quantidade máxima de tentativas de cada entrega
+	MaxAttempts int
+	// Intervalo antes da segunda tentativa. O intervalo dobra a cada nova tentativa.
+	Backoff time.Duration
+	// Client das chamadas aos webhooks. Quando nil, é utilizado o NewWebhookClient, que recusa
+	// os endereços locais e das redes internas.
+	HTTPClient *http.Client
+}
+
+// Struct do scheduler, que avalia as assinaturas periodicamente e envia os webhooks
+type Scheduler struct {
+	store   *Store
+	client  serviceb.TemperaturaClient
+	tracer  trace.Tracer
+	config  Config
+	mu      sync.Mutex
+	dead    []*DeadLetter
+	pending sync.WaitGroup
+	// Contexto das entregas, cancelado no Stop
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Função que cria um novo scheduler
+func NewScheduler(store *Store, client serviceb.TemperaturaClient, tracer trace.Tracer, config Config) *Scheduler {
+	if config.HTTPClient == nil {
+		config.HTTPClient = NewWebhookClient(10 * time.Second)
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		store:  store,
+		client: client,
+		tracer: tracer,
+		config: config,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Store das assinaturas avaliadas pelo scheduler
+func (s *Scheduler) Store() *Store {
+	return s.store
+}
+
+// Função que executa as avaliações no intervalo configurado até o contexto ser cancelado
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Evaluate(ctx)
+		}
+	}
+}
+
+// Função que cancela as retentativas em andamento e aguarda as entregas terminarem.
+// As entregas interrompidas são movidas para a lista de falhas.
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.pending.Wait()
+}
+
+// Função que aguarda as entregas em andamento
+func (s *Scheduler) Wait() {
+	s.pending.Wait()
+}
+
+// Função que consulta a temperatura dos CEPs assinados e dispara os webhooks das assinaturas
+// cujo threshold foi cruzado. Cada código postal de cada país é consultado uma única vez por avaliação.
+func (s *Scheduler) Evaluate(ctx context.Context) {
+	subscriptions := s.store.List()
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	ctx, span := s.tracer.Start(ctx, "Avaliação de Alertas")
+	defer span.End()
+	span.SetAttributes(attribute.Int("subscriptions", len(subscriptions)))
+
+	type postalCode struct{ country, cep string }
+	byCep := map[postalCode][]*Subscription{}
+	for _, sub := range subscriptions {
+		key := postalCode{sub.Country, sub.Cep}
+		byCep[key] = append(byCep[key], sub)
+	}
+
+	for key, subs := range byCep {
+		clima, err := s.client.BuscaTemperatura(ctx, key.country, key.cep)
+		if err != nil {
+			span.RecordError(err)
+			log.Printf("alert: falha ao consultar o cep %s (%s): %v", key.cep, key.country, err)
+			continue
+		}
+		for _, sub := range subs {
+			previous, ok := s.store.setLastTemperature(sub.ID, clima.TempC)
+			if !ok || previous == nil || !sub.Crossed(*previous, clima.TempC) {
+				continue
+			}
+			event := Event{
+				DeliveryID:          newID(),
+				SubscriptionID:      sub.ID,
+				Country:             sub.Country,
+				Cep:                 sub.Cep,
+				City:                clima.Cidade,
+				Threshold:           sub.Threshold,
+				Direction:           sub.Direction,
+				PreviousTemperature: *previous,
+				Temperature:         clima.TempC,
+				OccurredAt:          time.Now().UTC(),
+			}
+			s.dispatch(trace.ContextWithSpanContext(s.ctx, span.SpanContext()), sub.URL, sub.Secret, event)
+		}
+	}
+}
+
+// Função que envia o webhook em segundo plano
+func (s *Scheduler) dispatch(ctx context.Context, url, secret string, event Event) {
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		s.deliver(ctx, url, secret, event)
+	}()
+}
+
+// Função que envia o webhook, repetindo as falhas com backoff exponencial.
+// Depois da última tentativa, a entrega é movida para a lista de falhas.
+func (s *Scheduler) deliver(ctx context.Context, url, secret string, event Event) {
+	ctx, span := s.tracer.Start(ctx, "Envio Webhook", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("subscription.id", event.SubscriptionID),
+		attribute.String("delivery.id", event.DeliveryID),
+	)
+
+	body, _ := json.Marshal(event)
+	backoff := s.config.Backoff
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		if err = send(ctx, s.config.HTTPClient, url, secret, event.DeliveryID, body); err == nil {
+			span.SetAttributes(attribute.Int("attempts", attempt))
+			return
+		}
+		if attempt >= s.config.MaxAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+			backoff *= 2
+			continue
+		}
+		break
+	}
+
+	span.SetAttributes(attribute.Int("attempts", attempt))
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "webhook não entregue")
+	log.Printf("alert: webhook %s da assinatura %s não entregue após %d tentativas: %v", event.DeliveryID, event.SubscriptionID, attempt, err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dead = append(s.dead, &DeadLetter{
+		Event:     event,
+		URL:       url,
+		Attempts:  attempt,
+		LastError: err.Error(),
+		FailedAt:  time.Now().UTC(),
+		secret:    secret,
+	})
+}
+
+// Função que retorna as entregas que falharam em todas as tentativas
+func (s *Scheduler) DeadLetters() []DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]DeadLetter, len(s.dead))
+	for i, dead := range s.dead {
+		list[i] = *dead
+	}
+	return list
+}
+
+// Função que remove a entrega da lista de falhas e a envia novamente
+func (s *Scheduler) Redeliver(ctx context.Context, deliveryID string) error {
+	s.mu.Lock()
+	var dead *DeadLetter
+	for i, d := range s.dead {
+		if d.Event.DeliveryID == deliveryID {
+			dead = d
+			s.dead = append(s.dead[:i], s.dead[i+1:]...)
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if dead == nil {
+		return ErrDeadLetterNotFound
+	}
+	s.dispatch(trace.ContextWithSpanContext(s.ctx, trace.SpanContextFromContext(ctx)), dead.URL, dead.secret, dead.Event)
+	return nil
+}
@@ -0,0 +1,322 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Client fake do service-b com temperatura configurável
+type fakeClient struct {
+	mu   sync.Mutex
+	temp float64
+	// Consultas por país e código postal
+	calls map[string]int
+}
+
+func (f *fakeClient) BuscaTemperatura(ctx context.Context, pais, cep string) (*serviceb.ClimaCidade, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.calls != nil {
+		f.calls[pais+"/"+cep]++
+	}
+	return &serviceb.ClimaCidade{Cidade: "Ibirité", TempC: f.temp}, nil
+}
+
+func (f *fakeClient) setTemp(temp float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.temp = temp
+}
+
+func TestCrossed(t *testing.T) {
+	above := &Subscription{Threshold: 30, Direction: DirectionAbove}
+	below := &Subscription{Threshold: 10, Direction: DirectionBelow}
+
+	assert.True(t, above.Crossed(29, 31))
+	assert.True(t, above.Crossed(30, 30.5))
+	assert.False(t, above.Crossed(31, 32))
+	assert.False(t, above.Crossed(31, 29))
+	assert.True(t, below.Crossed(11, 9))
+	assert.False(t, below.Crossed(9, 8))
+	assert.False(t, below.Crossed(9, 11))
+}
+
+// As URLs dos webhooks não podem apontar para a própria máquina ou para a rede interna
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url string
+		err error
+	}{
+		{"https://example.com/hook", nil},
+		{"http://203.0.113.10:8080/hook", nil},
+		{"ftp://example.com/hook", ErrInvalidURL},
+		{"http://localhost:8080/hook", ErrForbiddenAddress},
+		{"http://api.localhost/hook", ErrForbiddenAddress},
+		{"http://127.0.0.1/hook", ErrForbiddenAddress},
+		{"http://[::1]/hook", ErrForbiddenAddress},
+		{"http://10.0.0.5/hook", ErrForbiddenAddress},
+		{"http://192.168.1.1/hook", ErrForbiddenAddress},
+		{"http://169.254.169.254/latest/meta-data", ErrForbiddenAddress},
+		{"http://0.0.0.0/hook", ErrForbiddenAddress},
+		{"http://[::ffff:127.0.0.1]/hook", ErrForbiddenAddress},
+		{"http://[::ffff:10.0.0.5]/hook", ErrForbiddenAddress},
+		{"http://172.16.0.1/hook", ErrForbiddenAddress},
+		{"http://100.64.0.1/hook", ErrForbiddenAddress},
+		{"http://100.127.255.254/hook", ErrForbiddenAddress},
+		{"http://100.128.0.1/hook", nil},
+		{"http://198.18.0.1/hook", ErrForbiddenAddress},
+		{"http://198.19.255.254/hook", ErrForbiddenAddress},
+		{"http://192.0.0.170/hook", ErrForbiddenAddress},
+		{"http://224.0.0.1/hook", ErrForbiddenAddress},
+		{"http://239.255.255.250/hook", ErrForbiddenAddress},
+		{"http://240.0.0.1/hook", ErrForbiddenAddress},
+		{"http://255.255.255.255/hook", ErrForbiddenAddress},
+		{"http://[::]/hook", ErrForbiddenAddress},
+		{"http://[::127.0.0.1]/hook", ErrForbiddenAddress},
+		{"http://[fd00::1]/hook", ErrForbiddenAddress},
+		{"http://[fe80::1%25eth0]/hook", ErrForbiddenAddress},
+		{"http://[ff02::1]/hook", ErrForbiddenAddress},
+		{"http://[64:ff9b::a9fe:a9fe]/hook", ErrForbiddenAddress},
+		{"http://[64:ff9b::7f00:1]/hook", ErrForbiddenAddress},
+		{"http://[64:ff9b::cb00:710a]/hook", nil},
+		{"http://[64:ff9b:1::a00:5]/hook", ErrForbiddenAddress},
+		{"http://[2002:c0a8:101::1]/hook", ErrForbiddenAddress},
+		{"http://[2002:cb00:710a::1]/hook", nil},
+		{"http://[2001:4860:4860::8888]/hook", nil},
+	}
+	for _, tt := range tests {
+		sub := Subscription{Direction: DirectionAbove, URL: tt.url}
+		err := sub.Validate()
+		if tt.err == nil {
+			assert.NoError(t, err, tt.url)
+		} else {
+			assert.ErrorIs(t, err, tt.err, tt.url)
+		}
+	}
+}
+
+// As assinaturas só podem ser consultadas e alteradas pelo cliente que as criou
+func TestStoreDono(t *testing.T) {
+	store := NewStore()
+	sub := store.Create(Subscription{Owner: "app-a", Cep: "32450000", Threshold: 30, Direction: DirectionAbove, URL: "https://example.com/hook"})
+	store.Create(Subscription{Owner: "app-b", Cep: "01021200", Threshold: 10, Direction: DirectionBelow, URL: "https://example.com/hook"})
+
+	_, err := store.Get("app-a", sub.ID)
+	assert.NoError(t, err)
+	_, err = store.Get("app-b", sub.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Update("app-b", sub.ID, Subscription{Cep: "01021200", Direction: DirectionBelow, URL: "https://example.com/hook"})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, store.Delete("app-b", sub.ID), ErrNotFound)
+
+	require.Len(t, store.ListByOwner("app-a"), 1)
+	assert.Equal(t, sub.ID, store.ListByOwner("app-a")[0].ID)
+	assert.Len(t, store.List(), 2)
+	assert.NoError(t, store.Delete("app-a", sub.ID))
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"cep":"32450000"}`)
+	signature := Sign("segredo", "1718000000", body)
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.True(t, Verify("segredo", "1718000000", body, signature))
+	assert.False(t, Verify("outro", "1718000000", body, signature))
+	assert.False(t, Verify("segredo", "1718000001", body, signature))
+}
+
+// O webhook deve ser enviado somente no cruzamento do threshold, assinado e com o contexto de trace
+func TestSchedulerEnviaWebhook(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	tracer := sdktrace.NewTracerProvider().Tracer("test")
+
+	type recebido struct {
+		event       Event
+		valid       bool
+		traceparent string
+	}
+	received := make(chan recebido, 10)
+	var secret string
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var event Event
+		json.Unmarshal(body, &event)
+		received <- recebido{
+			event:       event,
+			valid:       Verify(secret, r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)),
+			traceparent: r.Header.Get("traceparent"),
+		}
+	}))
+	defer webhook.Close()
+
+	client := &fakeClient{temp: 25}
+	store := NewStore()
+	scheduler := NewScheduler(store, client, tracer, Config{Interval: time.Hour, MaxAttempts: 1, HTTPClient: webhook.Client()})
+	sub := store.Create(Subscription{Cep: "32450000", Threshold: 30, Direction: DirectionAbove, URL: webhook.URL})
+	secret = sub.Secret
+
+	ctx, span := tracer.Start(context.Background(), "teste")
+	defer span.End()
+
+	// Primeira avaliação apenas registra a temperatura
+	scheduler.Evaluate(ctx)
+	client.setTemp(29)
+	scheduler.Evaluate(ctx)
+	client.setTemp(31)
+	scheduler.Evaluate(ctx)
+	client.setTemp(32)
+	scheduler.Evaluate(ctx)
+	scheduler.Wait()
+
+	require.Len(t, received, 1)
+	r := <-received
+	assert.True(t, r.valid)
+	assert.Equal(t, sub.ID, r.event.SubscriptionID)
+	assert.Equal(t, "Ibirité", r.event.City)
+	assert.Equal(t, 29.0, r.event.PreviousTemperature)
+	assert.Equal(t, 31.0, r.event.Temperature)
+	assert.Contains(t, r.traceparent, span.SpanContext().TraceID().String())
+
+	saved, err := store.Get("", sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 32.0, *saved.LastTemperature)
+}
+
+// As falhas devem ser repetidas com backoff e, depois da última tentativa, movidas para a lista de falhas
+// Os códigos postais são consultados no país da assinatura, uma vez por país
+func TestSchedulerPais(t *testing.T) {
+	received := make(chan Event, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer webhook.Close()
+
+	client := &fakeClient{temp: 25, calls: map[string]int{}}
+	store := NewStore()
+	scheduler := NewScheduler(store, client, otel.Tracer("test"), Config{Interval: time.Hour, MaxAttempts: 1, HTTPClient: webhook.Client()})
+	store.Create(Subscription{Country: "PT", Cep: "1000001", Threshold: 30, Direction: DirectionAbove, URL: webhook.URL})
+	store.Create(Subscription{Country: "PT", Cep: "1000001", Threshold: 40, Direction: DirectionAbove, URL: webhook.URL})
+	store.Create(Subscription{Country: "BR", Cep: "32450000", Threshold: 40, Direction: DirectionAbove, URL: webhook.URL})
+
+	scheduler.Evaluate(context.Background())
+	client.setTemp(31)
+	scheduler.Evaluate(context.Background())
+	scheduler.Wait()
+
+	assert.Equal(t, map[string]int{"PT/1000001": 2, "BR/32450000": 2}, client.calls)
+	require.Len(t, received, 1)
+	assert.Equal(t, "PT", (<-received).Country)
+}
+
+func TestSchedulerDeadLetter(t *testing.T) {
+	var calls atomic.Int32
+	var fail atomic.Bool
+	fail.Store(true)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer webhook.Close()
+
+	client := &fakeClient{temp: 15}
+	store := NewStore()
+	scheduler := NewScheduler(store, client, otel.Tracer("test"), Config{
+		Interval:    time.Hour,
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		HTTPClient:  webhook.Client(),
+	})
+	store.Create(Subscription{Cep: "32450000", Threshold: 10, Direction: DirectionBelow, URL: webhook.URL})
+
+	scheduler.Evaluate(context.Background())
+	client.setTemp(5)
+	scheduler.Evaluate(context.Background())
+	scheduler.Wait()
+
+	assert.Equal(t, int32(3), calls.Load())
+	dead := scheduler.DeadLetters()
+	require.Len(t, dead, 1)
+	assert.Equal(t, 3, dead[0].Attempts)
+	assert.Equal(t, "webhook respondeu com o status 500", dead[0].LastError)
+
+	// Reenvio manual remove da lista de falhas
+	fail.Store(false)
+	require.NoError(t, scheduler.Redeliver(context.Background(), dead[0].Event.DeliveryID))
+	scheduler.Wait()
+	assert.Equal(t, int32(4), calls.Load())
+	assert.Empty(t, scheduler.DeadLetters())
+	assert.ErrorIs(t, scheduler.Redeliver(context.Background(), dead[0].Event.DeliveryID), ErrDeadLetterNotFound)
+}
+
+// O Stop deve interromper as retentativas e mover a entrega para a lista de falhas
+func TestSchedulerStop(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer webhook.Close()
+
+	client := &fakeClient{temp: 15}
+	store := NewStore()
+	scheduler := NewScheduler(store, client, otel.Tracer("test"), Config{
+		Interval:    time.Hour,
+		MaxAttempts: 5,
+		Backoff:     time.Hour,
+		HTTPClient:  webhook.Client(),
+	})
+	store.Create(Subscription{Cep: "32450000", Threshold: 20, Direction: DirectionAbove, URL: webhook.URL})
+	scheduler.Evaluate(context.Background())
+	client.setTemp(25)
+	scheduler.Evaluate(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		scheduler.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop não interrompeu as retentativas")
+	}
+	require.Len(t, scheduler.DeadLetters(), 1)
+	assert.Equal(t, 1, scheduler.DeadLetters()[0].Attempts)
+}
+
+// O client padrão não deve alcançar endereços locais, mesmo quando a URL não passou pela validação
+func TestSchedulerRecusaEnderecoLocal(t *testing.T) {
+	var calls atomic.Int32
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer webhook.Close()
+
+	client := &fakeClient{temp: 15}
+	store := NewStore()
+	scheduler := NewScheduler(store, client, otel.Tracer("test"), Config{Interval: time.Hour, MaxAttempts: 1})
+	store.Create(Subscription{Cep: "32450000", Threshold: 20, Direction: DirectionAbove, URL: webhook.URL})
+	scheduler.Evaluate(context.Background())
+	client.setTemp(25)
+	scheduler.Evaluate(context.Background())
+	scheduler.Wait()
+
+	assert.Zero(t, calls.Load())
+	require.Len(t, scheduler.DeadLetters(), 1)
+	assert.Contains(t, scheduler.DeadLetters()[0].LastError, ErrForbiddenAddress.Error())
+}
@@ -0,0 +1,115 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Headers enviados nas chamadas do webhook
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+// Corpo enviado no webhook
+type Event struct {
+	DeliveryID          string    `json:"delivery_id"`
+	SubscriptionID      string    `json:"subscription_id"`
+	Country             string    `json:"country"`
+	Cep                 string    `json:"cep"`
+	City                string    `json:"city"`
+	Threshold           float64   `json:"threshold"`
+	Direction           string    `json:"direction"`
+	PreviousTemperature float64   `json:"previous_temperature"`
+	Temperature         float64   `json:"temperature"`
+	OccurredAt          time.Time `json:"occurred_at"`
+}
+
+// Erro retornado quando o webhook responde com um status diferente de 2xx
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook respondeu com o status %d", e.StatusCode)
+}
+
+// Função que cria o client HTTP dos webhooks. O IP de destino é verificado no momento da conexão,
+// depois da resolução DNS, para que um nome que passou na validação da assinatura não alcance a
+// própria máquina ou a rede interna (inclusive após um redirect ou uma nova resolução do nome).
+// O proxy das variáveis de ambiente não é utilizado, pois a verificação seria feita no IP do proxy.
+func NewWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip, err := netip.ParseAddr(host); err != nil || forbiddenIP(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// Função que calcula a assinatura HMAC-SHA256 do corpo. A mensagem assinada é "timestamp.corpo",
+// para que o destinatário possa recusar chamadas antigas reenviadas por terceiros.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Função que verifica a assinatura recebida no header X-Webhook-Signature
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Função que envia o corpo assinado para a URL do webhook. O contexto de trace é propagado nos headers.
+func send(ctx context.Context, httpClient *http.Client, url, secret, deliveryID string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+	req.Header.Set(HeaderDelivery, deliveryID)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Chave de API cadastrada. Somente o hash SHA-256 da chave (em hexadecimal) é armazenado.
+type APIKey struct {
+	ClientID string   `json:"client_id"`
+	SHA256   string   `json:"sha256"`
+	Scopes   []string `json:"scopes"`
+}
+
+// Conjunto de chaves de API, indexado pelo hash da chave
+type APIKeys struct {
+	byHash map[[sha256.Size]byte]*Principal
+}
+
+// Função que calcula o hash de uma chave de API, no formato utilizado no cadastro
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Função que cria o conjunto de chaves, validando o hash, o cliente e os escopos de cada chave
+func NewAPIKeys(keys []APIKey) (*APIKeys, error) {
+	set := &APIKeys{byHash: map[[sha256.Size]byte]*Principal{}}
+	for _, key := range keys {
+		hash, err := hex.DecodeString(key.SHA256)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("%w: the api key of client %q must be a hex encoded sha256 hash", ErrInvalidConfig, key.ClientID)
+		}
+		if key.ClientID == "" {
+			return nil, fmt.Errorf("%w: every api key must have a client_id", ErrInvalidConfig)
+		}
+		if !validScopes(key.Scopes) {
+			return nil, fmt.Errorf("%w: the api key of client %q must have scopes among %s", ErrInvalidConfig, key.ClientID, strings.Join(Scopes, ", "))
+		}
+		index := [sha256.Size]byte(hash)
+		if _, ok := set.byHash[index]; ok {
+			return nil, fmt.Errorf("%w: duplicated api key for client %q", ErrInvalidConfig, key.ClientID)
+		}
+		set.byHash[index] = &Principal{ClientID: key.ClientID, Method: MethodAPIKey, Scopes: key.Scopes}
+	}
+	return set, nil
+}
+
+// Quantidade de chaves cadastradas
+func (k *APIKeys) Len() int {
+	return len(k.byHash)
+}
+
+// Função que retorna o cliente da chave informada
+func (k *APIKeys) Authenticate(key string) (*Principal, error) {
+	principal, ok := k.byHash[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return principal, nil
+}
+
+// Função que interpreta a lista de chaves no formato "cliente:sha256:escopo|escopo", separadas por vírgula
+func ParseAPIKeys(s string) ([]APIKey, error) {
+	var keys []APIKey
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("%w: api key entries must be in the format client:sha256:scope|scope", ErrInvalidConfig)
+		}
+		keys = append(keys, APIKey{ClientID: parts[0], SHA256: parts[1], Scopes: strings.Split(parts[2], "|")})
+	}
+	return keys, nil
+}
+
+// Função que carrega as chaves de um arquivo JSON com a lista de chaves:
+// [{"client_id": "app", "sha256": "...", "scopes": ["current"]}]
+func LoadAPIKeysFile(path string) ([]APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, path, err)
+	}
+	return keys, nil
+}
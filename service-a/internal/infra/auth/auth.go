@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// Escopos de acesso às rotas do service-a
+const (
+	// Temperatura atual: POST /cep, GraphQL, assinaturas e alertas
+	ScopeCurrent = "current"
+	// Jobs assíncronos com listas de CEPs
+	ScopeBatch = "batch"
+	// Histórico de consultas. Concede também todos os demais escopos.
+	ScopeAdmin = "admin"
+)
+
+// Escopos conhecidos, na ordem em que são documentados
+var Scopes = []string{ScopeCurrent, ScopeBatch, ScopeAdmin}
+
+// Métodos de autenticação
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+var (
+	// Erro retornado quando a configuração das chaves ou do JWKS é inválida
+	ErrInvalidConfig = errors.New("invalid auth config")
+	// A requisição não possui credenciais
+	ErrMissingCredentials = errors.New("missing credentials")
+	// As credenciais informadas não são válidas
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Cliente autenticado
+type Principal struct {
+	ClientID string
+	Method   string
+	Scopes   []string
+}
+
+// Função que verifica se o cliente possui o escopo. O escopo admin concede todos os escopos.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+type contextKey struct{}
+
+// Retorna uma cópia do contexto com o cliente autenticado
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// Retorna o cliente autenticado presente no contexto
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(*Principal)
+	return principal, ok
+}
+
+// Retorna a identificação do cliente autenticado presente no contexto, ou vazio se não houver
+func ClientID(ctx context.Context) string {
+	if principal, ok := FromContext(ctx); ok {
+		return principal.ClientID
+	}
+	return ""
+}
+
+// Função que valida a lista de escopos
+func validScopes(scopes []string) bool {
+	if len(scopes) == 0 {
+		return false
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Gera o JWKS com as chaves públicas informadas, indexadas pelo kid
+func jwks(t *testing.T, keys map[string]crypto.PublicKey) []byte {
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for kid, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{"kty": "RSA", "kid": kid, "use": "sig", "n": encode(key.N.Bytes()), "e": encode(big.NewInt(int64(key.E)).Bytes())})
+		case *ecdsa.PublicKey:
+			set.Keys = append(set.Keys, map[string]string{"kty": "EC", "kid": kid, "crv": "P-256", "x": encode(key.X.FillBytes(make([]byte, 32))), "y": encode(key.Y.FillBytes(make([]byte, 32)))})
+		}
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	return data
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key crypto.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys("app-mobile:" + HashKey("chave-mobile") + ":current, batch:" + HashKey("chave-batch") + ":batch")
+	require.NoError(t, err)
+	set, err := NewAPIKeys(keys)
+	require.NoError(t, err)
+	assert.Equal(t, 2, set.Len())
+
+	principal, err := set.Authenticate("chave-mobile")
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{ClientID: "app-mobile", Method: MethodAPIKey, Scopes: []string{"current"}}, principal)
+	assert.True(t, principal.HasScope(ScopeCurrent))
+	assert.False(t, principal.HasScope(ScopeBatch))
+
+	_, err = set.Authenticate("chave-falsa")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	// O escopo admin concede todos os escopos
+	assert.True(t, (&Principal{Scopes: []string{ScopeAdmin}}).HasScope(ScopeBatch))
+
+	for _, invalid := range [][]APIKey{
+		{{ClientID: "app", SHA256: "abc", Scopes: []string{"current"}}},
+		{{ClientID: "", SHA256: HashKey("x"), Scopes: []string{"current"}}},
+		{{ClientID: "app", SHA256: HashKey("x"), Scopes: []string{"weather"}}},
+		{{ClientID: "app", SHA256: HashKey("x"), Scopes: []string{"forecast"}}},
+		{{ClientID: "app", SHA256: HashKey("x")}},
+		{{ClientID: "a", SHA256: HashKey("x"), Scopes: []string{"current"}}, {ClientID: "b", SHA256: HashKey("x"), Scopes: []string{"batch"}}},
+	} {
+		_, err := NewAPIKeys(invalid)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	}
+	_, err = ParseAPIKeys("app:" + HashKey("x"))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestLoadAPIKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"client_id": "painel", "sha256": "`+HashKey("chave-painel")+`", "scopes": ["admin"]}]`), 0o600))
+
+	keys, err := LoadAPIKeysFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []APIKey{{ClientID: "painel", SHA256: HashKey("chave-painel"), Scopes: []string{"admin"}}}, keys)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{`), 0o600))
+	_, err = LoadAPIKeysFile(path)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestJWTValidator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks(t, map[string]crypto.PublicKey{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey}), 0o600))
+	keys, err := LoadJWKSFile(path)
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+	validator := NewJWTValidator(keys, "https://auth.example.com", "service-a")
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   "https://auth.example.com",
+			"aud":   "service-a",
+			"sub":   "app-web",
+			"scope": "current batch",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+	}
+
+	principal, err := validator.Authenticate(sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, valid()))
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{ClientID: "app-web", Method: MethodJWT, Scopes: []string{"current", "batch"}}, principal)
+
+	// O claim client_id tem precedência sobre o sub
+	claims := valid()
+	claims["client_id"] = "app-ios"
+	principal, err = validator.Authenticate(sign(t, jwt.SigningMethodES256, "ec", ecKey, claims))
+	require.NoError(t, err)
+	assert.Equal(t, "app-ios", principal.ClientID)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	expired := valid()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	otherIssuer := valid()
+	otherIssuer["iss"] = "https://outro.example.com"
+	otherAudience := valid()
+	otherAudience["aud"] = "service-b"
+	withoutExp := valid()
+	delete(withoutExp, "exp")
+	withoutSubject := valid()
+	delete(withoutSubject, "sub")
+
+	for name, token := range map[string]string{
+		"expirado":           sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, expired),
+		"issuer diferente":   sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, otherIssuer),
+		"audience diferente": sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, otherAudience),
+		"sem exp":            sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, withoutExp),
+		"sem cliente":        sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, withoutSubject),
+		"chave desconhecida": sign(t, jwt.SigningMethodRS256, "rsa", otherKey, valid()),
+		"kid desconhecido":   sign(t, jwt.SigningMethodRS256, "outro", rsaKey, valid()),
+		"sem kid":            sign(t, jwt.SigningMethodRS256, "", rsaKey, valid()),
+		"algoritmo hmac":     sign(t, jwt.SigningMethodHS256, "rsa", []byte("segredo"), valid()),
+		"algoritmo none":     sign(t, jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType, valid()),
+		"malformado":         "abc.def",
+	} {
+		_, err := validator.Authenticate(token)
+		assert.ErrorIs(t, err, ErrInvalidCredentials, name)
+	}
+
+	// Sem kid, o token é aceito quando o JWKS possui uma única chave
+	single := NewJWTValidator(map[string]crypto.PublicKey{"rsa": &rsaKey.PublicKey}, "", "")
+	_, err = single.Authenticate(sign(t, jwt.SigningMethodRS256, "", rsaKey, valid()))
+	assert.NoError(t, err)
+}
+
+func TestParseJWKSInvalido(t *testing.T) {
+	for _, invalid := range []string{
+		`{`,
+		`{"keys": []}`,
+		`{"keys": [{"kty": "oct", "kid": "a", "k": "c2VncmVkbw"}]}`,
+		`{"keys": [{"kty": "RSA", "kid": "a", "n": "", "e": "AQAB"}]}`,
+		`{"keys": [{"kty": "EC", "kid": "a", "crv": "P-256", "x": "AQ", "y": "AQ"}]}`,
+	} {
+		_, err := ParseJWKS([]byte(invalid))
+		assert.ErrorIs(t, err, ErrInvalidConfig, invalid)
+	}
+}
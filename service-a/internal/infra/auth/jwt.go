@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Tolerância de relógio na validação do exp, nbf e iat
+const DefaultLeeway = 30 * time.Second
+
+// Algoritmos aceitos nos tokens. Tokens com outros algoritmos (inclusive "none") são rejeitados.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Chave pública no formato JWK (RFC 7517). Somente as chaves RSA e EC são suportadas.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Função que converte o JWK na chave pública correspondente
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("the point is not on the %s curve", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid base64url value %q", s)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// Função que interpreta um JWKS ({"keys": [...]}) e retorna as chaves de assinatura indexadas pelo kid
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %w", ErrInvalidConfig, jwk.Kid, err)
+		}
+		if _, ok := keys[jwk.Kid]; ok {
+			return nil, fmt.Errorf("%w: duplicated kid %q", ErrInvalidConfig, jwk.Kid)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: the JWKS has no signing keys", ErrInvalidConfig)
+	}
+	return keys, nil
+}
+
+// Função que carrega o JWKS de um arquivo local
+func LoadJWKSFile(path string) (map[string]crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+// Claims dos tokens. Os escopos seguem o formato do OAuth 2.0 (claim "scope", separados por espaço)
+// e o cliente é identificado pelo claim "client_id" ou, na ausência dele, pelo "sub".
+type claims struct {
+	jwt.RegisteredClaims
+	Scope    string `json:"scope"`
+	ClientID string `json:"client_id"`
+}
+
+// Validador dos tokens JWT bearer
+type JWTValidator struct {
+	keys   map[string]crypto.PublicKey
+	parser *jwt.Parser
+}
+
+// Função que cria o validador com as chaves do JWKS. O issuer e a audience são validados
+// somente quando informados. O claim exp é obrigatório.
+func NewJWTValidator(keys map[string]crypto.PublicKey, issuer, audience string) *JWTValidator {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(DefaultLeeway),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	return &JWTValidator{keys: keys, parser: jwt.NewParser(options...)}
+}
+
+// Função que retorna a chave do token, pelo kid. Sem kid, é aceita somente quando o JWKS tem uma única chave.
+func (v *JWTValidator) keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown kid %q", kid)
+}
+
+// Função que valida o token e retorna o cliente autenticado
+func (v *JWTValidator) Authenticate(token string) (*Principal, error) {
+	var c claims
+	if _, err := v.parser.ParseWithClaims(token, &c, v.keyfunc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+	clientID := c.ClientID
+	if clientID == "" {
+		clientID = c.Subject
+	}
+	if clientID == "" {
+		return nil, fmt.Errorf("%w: the token has no client_id or sub claim", ErrInvalidCredentials)
+	}
+	return &Principal{ClientID: clientID, Method: MethodJWT, Scopes: strings.Fields(c.Scope)}, nil
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header com a chave de API do cliente
+const APIKeyHeader = "X-API-Key"
+
+// Autenticador das requisições. Os métodos não configurados (nil) não são aceitos.
+type Authenticator struct {
+	APIKeys *APIKeys
+	JWT     *JWTValidator
+	Tracer  trace.Tracer
+}
+
+// Função que autentica a requisição pela chave de API (header X-API-Key) ou pelo token
+// JWT (header Authorization: Bearer).
+func (a *Authenticator) authenticate(r *http.Request) (*Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		if a.APIKeys == nil {
+			return nil, fmt.Errorf("%w: api keys are not accepted", ErrInvalidCredentials)
+		}
+		return a.APIKeys.Authenticate(key)
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") && token != "" {
+		if a.JWT == nil {
+			return nil, fmt.Errorf("%w: bearer tokens are not accepted", ErrInvalidCredentials)
+		}
+		return a.JWT.Authenticate(strings.TrimSpace(token))
+	}
+	return nil, ErrMissingCredentials
+}
+
+// Middleware que exige a autenticação do cliente. O cliente autenticado é adicionado ao
+// contexto da requisição e o client.id ao baggage, que é propagado para o service-b. O contexto
+// de trace já deve ter sido extraído dos headers (telemetry.ExtractHTTP); o client.id é definido
+// por último, então nenhum header da requisição o substitui.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := a.Tracer.Start(r.Context(), "Autenticação")
+
+		principal, err := a.authenticate(r)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Cliente não autenticado")
+			span.End()
+			challenge := `Bearer realm="service-a"`
+			detail := "the request must have an X-API-Key header or an Authorization: Bearer token"
+			if !errors.Is(err, ErrMissingCredentials) {
+				challenge += `, error="invalid_token"`
+				detail = "the credentials are invalid or expired"
+				log.Printf("Falha na autenticação: %s", err)
+			}
+			w.Header().Set("WWW-Authenticate", challenge)
+			problem.Write(ctx, w, r, problem.Unauthorized(detail))
+			return
+		}
+		span.SetAttributes(
+			attribute.String(telemetry.ClientIDKey, principal.ClientID),
+			attribute.String("auth.method", principal.Method),
+			attribute.StringSlice("auth.scopes", principal.Scopes),
+		)
+		span.End()
+
+		ctx = WithPrincipal(r.Context(), principal)
+		ctx = telemetry.WithClientID(ctx, principal.ClientID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Middleware que exige o escopo informado do cliente autenticado
+func Require(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := FromContext(r.Context())
+			if !ok {
+				problem.Write(r.Context(), w, r, problem.Unauthorized("the request is not authenticated"))
+				return
+			}
+			if !principal.HasScope(scope) {
+				log.Printf("Cliente %s sem o escopo %s: %s %s", principal.ClientID, scope, r.Method, r.URL.Path)
+				problem.Write(r.Context(), w, r, problem.Forbidden("").Detailf("the %s scope is required", scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys, err := NewAPIKeys([]APIKey{{ClientID: "app-mobile", SHA256: HashKey("chave-mobile"), Scopes: []string{ScopeCurrent}}})
+	require.NoError(t, err)
+	recorder := tracetest.NewSpanRecorder()
+	authenticator := &Authenticator{
+		APIKeys: keys,
+		JWT:     NewJWTValidator(map[string]crypto.PublicKey{"rsa": &rsaKey.PublicKey}, "", ""),
+		Tracer:  sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test"),
+	}
+
+	var principal *Principal
+	var clientID string
+	handler := authenticator.Middleware(Require(ScopeBatch)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = FromContext(r.Context())
+		clientID = telemetry.ClientID(r.Context())
+		w.WriteHeader(http.StatusAccepted)
+	})))
+	serve := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/jobs", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	problemType := func(w *httptest.ResponseRecorder) string {
+		var details problem.Details
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+		return details.Type
+	}
+
+	// Sem credenciais
+	w := serve("", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, problem.TypeUnauthorized, problemType(w))
+	assert.Equal(t, `Bearer realm="service-a"`, w.Header().Get("WWW-Authenticate"))
+
+	// Credenciais inválidas
+	w = serve(APIKeyHeader, "chave-falsa")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer realm="service-a", error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, serve("Authorization", "Bearer abc.def.ghi").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("Authorization", "Basic dXNlcjpzZW5oYQ==").Code)
+
+	// Chave válida sem o escopo batch
+	w = serve(APIKeyHeader, "chave-mobile")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, problem.TypeForbidden, problemType(w))
+
+	// Token com o escopo batch
+	token := sign(t, jwt.SigningMethodRS256, "rsa", rsaKey, jwt.MapClaims{
+		"sub":   "importador",
+		"scope": "batch",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	w = serve("Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "importador", principal.ClientID)
+	assert.Equal(t, MethodJWT, principal.Method)
+	assert.Equal(t, "importador", clientID)
+
+	spans := recorder.Ended()
+	last := spans[len(spans)-1]
+	assert.Equal(t, "Autenticação", last.Name())
+	assert.Contains(t, last.Attributes(), attribute.String(telemetry.ClientIDKey, "importador"))
+	assert.Contains(t, last.Attributes(), attribute.String("auth.method", MethodJWT))
+}
+
+// Sem o método configurado, as credenciais correspondentes são rejeitadas
+func TestMiddlewareMetodoDesabilitado(t *testing.T) {
+	authenticator := &Authenticator{Tracer: sdktrace.NewTracerProvider().Tracer("test")}
+	handler := authenticator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for header, value := range map[string]string{APIKeyHeader: "chave", "Authorization": "Bearer abc"} {
+		req := httptest.NewRequest(http.MethodPost, "/cep", nil)
+		req.Header.Set(header, value)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+	}
+}
@@ -0,0 +1,108 @@
+package history
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Limites da paginação da consulta do histórico
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Struct de um registro do histórico de consultas
+type Entry struct {
+	ID        int64     `json:"id"`
+	Cep       string    `json:"cep"`
+	City      string    `json:"city,omitempty"`
+	TempC     *float64  `json:"temp_C,omitempty"`
+	TempF     *float64  `json:"temp_F,omitempty"`
+	TempK     *float64  `json:"temp_K,omitempty"`
+	Status    int       `json:"status"`
+	LatencyMs float64   `json:"latency_ms"`
+	TraceID   string    `json:"trace_id,omitempty"`
+	ClientID  string    `json:"client_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Filtros da consulta do histórico. Os campos vazios não são aplicados.
+// A paginação utiliza o ID do último registro da página anterior (Cursor).
+type Filter struct {
+	Cep      string
+	City     string
+	Status   int
+	ClientID string
+	From     time.Time
+	To       time.Time
+	Cursor   int64
+	Limit    int
+}
+
+// Página de resultados. O NextCursor é zero quando não existem mais registros.
+type Page struct {
+	Items      []Entry `json:"items"`
+	NextCursor int64   `json:"next_cursor,omitempty"`
+}
+
+// Função que representa a página em CSV, um registro por linha. O cursor da próxima página não
+// faz parte do CSV e é enviado no header X-Next-Cursor.
+func (p *Page) MarshalCSV() ([]string, [][]string) {
+	formatFloat := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64)
+	}
+	records := make([][]string, 0, len(p.Items))
+	for _, e := range p.Items {
+		records = append(records, []string{
+			strconv.FormatInt(e.ID, 10), e.Cep, e.City, formatFloat(e.TempC), formatFloat(e.TempF), formatFloat(e.TempK),
+			strconv.Itoa(e.Status), strconv.FormatFloat(e.LatencyMs, 'f', -1, 64), e.TraceID, e.ClientID, e.CreatedAt.Format(time.RFC3339Nano),
+		})
+	}
+	return []string{"id", "cep", "city", "temp_C", "temp_F", "temp_K", "status", "latency_ms", "trace_id", "client_id", "created_at"}, records
+}
+
+// Interface do repositório do histórico
+type Repository interface {
+	// Registra uma consulta
+	Save(ctx context.Context, entry *Entry) error
+	// Retorna os registros mais recentes primeiro
+	List(ctx context.Context, filter Filter) (*Page, error)
+	// Remove os registros criados antes da data informada e retorna a quantidade removida
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	Close() error
+}
+
+// Função que normaliza o limite da página
+func (f *Filter) normalize() {
+	if f.Limit <= 0 {
+		f.Limit = DefaultLimit
+	}
+	if f.Limit > MaxLimit {
+		f.Limit = MaxLimit
+	}
+}
+
+// Função que remove os registros mais antigos que a retenção, no intervalo informado, até o contexto ser cancelado
+func RunRetention(ctx context.Context, repo Repository, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		removed, err := repo.Purge(ctx, time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("history: falha ao remover os registros antigos: %v", err)
+		} else if removed > 0 {
+			log.Printf("history: %d registros antigos removidos", removed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
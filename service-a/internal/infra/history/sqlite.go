@@ -0,0 +1,186 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	_ "modernc.org/sqlite"
+)
+
+// Schema da tabela do histórico
+const schema = `
+CREATE TABLE IF NOT EXISTS history (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	cep        TEXT    NOT NULL,
+	city       TEXT    NOT NULL DEFAULT '',
+	temp_c     REAL,
+	temp_f     REAL,
+	temp_k     REAL,
+	status     INTEGER NOT NULL,
+	latency_ms REAL    NOT NULL,
+	trace_id   TEXT    NOT NULL DEFAULT '',
+	client_id  TEXT    NOT NULL DEFAULT '',
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS history_created_at ON history (created_at);
+CREATE INDEX IF NOT EXISTS history_cep ON history (cep);
+`
+
+// Struct do repositório do histórico no SQLite
+type SQLiteRepository struct {
+	db     *sql.DB
+	tracer trace.Tracer
+}
+
+// Função que abre (ou cria) o banco no caminho informado e cria a tabela do histórico
+func NewSQLiteRepository(path string, tracer trace.Tracer) (*SQLiteRepository, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+	// O SQLite aceita apenas uma escrita por vez
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create history schema: %w", err)
+	}
+	return &SQLiteRepository{db: db, tracer: tracer}, nil
+}
+
+// Função que cria o span da operação no banco, com os atributos da convenção semântica de banco de dados
+func (r *SQLiteRepository) startSpan(ctx context.Context, operation, statement string) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, "SQLite "+operation+" history",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "sqlite"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.sql.table", "history"),
+			attribute.String("db.statement", statement),
+		),
+	)
+}
+
+// Função que finaliza o span registrando o erro, quando existir
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (r *SQLiteRepository) Save(ctx context.Context, entry *Entry) (err error) {
+	const statement = `INSERT INTO history (cep, city, temp_c, temp_f, temp_k, status, latency_ms, trace_id, client_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	ctx, span := r.startSpan(ctx, "INSERT", statement)
+	defer func() { endSpan(span, err) }()
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Millisecond)
+	result, err := r.db.ExecContext(ctx, statement,
+		entry.Cep, entry.City, entry.TempC, entry.TempF, entry.TempK,
+		entry.Status, entry.LatencyMs, entry.TraceID, entry.ClientID, entry.CreatedAt.UnixMilli(),
+	)
+	if err != nil {
+		return err
+	}
+	entry.ID, err = result.LastInsertId()
+	return err
+}
+
+func (r *SQLiteRepository) List(ctx context.Context, filter Filter) (page *Page, err error) {
+	filter.normalize()
+
+	var where []string
+	var args []any
+	add := func(condition string, arg any) {
+		where = append(where, condition)
+		args = append(args, arg)
+	}
+	if filter.Cep != "" {
+		add("cep = ?", filter.Cep)
+	}
+	if filter.City != "" {
+		add("city = ? COLLATE NOCASE", filter.City)
+	}
+	if filter.Status != 0 {
+		add("status = ?", filter.Status)
+	}
+	if filter.ClientID != "" {
+		add("client_id = ?", filter.ClientID)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= ?", filter.From.UnixMilli())
+	}
+	if !filter.To.IsZero() {
+		add("created_at < ?", filter.To.UnixMilli())
+	}
+	if filter.Cursor > 0 {
+		add("id < ?", filter.Cursor)
+	}
+
+	statement := "SELECT id, cep, city, temp_c, temp_f, temp_k, status, latency_ms, trace_id, client_id, created_at FROM history"
+	if len(where) > 0 {
+		statement += " WHERE " + strings.Join(where, " AND ")
+	}
+	// Um registro a mais é lido para saber se existe a próxima página
+	statement += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit+1)
+
+	ctx, span := r.startSpan(ctx, "SELECT", statement)
+	defer func() { endSpan(span, err) }()
+
+	rows, err := r.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	page = &Page{Items: []Entry{}}
+	for rows.Next() {
+		var entry Entry
+		var createdAt int64
+		if err := rows.Scan(&entry.ID, &entry.Cep, &entry.City, &entry.TempC, &entry.TempF, &entry.TempK,
+			&entry.Status, &entry.LatencyMs, &entry.TraceID, &entry.ClientID, &createdAt); err != nil {
+			return nil, err
+		}
+		entry.CreatedAt = time.UnixMilli(createdAt).UTC()
+		page.Items = append(page.Items, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(page.Items) > filter.Limit {
+		page.Items = page.Items[:filter.Limit]
+		page.NextCursor = page.Items[filter.Limit-1].ID
+	}
+	span.SetAttributes(attribute.Int("db.rows", len(page.Items)))
+	return page, nil
+}
+
+func (r *SQLiteRepository) Purge(ctx context.Context, before time.Time) (removed int64, err error) {
+	const statement = "DELETE FROM history WHERE created_at < ?"
+	ctx, span := r.startSpan(ctx, "DELETE", statement)
+	defer func() { endSpan(span, err) }()
+
+	result, err := r.db.ExecContext(ctx, statement, before.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	removed, err = result.RowsAffected()
+	span.SetAttributes(attribute.Int64("db.rows", removed))
+	return removed, err
+}
+
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
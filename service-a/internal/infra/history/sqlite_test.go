@@ -0,0 +1,110 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestRepository(t *testing.T) (*SQLiteRepository, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	repo, err := NewSQLiteRepository(filepath.Join(t.TempDir(), "history.db"), tracer)
+	require.NoError(t, err)
+	t.Cleanup(func() { repo.Close() })
+	return repo, recorder
+}
+
+func temp(v float64) *float64 {
+	return &v
+}
+
+// Os registros devem ser gravados, filtrados e paginados do mais recente para o mais antigo
+func TestSQLiteRepositoryList(t *testing.T) {
+	repo, recorder := newTestRepository(t)
+	ctx := context.Background()
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		entry := &Entry{Cep: "32450000", City: "Ibirité", TempC: temp(20 + float64(i)), Status: 200, LatencyMs: 12.5, TraceID: "abc", ClientID: "app", CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		require.NoError(t, repo.Save(ctx, entry))
+		assert.Equal(t, int64(i+1), entry.ID)
+	}
+	require.NoError(t, repo.Save(ctx, &Entry{Cep: "00000000", Status: 404, ClientID: "outro", CreatedAt: base.Add(10 * time.Minute)}))
+
+	page, err := repo.List(ctx, Filter{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, int64(6), page.Items[0].ID)
+	assert.Equal(t, int64(5), page.NextCursor)
+
+	page, err = repo.List(ctx, Filter{Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{4, 3}, []int64{page.Items[0].ID, page.Items[1].ID})
+	assert.Equal(t, "Ibirité", page.Items[0].City)
+	assert.Equal(t, 23.0, *page.Items[0].TempC)
+	assert.Nil(t, page.Items[0].TempF)
+	assert.Equal(t, base.Add(3*time.Minute), page.Items[0].CreatedAt)
+
+	page, err = repo.List(ctx, Filter{Status: 404})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "00000000", page.Items[0].Cep)
+	assert.Zero(t, page.NextCursor)
+
+	page, err = repo.List(ctx, Filter{City: "ibirité", ClientID: "app", From: base.Add(time.Minute), To: base.Add(3 * time.Minute)})
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+
+	page, err = repo.List(ctx, Filter{Cep: "99999999"})
+	require.NoError(t, err)
+	assert.NotNil(t, page.Items)
+	assert.Empty(t, page.Items)
+
+	// As operações no banco devem gerar spans
+	names := map[string]int{}
+	for _, span := range recorder.Ended() {
+		names[span.Name()]++
+	}
+	assert.Equal(t, 6, names["SQLite INSERT history"])
+	assert.Equal(t, 5, names["SQLite SELECT history"])
+}
+
+// A retenção deve remover apenas os registros antigos
+func TestSQLiteRepositoryPurge(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, repo.Save(ctx, &Entry{Cep: "32450000", Status: 200, CreatedAt: now.Add(-48 * time.Hour)}))
+	require.NoError(t, repo.Save(ctx, &Entry{Cep: "32450001", Status: 200, CreatedAt: now.Add(-time.Hour)}))
+
+	removed, err := repo.Purge(ctx, now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), removed)
+
+	page, err := repo.List(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "32450001", page.Items[0].Cep)
+
+	// O RunRetention executa a remoção imediatamente e encerra com o contexto
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		RunRetention(ctx, repo, 30*time.Minute, time.Hour)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		page, err := repo.List(context.Background(), Filter{})
+		return err == nil && len(page.Items) == 0
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+}
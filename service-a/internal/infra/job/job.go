@@ -0,0 +1,400 @@
+package job
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Situações possíveis de um job
+const (
+	StatusQueued   = "queued"
+	StatusRunning  = "running"
+	StatusDone     = "done"
+	StatusCanceled = "canceled"
+)
+
+// Erros retornados pelo manager
+var (
+	ErrNotFound       = errors.New("job not found")
+	ErrStopped        = errors.New("job manager stopped")
+	ErrTooManyPending = errors.New("too many pending jobs")
+	ErrFull           = errors.New("job store is full")
+)
+
+// Resultado da consulta de um CEP do job
+type Result struct {
+	Cep   string                `json:"cep"`
+	Clima *serviceb.ClimaCidade `json:"result,omitempty"`
+	Error *problem.Details      `json:"error,omitempty"`
+}
+
+// Resultados de um job, na ordem em que os CEPs foram enviados
+type Results []Result
+
+// Função que representa os resultados em CSV, um CEP por linha
+func (results Results) MarshalCSV() ([]string, [][]string) {
+	formatFloat := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	records := make([][]string, 0, len(results))
+	for _, result := range results {
+		switch {
+		case result.Clima != nil:
+			records = append(records, []string{result.Cep, result.Clima.Cidade, formatFloat(result.Clima.TempC), formatFloat(result.Clima.TempF), formatFloat(result.Clima.TempK), "200", ""})
+		case result.Error != nil:
+			records = append(records, []string{result.Cep, "", "", "", "", strconv.Itoa(result.Error.Status), result.Error.Message})
+		default:
+			// CEP não processado (job cancelado)
+			records = append(records, []string{result.Cep, "", "", "", "", "", ""})
+		}
+	}
+	return []string{"cep", "city", "temp_C", "temp_F", "temp_K", "status", "error"}, records
+}
+
+// Struct com a situação de um job. Os resultados são consultados separadamente.
+type Job struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Country    string     `json:"country"`
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	Succeeded  int        `json:"succeeded"`
+	Failed     int        `json:"failed"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Estado interno do job
+type state struct {
+	Job
+	ceps    []string
+	results []Result
+	span    trace.Span
+	ctx     context.Context
+}
+
+// Consulta de um CEP enviada aos workers
+type task struct {
+	job   *state
+	index int
+}
+
+// Configuração do manager
+type Config struct {
+	// Quantidade de workers, ou seja, de consultas simultâneas ao service-b
+	Workers int
+	// Tempo que os jobs finalizados ficam disponíveis para consulta
+	Retention time.Duration
+	// Intervalo da remoção dos jobs expirados. Quando zerado, é utilizada a própria retenção.
+	CleanupInterval time.Duration
+	// Quantidade máxima de jobs armazenados, em andamento ou finalizados dentro da retenção.
+	// Quando zerado, não há limite.
+	MaxJobs int
+	// Quantidade máxima de jobs na fila ou em execução. Quando zerado, não há limite.
+	MaxPending int
+	// Função que valida e normaliza o código postal do país do job. Os códigos inválidos não são
+	// enviados ao service-b e a mensagem do erro é utilizada como detalhe do resultado.
+	Normalize func(pais, cep string) (string, error)
+}
+
+// Struct que gerencia os jobs e o pool de workers
+type Manager struct {
+	client serviceb.TemperaturaClient
+	tracer trace.Tracer
+	config Config
+	tasks  chan task
+	mu     sync.RWMutex
+	jobs   map[string]*state
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Função que cria o manager e inicia os workers
+func NewManager(client serviceb.TemperaturaClient, tracer trace.Tracer, config Config) *Manager {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		client: client,
+		tracer: tracer,
+		config: config,
+		tasks:  make(chan task),
+		jobs:   map[string]*state{},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < config.Workers; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+	if config.Retention > 0 {
+		if m.config.CleanupInterval <= 0 {
+			m.config.CleanupInterval = config.Retention
+		}
+		m.wg.Add(1)
+		go m.sweep()
+	}
+	return m
+}
+
+// Função que cria o job com os códigos postais do país informado e o coloca na fila. O span do job
+// é um novo trace, ligado (span link) ao span da requisição que o submeteu. Retorna ErrTooManyPending quando a fila está cheia e
+// ErrFull quando o limite de jobs armazenados foi atingido.
+func (m *Manager) Submit(ctx context.Context, pais string, ceps []string) (*Job, error) {
+	if m.ctx.Err() != nil {
+		return nil, ErrStopped
+	}
+	m.cleanup()
+
+	jobCtx, span := m.tracer.Start(m.ctx, "Job Consulta CEPs",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+	)
+	s := &state{
+		Job: Job{
+			ID:        newID(),
+			Status:    StatusQueued,
+			Country:   pais,
+			Total:     len(ceps),
+			CreatedAt: time.Now().UTC(),
+		},
+		ceps:    ceps,
+		results: make([]Result, len(ceps)),
+		span:    span,
+		ctx:     jobCtx,
+	}
+	span.SetAttributes(attribute.String("job.id", s.ID), attribute.Int("job.total", s.Total))
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("job.id", s.ID))
+
+	m.mu.Lock()
+	if err := m.checkLimits(); err != nil {
+		m.mu.Unlock()
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		return nil, err
+	}
+	m.jobs[s.ID] = s
+	job := s.Job
+	m.mu.Unlock()
+
+	if len(ceps) == 0 {
+		m.finish(s, StatusDone)
+		return m.Get(s.ID)
+	}
+
+	// Os CEPs são enviados aos workers em segundo plano, respeitando o limite de concorrência
+	m.wg.Add(1)
+	go m.feed(s)
+	return &job, nil
+}
+
+// Função que retorna a situação do job
+func (m *Manager) Get(id string) (*Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	job := s.Job
+	return &job, nil
+}
+
+// Função que retorna a situação e os resultados do job, na ordem em que os CEPs foram enviados
+func (m *Manager) Results(id string) (*Job, Results, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.jobs[id]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	job := s.Job
+	return &job, append(Results(nil), s.results...), nil
+}
+
+// Função que interrompe os workers. Os jobs não finalizados são marcados como cancelados.
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+
+	m.mu.RLock()
+	var pending []*state
+	for _, s := range m.jobs {
+		if s.Status == StatusQueued || s.Status == StatusRunning {
+			pending = append(pending, s)
+		}
+	}
+	m.mu.RUnlock()
+	for _, s := range pending {
+		m.finish(s, StatusCanceled)
+	}
+}
+
+// Função que envia os CEPs do job para os workers
+func (m *Manager) feed(s *state) {
+	defer m.wg.Done()
+	for i := range s.ceps {
+		select {
+		case <-m.ctx.Done():
+			return
+		case m.tasks <- task{job: s, index: i}:
+		}
+	}
+}
+
+// Função executada por cada worker do pool
+func (m *Manager) worker() {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case t := <-m.tasks:
+			m.process(t)
+		}
+	}
+}
+
+// Função que consulta um CEP e registra o resultado no job
+func (m *Manager) process(t task) {
+	s := t.job
+	cep := s.ceps[t.index]
+
+	m.mu.Lock()
+	if s.Status == StatusQueued {
+		now := time.Now().UTC()
+		s.Status = StatusRunning
+		s.StartedAt = &now
+	}
+	m.mu.Unlock()
+
+	result := Result{Cep: cep}
+	var err error
+	if m.config.Normalize != nil {
+		cep, err = m.config.Normalize(s.Country, cep)
+	}
+	if err != nil {
+		result.Error = problem.InvalidZipcode(err.Error())
+	} else {
+		ctx, span := m.tracer.Start(s.ctx, "Job Consulta service-b")
+		span.SetAttributes(attribute.String("cep", cep), attribute.String("country", s.Country))
+		clima, err := m.client.BuscaTemperatura(ctx, s.Country, cep)
+		if err != nil {
+			result.Error = serviceb.ToProblem(err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, result.Error.Message)
+		} else {
+			result.Clima = clima
+		}
+		span.End()
+	}
+
+	m.mu.Lock()
+	s.results[t.index] = result
+	s.Processed++
+	if result.Error != nil {
+		s.Failed++
+	} else {
+		s.Succeeded++
+	}
+	done := s.Processed == s.Total
+	m.mu.Unlock()
+
+	if done {
+		m.finish(s, StatusDone)
+	}
+}
+
+// Função que finaliza o job e o seu span
+func (m *Manager) finish(s *state, status string) {
+	m.mu.Lock()
+	if s.FinishedAt != nil {
+		m.mu.Unlock()
+		return
+	}
+	now := time.Now().UTC()
+	s.Status = status
+	s.FinishedAt = &now
+	job := s.Job
+	m.mu.Unlock()
+
+	s.span.SetAttributes(
+		attribute.String("job.status", job.Status),
+		attribute.Int("job.succeeded", job.Succeeded),
+		attribute.Int("job.failed", job.Failed),
+	)
+	if status == StatusCanceled {
+		s.span.SetStatus(codes.Error, "job cancelado")
+	}
+	s.span.End()
+}
+
+// Função que verifica os limites de jobs armazenados e pendentes. Deve ser chamada com o lock.
+func (m *Manager) checkLimits() error {
+	if m.config.MaxJobs > 0 && len(m.jobs) >= m.config.MaxJobs {
+		return ErrFull
+	}
+	if m.config.MaxPending > 0 {
+		pending := 0
+		for _, s := range m.jobs {
+			if s.FinishedAt == nil {
+				pending++
+			}
+		}
+		if pending >= m.config.MaxPending {
+			return ErrTooManyPending
+		}
+	}
+	return nil
+}
+
+// Função que remove os jobs expirados periodicamente até o manager ser interrompido
+func (m *Manager) sweep() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(m.config.CleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+			m.cleanup()
+		}
+	}
+}
+
+// Função que remove os jobs finalizados há mais tempo que a retenção configurada
+func (m *Manager) cleanup() {
+	if m.config.Retention <= 0 {
+		return
+	}
+	limit := time.Now().Add(-m.config.Retention)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, s := range m.jobs {
+		if s.FinishedAt != nil && s.FinishedAt.Before(limit) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// Gera um identificador aleatório
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
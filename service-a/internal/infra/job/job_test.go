@@ -0,0 +1,223 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Client fake do service-b que registra a maior quantidade de consultas simultâneas
+type fakeClient struct {
+	active  atomic.Int32
+	max     atomic.Int32
+	release chan struct{}
+}
+
+func (f *fakeClient) BuscaTemperatura(ctx context.Context, pais, cep string) (*serviceb.ClimaCidade, error) {
+	active := f.active.Add(1)
+	defer f.active.Add(-1)
+	for {
+		max := f.max.Load()
+		if active <= max || f.max.CompareAndSwap(max, active) {
+			break
+		}
+	}
+	if f.release != nil {
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if cep == "00000000" {
+		return nil, &serviceb.StatusError{StatusCode: http.StatusNotFound}
+	}
+	if pais == "PT" {
+		return &serviceb.ClimaCidade{Cidade: "Lisboa", TempC: 18.5}, nil
+	}
+	return &serviceb.ClimaCidade{Cidade: "Ibirité", TempC: 28.5}, nil
+}
+
+func normalize(pais, cep string) (string, error) {
+	if pais == "PT" {
+		if len(cep) != 7 {
+			return "", errors.New("the postal code must contain exactly 7 digits")
+		}
+		return cep, nil
+	}
+	if len(cep) != 8 {
+		return "", errors.New("the zipcode must contain exactly 8 digits")
+	}
+	return cep, nil
+}
+
+func waitStatus(t *testing.T, m *Manager, id, status string) *Job {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		job, err := m.Get(id)
+		require.NoError(t, err)
+		if job.Status == status {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job não chegou na situação %s", status)
+	return nil
+}
+
+// O job deve processar todos os CEPs, respeitando o limite de workers e a ordem dos resultados
+func TestManagerProcessaJob(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	client := &fakeClient{}
+	m := NewManager(client, tracer, Config{Workers: 3, Normalize: normalize})
+	defer m.Stop()
+
+	ceps := make([]string, 50)
+	for i := range ceps {
+		ceps[i] = fmt.Sprintf("%08d", 32450000+i)
+	}
+	ceps[10] = "00000000"
+	ceps[20] = "123"
+
+	ctx, requestSpan := tracer.Start(context.Background(), "requisição")
+	created, err := m.Submit(ctx, "BR", ceps)
+	requestSpan.End()
+	require.NoError(t, err)
+	assert.Equal(t, 50, created.Total)
+
+	job := waitStatus(t, m, created.ID, StatusDone)
+	assert.Equal(t, 50, job.Processed)
+	assert.Equal(t, 48, job.Succeeded)
+	assert.Equal(t, 2, job.Failed)
+	assert.NotNil(t, job.StartedAt)
+	assert.NotNil(t, job.FinishedAt)
+	assert.LessOrEqual(t, client.max.Load(), int32(3))
+
+	_, results, err := m.Results(created.ID)
+	require.NoError(t, err)
+	require.Len(t, results, 50)
+	assert.Equal(t, ceps[0], results[0].Cep)
+	assert.Equal(t, "Ibirité", results[0].Clima.Cidade)
+	assert.Equal(t, http.StatusNotFound, results[10].Error.Status)
+	assert.Equal(t, http.StatusUnprocessableEntity, results[20].Error.Status)
+
+	// O span do job é um novo trace, ligado ao span da requisição
+	var jobSpan sdktrace.ReadOnlySpan
+	consultas := 0
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "Job Consulta CEPs":
+			jobSpan = span
+		case "Job Consulta service-b":
+			consultas++
+		}
+	}
+	require.NotNil(t, jobSpan)
+	assert.NotEqual(t, requestSpan.SpanContext().TraceID(), jobSpan.SpanContext().TraceID())
+	require.Len(t, jobSpan.Links(), 1)
+	assert.Equal(t, requestSpan.SpanContext().SpanID(), jobSpan.Links()[0].SpanContext.SpanID())
+	assert.Equal(t, 49, consultas)
+}
+
+// Os códigos postais do job são validados e consultados no país do job
+func TestManagerPais(t *testing.T) {
+	m := NewManager(&fakeClient{}, sdktrace.NewTracerProvider().Tracer("test"), Config{Workers: 2, Normalize: normalize})
+	defer m.Stop()
+
+	created, err := m.Submit(context.Background(), "PT", []string{"1000001", "32450000"})
+	require.NoError(t, err)
+	assert.Equal(t, "PT", created.Country)
+
+	job := waitStatus(t, m, created.ID, StatusDone)
+	assert.Equal(t, 1, job.Succeeded)
+	_, results, err := m.Results(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Lisboa", results[0].Clima.Cidade)
+	assert.Equal(t, http.StatusUnprocessableEntity, results[1].Error.Status)
+}
+
+// O Stop deve cancelar os jobs em andamento e recusar novos jobs
+func TestManagerStop(t *testing.T) {
+	client := &fakeClient{release: make(chan struct{})}
+	m := NewManager(client, sdktrace.NewTracerProvider().Tracer("test"), Config{Workers: 2, Normalize: normalize})
+
+	created, err := m.Submit(context.Background(), "BR", []string{"32450000", "32450001", "32450002"})
+	require.NoError(t, err)
+	waitStatus(t, m, created.ID, StatusRunning)
+
+	m.Stop()
+
+	job, err := m.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, job.Status)
+
+	_, err = m.Submit(context.Background(), "BR", []string{"32450000"})
+	assert.ErrorIs(t, err, ErrStopped)
+}
+
+// Os jobs finalizados são removidos depois da retenção
+func TestManagerRetencao(t *testing.T) {
+	m := NewManager(&fakeClient{}, sdktrace.NewTracerProvider().Tracer("test"), Config{Workers: 1, Retention: time.Millisecond, CleanupInterval: time.Hour})
+	defer m.Stop()
+
+	created, err := m.Submit(context.Background(), "BR", []string{"32450000"})
+	require.NoError(t, err)
+	waitStatus(t, m, created.ID, StatusDone)
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = m.Submit(context.Background(), "BR", []string{"32450000"})
+	require.NoError(t, err)
+	_, err = m.Get(created.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// Os jobs expirados são removidos periodicamente, mesmo sem novos jobs
+func TestManagerRetencaoPeriodica(t *testing.T) {
+	m := NewManager(&fakeClient{}, sdktrace.NewTracerProvider().Tracer("test"), Config{
+		Workers:         1,
+		Retention:       50 * time.Millisecond,
+		CleanupInterval: 10 * time.Millisecond,
+	})
+	defer m.Stop()
+
+	created, err := m.Submit(context.Background(), "BR", []string{"32450000"})
+	require.NoError(t, err)
+	waitStatus(t, m, created.ID, StatusDone)
+
+	assert.Eventually(t, func() bool {
+		_, err := m.Get(created.ID)
+		return errors.Is(err, ErrNotFound)
+	}, time.Second, 10*time.Millisecond)
+}
+
+// Os novos jobs são recusados quando a fila ou o armazenamento atingem o limite
+func TestManagerLimites(t *testing.T) {
+	client := &fakeClient{release: make(chan struct{})}
+	m := NewManager(client, sdktrace.NewTracerProvider().Tracer("test"), Config{Workers: 1, MaxPending: 1, MaxJobs: 2})
+	defer m.Stop()
+
+	first, err := m.Submit(context.Background(), "BR", []string{"32450000"})
+	require.NoError(t, err)
+	_, err = m.Submit(context.Background(), "BR", []string{"32450000"})
+	assert.ErrorIs(t, err, ErrTooManyPending)
+
+	close(client.release)
+	waitStatus(t, m, first.ID, StatusDone)
+	second, err := m.Submit(context.Background(), "BR", []string{"32450000"})
+	require.NoError(t, err)
+	waitStatus(t, m, second.ID, StatusDone)
+
+	_, err = m.Submit(context.Background(), "BR", []string{"32450000"})
+	assert.ErrorIs(t, err, ErrFull)
+}
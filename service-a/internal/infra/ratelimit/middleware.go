@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Requisições rejeitadas, por plano. Publicadas no /metrics.
+var rejectedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "ratelimit_rejected_requests_total",
+	Help: "Quantidade de requisições rejeitadas pelo rate limit, por plano.",
+}, []string{"plan"})
+
+// Configuração do middleware
+type Config struct {
+	Limiter Limiter
+	// Planos disponíveis. O plano DefaultPlan é utilizado para os clientes identificados pelo IP.
+	Plans map[string]Plan
+	// Identificação do cliente autenticado no contexto da requisição e o plano de cada cliente.
+	// Os clientes sem plano utilizam o DefaultPlan.
+	ClientID func(ctx context.Context) string
+	Clients  map[string]string
+	Tracer   trace.Tracer
+}
+
+// Função que identifica o cliente. Um cliente autenticado (chave de API ou token JWT validados
+// pelo pacote auth) utiliza o plano dele; as demais requisições são identificadas pelo endereço da
+// conexão e utilizam o DefaultPlan. Os headers X-Forwarded-For e X-Real-IP só alteram esse endereço
+// quando enviados por um proxy confiável (realip.Middleware).
+func (c Config) identify(r *http.Request) (key, keyType string, plan Plan) {
+	if c.ClientID != nil {
+		if clientID := c.ClientID(r.Context()); clientID != "" {
+			name, ok := c.Clients[clientID]
+			if !ok {
+				name = DefaultPlan
+			}
+			return "client:" + clientID, "client", c.Plans[name]
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host, "ip", c.Plans[DefaultPlan]
+}
+
+// Bucket do cliente da requisição, guardado no contexto pelo middleware para o Charge
+type charger struct {
+	config Config
+	key    string
+	plan   Plan
+}
+
+type chargerKey struct{}
+
+// Middleware que aplica o rate limit por cliente. Cada requisição consome um token. Todas as
+// respostas recebem os headers RateLimit-*; as requisições rejeitadas recebem o status 429 e o
+// header Retry-After. Em caso de falha no backend, a requisição é permitida.
+func Middleware(config Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, keyType, plan := config.identify(r)
+			c := &charger{config: config, key: key, plan: plan}
+			if !c.allow(w, r, 1, attribute.String("ratelimit.key_type", keyType)) {
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), chargerKey{}, c)))
+		})
+	}
+}
+
+// Função que cobra as rotas em que o custo depende da quantidade de CEPs da requisição (GraphQL e
+// jobs), para que uma única requisição não consulte mais CEPs do que o plano permite. O Middleware
+// já consumiu um token, então são consumidos mais ceps-1 tokens do mesmo bucket. Uma requisição
+// com mais CEPs do que a capacidade do bucket nunca seria permitida e é rejeitada sem o header
+// Retry-After. Quando a requisição é rejeitada, a resposta 429 é escrita e a função retorna false.
+// Sem o Middleware na rota, ou em caso de falha no backend, a requisição é permitida.
+func Charge(w http.ResponseWriter, r *http.Request, ceps int) bool {
+	c, ok := r.Context().Value(chargerKey{}).(*charger)
+	if !ok || ceps <= 1 {
+		return true
+	}
+	if ceps > c.plan.capacity() {
+		rejectedRequests.WithLabelValues(c.plan.Name).Inc()
+		problem.Write(r.Context(), w, r, problem.TooManyRequests("").Detailf("the request contains %d zipcodes and the %s plan allows at most %d at once", ceps, c.plan.Name, c.plan.capacity()))
+		return false
+	}
+	return c.allow(w, r, ceps-1, attribute.Int("ratelimit.ceps", ceps))
+}
+
+// Função que consome os tokens do bucket e escreve os headers RateLimit-*. Quando a requisição é
+// rejeitada, escreve a resposta 429 e retorna false.
+func (c *charger) allow(w http.ResponseWriter, r *http.Request, cost int, attrs ...attribute.KeyValue) bool {
+	ctx, span := c.config.Tracer.Start(r.Context(), "Rate Limit")
+	defer span.End()
+	span.SetAttributes(attribute.String("ratelimit.plan", c.plan.Name), attribute.Int("ratelimit.cost", cost))
+	span.SetAttributes(attrs...)
+
+	result, err := c.config.Limiter.Allow(ctx, c.key, c.plan, cost)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Erro ao consultar o rate limit")
+		log.Printf("Erro ao consultar o rate limit do cliente %s: %s", c.key, err)
+		return true
+	}
+
+	header := w.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d", c.plan.Limit, ceilSeconds(c.plan.Window), c.plan.capacity()))
+	span.SetAttributes(attribute.Int("ratelimit.remaining", result.Remaining))
+
+	if result.Allowed {
+		return true
+	}
+	retryAfter := max(1, ceilSeconds(result.RetryAfter))
+	rejectedRequests.WithLabelValues(c.plan.Name).Inc()
+	span.AddEvent("rate limit exceeded", trace.WithAttributes(
+		attribute.String("ratelimit.plan", c.plan.Name),
+		attribute.Int("ratelimit.retry_after", retryAfter),
+	))
+	span.SetStatus(codes.Error, "rate limit exceeded")
+	header.Set("Retry-After", strconv.Itoa(retryAfter))
+	problem.Write(ctx, w, r, problem.TooManyRequests("").Detailf("the %s plan allows %d requests every %s", c.plan.Name, c.plan.Limit, c.plan.Window))
+	return false
+}
+
+// Converte a duração em segundos inteiros, arredondando para cima
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Backend que sempre falha
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, Plan, int) (Result, error) {
+	return Result{}, errors.New("redis indisponível")
+}
+
+func newHandler(t *testing.T, limiter Limiter) (http.Handler, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	plans, err := ParsePlans("anonymous=2/1m,pro=100/1m")
+	require.NoError(t, err)
+	handler := Middleware(Config{
+		Limiter: limiter,
+		Plans:   plans,
+		Tracer:  sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test"),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	return handler, recorder
+}
+
+func request(handler http.Handler, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/cep", nil)
+	req.RemoteAddr = ip + ":12345"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+// Clientes identificados pelo IP utilizam o plano anonymous
+func TestMiddleware(t *testing.T) {
+	handler, recorder := newHandler(t, NewMemory())
+	before := testutil.ToFloat64(rejectedRequests.WithLabelValues(DefaultPlan))
+
+	w := request(handler, "10.0.0.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=60;burst=2", w.Header().Get("RateLimit-Policy"))
+
+	assert.Equal(t, http.StatusOK, request(handler, "10.0.0.1").Code)
+
+	w = request(handler, "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	var details problem.Details
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+	assert.Equal(t, problem.TypeTooManyRequests, details.Type)
+	assert.Equal(t, before+1, testutil.ToFloat64(rejectedRequests.WithLabelValues(DefaultPlan)))
+
+	spans := recorder.Ended()
+	last := spans[len(spans)-1]
+	require.Len(t, last.Events(), 1)
+	assert.Equal(t, "rate limit exceeded", last.Events()[0].Name)
+
+	// Uma chave de API não autenticada não altera o bucket
+	req := httptest.NewRequest(http.MethodPost, "/cep", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	req.Header.Set("X-API-Key", "chave-pro")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	// Os headers de IP enviados pelo próprio cliente não alteram o bucket
+	req = httptest.NewRequest(http.MethodPost, "/cep", nil)
+	req.RemoteAddr = "10.0.0.1:12345"
+	req.Header.Set("X-Forwarded-For", "10.0.0.9")
+	req.Header.Set("X-Real-IP", "10.0.0.9")
+	req.Header.Set("True-Client-IP", "10.0.0.9")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	// Outro IP possui o seu próprio bucket
+	assert.Equal(t, http.StatusOK, request(handler, "10.0.0.2").Code)
+}
+
+// Clientes autenticados possuem o próprio bucket, independente do IP, com o plano configurado
+func TestMiddlewareClienteAutenticado(t *testing.T) {
+	type clientKey struct{}
+	plans, err := ParsePlans("anonymous=1/1m,pro=100/1m")
+	require.NoError(t, err)
+	handler := Middleware(Config{
+		Limiter:  NewMemory(),
+		Plans:    plans,
+		ClientID: func(ctx context.Context) string { id, _ := ctx.Value(clientKey{}).(string); return id },
+		Clients:  map[string]string{"app-pro": "pro"},
+		Tracer:   sdktrace.NewTracerProvider().Tracer("test"),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(clientID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/cep", nil)
+		if clientID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), clientKey{}, clientID))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve("").Code)
+	// Cliente sem plano configurado utiliza o plano anonymous, em um bucket separado do IP
+	assert.Equal(t, http.StatusOK, serve("app-free").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve("app-free").Code)
+	w := serve("app-pro")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get("RateLimit-Limit"))
+}
+
+// Com o backend indisponível, as requisições são permitidas
+func TestMiddlewareFalhaBackend(t *testing.T) {
+	handler, recorder := newHandler(t, failingLimiter{})
+	w := request(handler, "10.0.0.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	require.Len(t, recorder.Ended(), 1)
+	assert.Equal(t, "Erro ao consultar o rate limit", recorder.Ended()[0].Status().Description)
+}
+
+// As rotas com listas de CEPs consomem um token por CEP do mesmo bucket do middleware
+func TestCharge(t *testing.T) {
+	plans, err := ParsePlans("anonymous=5/1m")
+	require.NoError(t, err)
+	handler := Middleware(Config{
+		Limiter: NewMemory(),
+		Plans:   plans,
+		Tracer:  sdktrace.NewTracerProvider().Tracer("test"),
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ceps, _ := strconv.Atoi(r.URL.Query().Get("ceps"))
+		if Charge(w, r, ceps) {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	serve := func(ceps int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/jobs?ceps="+strconv.Itoa(ceps), nil)
+		req.RemoteAddr = "10.0.0.1:12345"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(3)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Remaining"))
+
+	// Mais CEPs do que a capacidade do bucket nunca seriam permitidos
+	w = serve(6)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	// O token de cada requisição é consumido pelo middleware, mesmo quando o Charge a rejeita
+	w = serve(3)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(1).Code)
+
+	// Sem o middleware, a requisição é permitida
+	assert.True(t, Charge(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/jobs", nil), 100))
+}
+
+func TestCeilSeconds(t *testing.T) {
+	assert.Equal(t, 0, ceilSeconds(0))
+	assert.Equal(t, 1, ceilSeconds(10*time.Millisecond))
+	assert.Equal(t, 60, ceilSeconds(time.Minute))
+}
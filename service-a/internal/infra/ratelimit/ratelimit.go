@@ -0,0 +1,206 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Plano utilizado pelos clientes identificados apenas pelo IP e pelos clientes autenticados sem plano
+const DefaultPlan = "anonymous"
+
+// Erro retornado quando a configuração dos planos ou dos clientes é inválida
+var ErrInvalidConfig = errors.New("invalid rate limit config")
+
+// Plano de uso: Limit requisições a cada Window, com rajadas de até Burst requisições.
+// O bucket recebe Limit/Window tokens por segundo e cada requisição consome um token. As rotas
+// com listas de CEPs (GraphQL e jobs) consomem um token por CEP (ver Charge).
+type Plan struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+	Burst  int
+}
+
+// Quantidade de tokens adicionados ao bucket por segundo
+func (p Plan) rate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+// Capacidade do bucket. Quando o Burst não é informado, é utilizado o Limit.
+func (p Plan) capacity() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// Resultado da avaliação de uma requisição
+type Result struct {
+	Allowed bool
+	// Capacidade do bucket e tokens restantes após a requisição
+	Limit     int
+	Remaining int
+	// Tempo até o bucket ficar cheio novamente
+	Reset time.Duration
+	// Tempo até o próximo token, preenchido quando a requisição é rejeitada
+	RetryAfter time.Duration
+}
+
+// Interface dos backends de rate limit. A requisição é permitida quando o bucket possui os cost
+// tokens, que são consumidos de uma vez; uma requisição rejeitada não consome tokens.
+type Limiter interface {
+	Allow(ctx context.Context, key string, plan Plan, cost int) (Result, error)
+}
+
+// Função que calcula o bucket após a requisição. Recebe os tokens, o instante da última
+// atualização e o custo da requisição e retorna o resultado e os novos tokens. Utilizada pelo backend em memória;
+// o backend Redis executa o mesmo cálculo no script Lua.
+func take(plan Plan, tokens float64, last, now time.Time, cost int) (Result, float64) {
+	capacity := float64(plan.capacity())
+	rate := plan.rate()
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed*rate)
+	}
+
+	result := Result{Limit: plan.capacity()}
+	if tokens >= float64(cost) {
+		tokens -= float64(cost)
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((float64(cost) - tokens) / rate)
+	}
+	result.Remaining = int(tokens)
+	result.Reset = seconds((capacity - tokens) / rate)
+	return result, tokens
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Bucket de um cliente no backend em memória
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// Instante em que o bucket estará cheio novamente
+	full time.Time
+}
+
+// Backend em memória. Cada instância do service-a mantém os seus próprios buckets.
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// Intervalo mínimo entre as remoções dos buckets cheios
+const sweepInterval = time.Minute
+
+// Função que cria o backend em memória
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+func (m *Memory) Allow(ctx context.Context, key string, plan Plan, cost int) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	key = plan.Name + ":" + key
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(plan.capacity()), last: now}
+		m.buckets[key] = b
+	}
+	result, tokens := take(plan, b.tokens, b.last, now, cost)
+	b.tokens = tokens
+	b.last = now
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// Remove os buckets que já estariam cheios, pois equivalem a um bucket novo
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// Função que interpreta a lista de planos no formato "nome=limite/janela[:burst]", separados
+// por vírgula. Exemplo: "anonymous=60/1m,pro=600/1m:100".
+func ParsePlans(s string) (map[string]Plan, error) {
+	plans := map[string]Plan{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: plan %q must be in the format name=limit/window[:burst]", ErrInvalidConfig, item)
+		}
+		spec, burst, hasBurst := strings.Cut(spec, ":")
+		limit, window, ok := strings.Cut(spec, "/")
+		if !ok {
+			return nil, fmt.Errorf("%w: plan %q must be in the format name=limit/window[:burst]", ErrInvalidConfig, item)
+		}
+
+		plan := Plan{Name: strings.TrimSpace(name)}
+		var err error
+		if plan.Limit, err = strconv.Atoi(limit); err != nil || plan.Limit <= 0 {
+			return nil, fmt.Errorf("%w: plan %q has an invalid limit", ErrInvalidConfig, item)
+		}
+		if plan.Window, err = time.ParseDuration(window); err != nil || plan.Window <= 0 {
+			return nil, fmt.Errorf("%w: plan %q has an invalid window", ErrInvalidConfig, item)
+		}
+		if hasBurst {
+			if plan.Burst, err = strconv.Atoi(burst); err != nil || plan.Burst <= 0 {
+				return nil, fmt.Errorf("%w: plan %q has an invalid burst", ErrInvalidConfig, item)
+			}
+		}
+		plans[plan.Name] = plan
+	}
+	if _, ok := plans[DefaultPlan]; !ok {
+		return nil, fmt.Errorf("%w: the %q plan is required", ErrInvalidConfig, DefaultPlan)
+	}
+	return plans, nil
+}
+
+// Função que interpreta a lista de clientes autenticados no formato "cliente=plano", separados por vírgula.
+// Todos os planos informados devem existir.
+func ParseClients(s string, plans map[string]Plan) (map[string]string, error) {
+	clients := map[string]string{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		client, plan, ok := strings.Cut(item, "=")
+		if !ok || client == "" {
+			return nil, fmt.Errorf("%w: client entries must be in the format client=plan", ErrInvalidConfig)
+		}
+		if _, ok := plans[plan]; !ok {
+			return nil, fmt.Errorf("%w: unknown plan %q", ErrInvalidConfig, plan)
+		}
+		clients[client] = plan
+	}
+	return clients, nil
+}
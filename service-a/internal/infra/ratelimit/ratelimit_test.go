@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Relógio controlado pelos testes
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+// Os dois backends devem ter o mesmo comportamento
+func backends(t *testing.T, c *clock) map[string]Limiter {
+	memory := NewMemory()
+	memory.now = c.Now
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	rds := NewRedis(client, "ratelimit:")
+	rds.now = c.Now
+
+	return map[string]Limiter{"memory": memory, "redis": rds}
+}
+
+// O bucket deve permitir a rajada, rejeitar a requisição seguinte e ser reabastecido com o tempo
+func TestTokenBucket(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	plan := Plan{Name: "free", Limit: 60, Window: time.Minute, Burst: 3}
+	ctx := context.Background()
+
+	for name, limiter := range backends(t, c) {
+		t.Run(name, func(t *testing.T) {
+			c.now = time.Unix(1700000000, 0)
+			for i := 2; i >= 0; i-- {
+				result, err := limiter.Allow(ctx, "ip:10.0.0.1", plan, 1)
+				require.NoError(t, err)
+				assert.True(t, result.Allowed)
+				assert.Equal(t, 3, result.Limit)
+				assert.Equal(t, i, result.Remaining)
+			}
+
+			result, err := limiter.Allow(ctx, "ip:10.0.0.1", plan, 1)
+			require.NoError(t, err)
+			assert.False(t, result.Allowed)
+			assert.Equal(t, 0, result.Remaining)
+			assert.Equal(t, time.Second, result.RetryAfter)
+			assert.Equal(t, 3*time.Second, result.Reset)
+
+			// Outro cliente possui o seu próprio bucket
+			result, err = limiter.Allow(ctx, "ip:10.0.0.2", plan, 1)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+
+			// Um token por segundo
+			c.now = c.now.Add(1500 * time.Millisecond)
+			result, err = limiter.Allow(ctx, "ip:10.0.0.1", plan, 1)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			result, err = limiter.Allow(ctx, "ip:10.0.0.1", plan, 1)
+			require.NoError(t, err)
+			assert.False(t, result.Allowed)
+			assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+			// O bucket não passa da capacidade
+			c.now = c.now.Add(time.Hour)
+			result, err = limiter.Allow(ctx, "ip:10.0.0.1", plan, 1)
+			require.NoError(t, err)
+			assert.Equal(t, 2, result.Remaining)
+		})
+	}
+}
+
+// Uma requisição com custo maior consome vários tokens de uma vez, e a rejeitada não consome nenhum
+func TestTokenBucketCusto(t *testing.T) {
+	plan := Plan{Name: "free", Limit: 60, Window: time.Minute, Burst: 5}
+	ctx := context.Background()
+
+	for name, limiter := range backends(t, &clock{now: time.Unix(1700000000, 0)}) {
+		t.Run(name, func(t *testing.T) {
+			result, err := limiter.Allow(ctx, "ip:10.0.0.1", plan, 3)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 2, result.Remaining)
+
+			result, err = limiter.Allow(ctx, "ip:10.0.0.1", plan, 3)
+			require.NoError(t, err)
+			assert.False(t, result.Allowed)
+			assert.Equal(t, 2, result.Remaining)
+			assert.Equal(t, time.Second, result.RetryAfter)
+
+			result, err = limiter.Allow(ctx, "ip:10.0.0.1", plan, 2)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+			assert.Equal(t, 0, result.Remaining)
+		})
+	}
+}
+
+// Os buckets cheios devem ser removidos do backend em memória
+func TestMemorySweep(t *testing.T) {
+	c := &clock{now: time.Unix(1700000000, 0)}
+	memory := NewMemory()
+	memory.now = c.Now
+	plan := Plan{Name: "free", Limit: 60, Window: time.Minute}
+
+	memory.Allow(context.Background(), "ip:10.0.0.1", plan, 1)
+	c.now = c.now.Add(2 * time.Minute)
+	memory.Allow(context.Background(), "ip:10.0.0.2", plan, 1)
+	assert.Len(t, memory.buckets, 1)
+}
+
+func TestParsePlans(t *testing.T) {
+	plans, err := ParsePlans("anonymous=60/1m, pro=600/1m:100")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Plan{
+		"anonymous": {Name: "anonymous", Limit: 60, Window: time.Minute},
+		"pro":       {Name: "pro", Limit: 600, Window: time.Minute, Burst: 100},
+	}, plans)
+
+	for _, invalid := range []string{"pro=600/1m", "anonymous=60", "anonymous=0/1m", "anonymous=60/xx", "anonymous=60/1m:0", "anonymous"} {
+		_, err := ParsePlans(invalid)
+		assert.ErrorIs(t, err, ErrInvalidConfig, invalid)
+	}
+
+	clients, err := ParseClients("app-mobile=pro, batch=anonymous", plans)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"app-mobile": "pro", "batch": "anonymous"}, clients)
+	_, err = ParseClients("app-mobile", plans)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = ParseClients("app-mobile=gold", plans)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Script Lua com o mesmo cálculo do take, executado de forma atômica no Redis.
+// KEYS[1]: chave do bucket; ARGV: capacidade, tokens por segundo, instante atual (ms) e custo.
+// Retorna: permitido (0 ou 1), tokens restantes, reset (ms) e retry after (ms).
+var tokenBucket = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(bucket[1])
+local last = tonumber(bucket[2])
+if tokens == nil then
+	tokens = capacity
+	last = now
+end
+
+local elapsed = (now - last) / 1000
+if elapsed > 0 then
+	tokens = math.min(capacity, tokens + elapsed * rate)
+end
+
+local allowed = 0
+local retry = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+else
+	retry = math.ceil((cost - tokens) / rate * 1000)
+end
+local reset = math.ceil((capacity - tokens) / rate * 1000)
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "last", now)
+redis.call("PEXPIRE", KEYS[1], math.max(reset, 1))
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+// Backend Redis. Os buckets são compartilhados entre as instâncias do service-a.
+type Redis struct {
+	client redis.Scripter
+	prefix string
+	now    func() time.Time
+}
+
+// Função que cria o backend Redis. As chaves dos buckets recebem o prefixo informado.
+func NewRedis(client redis.Scripter, prefix string) *Redis {
+	return &Redis{
+		client: client,
+		prefix: prefix,
+		now:    time.Now,
+	}
+}
+
+func (r *Redis) Allow(ctx context.Context, key string, plan Plan, cost int) (Result, error) {
+	values, err := tokenBucket.Run(ctx, r.client, []string{r.prefix + plan.Name + ":" + key},
+		plan.capacity(), plan.rate(), r.now().UnixMilli(), cost,
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      plan.capacity(),
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,352 @@
+package serviceb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Políticas de balanceamento entre as instâncias do service-b
+const (
+	// As instâncias são utilizadas em sequência
+	PolicyRoundRobin = "round-robin"
+	// A instância com menos consultas em andamento é utilizada
+	PolicyLeastOutstanding = "least-outstanding"
+	// Duas instâncias são sorteadas e a com menos consultas em andamento é utilizada
+	PolicyPowerOfTwo = "p2c"
+)
+
+// Valores padrão do health check passivo
+const (
+	DefaultMaxFailures  = 3
+	DefaultEjectionTime = 30 * time.Second
+)
+
+// Erro retornado quando a configuração do balanceamento é inválida
+var ErrInvalidBalancerConfig = errors.New("invalid balancer config")
+
+// Atributo dos spans com a instância do service-b utilizada
+const EndpointAttribute = "serviceb.endpoint"
+
+// Configuração do balanceamento
+type BalancerConfig struct {
+	Policy string
+	// Quantidade de falhas consecutivas que retira a instância do balanceamento e o tempo que
+	// ela fica fora. Depois desse tempo, uma nova falha retira a instância novamente.
+	MaxFailures  int
+	EjectionTime time.Duration
+	Tracer       trace.Tracer
+}
+
+// Função que cria o client de uma instância a partir do endereço
+type ClientFactory func(address string) (TemperaturaClient, error)
+
+// Instância do service-b, com as consultas em andamento e o estado do health check passivo
+type endpoint struct {
+	address     string
+	client      TemperaturaClient
+	outstanding atomic.Int64
+	// Indica que a instância foi removida pelo Update. O client é encerrado uma única vez,
+	// quando não há mais consultas em andamento.
+	removed   atomic.Bool
+	closeOnce sync.Once
+
+	mu           sync.Mutex
+	failures     int
+	ejectedUntil time.Time
+}
+
+// Verifica se a instância pode receber consultas
+func (e *endpoint) healthy(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !now.Before(e.ejectedUntil)
+}
+
+// Função que encerra a consulta em andamento. A instância removida é encerrada quando a última
+// consulta termina.
+func (e *endpoint) release() {
+	if e.outstanding.Add(-1) == 0 && e.removed.Load() {
+		e.close()
+	}
+}
+
+// Encerra o client da instância, uma única vez
+func (e *endpoint) close() (err error) {
+	e.closeOnce.Do(func() {
+		if closer, ok := e.client.(io.Closer); ok {
+			err = closer.Close()
+		}
+	})
+	return err
+}
+
+// Registra o resultado de uma consulta. Retorna true quando a instância é retirada do balanceamento.
+func (e *endpoint) record(failed bool, config BalancerConfig) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !failed {
+		e.failures = 0
+		return false
+	}
+	e.failures++
+	if e.failures < config.MaxFailures {
+		return false
+	}
+	// Depois da ejeção, uma única falha retira a instância novamente
+	e.failures = config.MaxFailures - 1
+	e.ejectedUntil = time.Now().Add(config.EjectionTime)
+	return true
+}
+
+// Client que distribui as consultas entre várias instâncias do service-b. As instâncias com
+// falhas consecutivas são retiradas temporariamente do balanceamento (health check passivo).
+type BalancedClient struct {
+	factory ClientFactory
+	config  BalancerConfig
+	next    atomic.Uint64
+
+	// Serializa as atualizações da lista de instâncias
+	updateMu sync.Mutex
+
+	mu        sync.RWMutex
+	endpoints []*endpoint
+}
+
+// Função que cria o client balanceado com os endereços informados
+func NewBalancedClient(factory ClientFactory, addresses []string, config BalancerConfig) (*BalancedClient, error) {
+	switch config.Policy {
+	case "":
+		config.Policy = PolicyRoundRobin
+	case PolicyRoundRobin, PolicyLeastOutstanding, PolicyPowerOfTwo:
+	default:
+		return nil, fmt.Errorf("%w: unknown policy %q", ErrInvalidBalancerConfig, config.Policy)
+	}
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = DefaultMaxFailures
+	}
+	if config.EjectionTime <= 0 {
+		config.EjectionTime = DefaultEjectionTime
+	}
+	if config.Tracer == nil {
+		config.Tracer = otel.Tracer("serviceb")
+	}
+	b := &BalancedClient{factory: factory, config: config}
+	if err := b.Update(addresses); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Função que atualiza a lista de instâncias. As instâncias mantidas preservam o estado e as
+// removidas são encerradas depois da troca, assim que as consultas em andamento terminam. Em caso
+// de erro na criação de um client, a lista não é alterada e os clients já criados são encerrados.
+func (b *BalancedClient) Update(addresses []string) error {
+	if len(addresses) == 0 {
+		return fmt.Errorf("%w: no service-b endpoint", ErrInvalidBalancerConfig)
+	}
+	b.updateMu.Lock()
+	defer b.updateMu.Unlock()
+
+	b.mu.RLock()
+	current := map[string]*endpoint{}
+	for _, e := range b.endpoints {
+		current[e.address] = e
+	}
+	b.mu.RUnlock()
+
+	endpoints := make([]*endpoint, 0, len(addresses))
+	var created []*endpoint
+	for _, address := range addresses {
+		if e, ok := current[address]; ok {
+			endpoints = append(endpoints, e)
+			delete(current, address)
+			continue
+		}
+		client, err := b.factory(address)
+		if err != nil {
+			for _, e := range created {
+				e.close()
+			}
+			return err
+		}
+		e := &endpoint{address: address, client: client}
+		endpoints = append(endpoints, e)
+		created = append(created, e)
+	}
+
+	b.mu.Lock()
+	b.endpoints = endpoints
+	b.mu.Unlock()
+	// As consultas são registradas com a lista bloqueada (acquire), então depois da troca nenhuma
+	// nova consulta utiliza as instâncias removidas
+	for _, removed := range current {
+		removed.removed.Store(true)
+		if removed.outstanding.Load() == 0 {
+			removed.close()
+		}
+	}
+	return nil
+}
+
+// Endereços das instâncias atuais
+func (b *BalancedClient) Addresses() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	addresses := make([]string, len(b.endpoints))
+	for i, e := range b.endpoints {
+		addresses[i] = e.address
+	}
+	return addresses
+}
+
+// Função que escolhe a instância e registra a consulta em andamento, retornando a quantidade de
+// consultas que já estavam em andamento. O registro é feito com a lista bloqueada, para que o
+// Update não encerre uma instância escolhida antes da troca.
+func (b *BalancedClient) acquire() (*endpoint, int64) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	e := b.pick(b.endpoints)
+	return e, e.outstanding.Add(1) - 1
+}
+
+// Função que escolhe a instância de acordo com a política. Quando todas as instâncias estão
+// fora do balanceamento, todas voltam a ser consideradas.
+func (b *BalancedClient) pick(all []*endpoint) *endpoint {
+	now := time.Now()
+	candidates := make([]*endpoint, 0, len(all))
+	for _, e := range all {
+		if e.healthy(now) {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = all
+	}
+
+	start := int((b.next.Add(1) - 1) % uint64(len(candidates)))
+	switch b.config.Policy {
+	case PolicyLeastOutstanding:
+		best := candidates[start]
+		for i := 1; i < len(candidates); i++ {
+			if e := candidates[(start+i)%len(candidates)]; e.outstanding.Load() < best.outstanding.Load() {
+				best = e
+			}
+		}
+		return best
+	case PolicyPowerOfTwo:
+		if len(candidates) == 1 {
+			return candidates[0]
+		}
+		i := rand.IntN(len(candidates))
+		j := rand.IntN(len(candidates) - 1)
+		if j >= i {
+			j++
+		}
+		if candidates[j].outstanding.Load() < candidates[i].outstanding.Load() {
+			return candidates[j]
+		}
+		return candidates[i]
+	}
+	return candidates[start]
+}
+
+// Função que busca a temperatura na instância escolhida. A consulta gera um span com a instância utilizada.
+func (b *BalancedClient) BuscaTemperatura(ctx context.Context, pais, cep string) (*ClimaCidade, error) {
+	e, outstanding := b.acquire()
+	ctx, span := b.config.Tracer.Start(ctx, "Instância service-b", trace.WithAttributes(
+		attribute.String(EndpointAttribute, e.address),
+		attribute.String("lb.policy", b.config.Policy),
+		attribute.Int64("lb.outstanding", outstanding),
+	))
+	defer span.End()
+
+	clima, err := e.client.BuscaTemperatura(ctx, pais, cep)
+	e.release()
+
+	// O cancelamento pelo chamador (como o hedge que perdeu) não indica falha da instância. O
+	// prazo esgotado conta como falha, pois é o resultado de uma instância travada.
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		if e.record(instanceFailure(err), b.config) {
+			log.Printf("Instância %s do service-b retirada do balanceamento por %s: %s", e.address, b.config.EjectionTime, err)
+			span.AddEvent("endpoint ejected")
+		}
+	}
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+	}
+	return clima, err
+}
+
+// Verifica se o erro indica falha da instância: erros de conexão, timeouts, respostas
+// inválidas e status 5xx. As respostas 422 e 404 são respostas válidas do service-b.
+func instanceFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	return ToProblem(err).Status >= http.StatusInternalServerError
+}
+
+// Encerra os clients das instâncias
+func (b *BalancedClient) Close() error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var errs []error
+	for _, e := range b.endpoints {
+		errs = append(errs, e.close())
+	}
+	return errors.Join(errs...)
+}
+
+// Função que resolve o nome DNS no formato host:porta em todos os endereços IP (registros A
+// e AAAA), no formato ip:porta.
+func LookupEndpoints(ctx context.Context, target string) ([]string, error) {
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, len(ips))
+	for i, ip := range ips {
+		addresses[i] = net.JoinHostPort(ip, port)
+	}
+	return addresses, nil
+}
+
+// Função que atualiza periodicamente as instâncias com os endereços retornados pelo resolve,
+// até o contexto ser cancelado. Em caso de erro, as instâncias atuais são mantidas.
+func (b *BalancedClient) Watch(ctx context.Context, resolve func(ctx context.Context) ([]string, error), interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			addresses, err := resolve(ctx)
+			if err == nil {
+				err = b.Update(addresses)
+			}
+			if err != nil {
+				log.Printf("Erro ao atualizar as instâncias do service-b: %s", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package serviceb
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Instâncias simuladas, pelo endereço. O client de cada instância contabiliza as consultas.
+// O endereço invalid não consegue criar o client.
+type instancias struct {
+	mu      sync.Mutex
+	clients map[string]*clientMock
+	closed  []string
+	invalid string
+}
+
+func (i *instancias) factory(address string) (TemperaturaClient, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if address == i.invalid {
+		return nil, errors.New("endereço inválido")
+	}
+	if i.clients == nil {
+		i.clients = map[string]*clientMock{}
+	}
+	if _, ok := i.clients[address]; !ok {
+		i.clients[address] = &clientMock{}
+	}
+	return &closerMock{clientMock: i.clients[address], close: func() {
+		i.mu.Lock()
+		defer i.mu.Unlock()
+		i.closed = append(i.closed, address)
+	}}, nil
+}
+
+func (i *instancias) closedList() []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return append([]string(nil), i.closed...)
+}
+
+func (i *instancias) calls(address string) int32 {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.clients[address].calls.Load()
+}
+
+type closerMock struct {
+	*clientMock
+	close func()
+}
+
+func (c *closerMock) Close() error {
+	c.close()
+	return nil
+}
+
+func TestBalancedClientRoundRobin(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	var inst instancias
+	client, err := NewBalancedClient(inst.factory, []string{"b1:8282", "b2:8282", "b3:8282"}, BalancerConfig{Tracer: tracer})
+	require.NoError(t, err)
+
+	for i := 0; i < 6; i++ {
+		_, err := client.BuscaTemperatura(context.Background(), "", "32450000")
+		require.NoError(t, err)
+	}
+	for _, address := range []string{"b1:8282", "b2:8282", "b3:8282"} {
+		assert.EqualValues(t, 2, inst.calls(address), address)
+	}
+
+	// Cada consulta gera um span com a instância utilizada
+	spans := recorder.Ended()
+	require.Len(t, spans, 6)
+	assert.Equal(t, "Instância service-b", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String(EndpointAttribute, "b1:8282"))
+	assert.Contains(t, spans[1].Attributes(), attribute.String(EndpointAttribute, "b2:8282"))
+	assert.Contains(t, spans[0].Attributes(), attribute.String("lb.policy", PolicyRoundRobin))
+}
+
+// As políticas least-outstanding e p2c evitam a instância com consultas em andamento
+func TestBalancedClientMenosConsultas(t *testing.T) {
+	for _, policy := range []string{PolicyLeastOutstanding, PolicyPowerOfTwo} {
+		t.Run(policy, func(t *testing.T) {
+			var inst instancias
+			client, err := NewBalancedClient(inst.factory, []string{"b1:8282", "b2:8282"}, BalancerConfig{Policy: policy})
+			require.NoError(t, err)
+
+			// Uma consulta em andamento ocupa a primeira instância, então as seguintes utilizam a outra
+			lenta := client.pick(client.endpoints)
+			lenta.outstanding.Add(1)
+			defer lenta.outstanding.Add(-1)
+			for i := 0; i < 10; i++ {
+				assert.NotEqual(t, lenta.address, client.pick(client.endpoints).address)
+			}
+		})
+	}
+}
+
+// Instâncias com falhas consecutivas saem do balanceamento e voltam depois do tempo de ejeção
+func TestBalancedClientHealthCheckPassivo(t *testing.T) {
+	var inst instancias
+	client, err := NewBalancedClient(inst.factory, []string{"b1:8282", "b2:8282"}, BalancerConfig{MaxFailures: 2, EjectionTime: 100 * time.Millisecond})
+	require.NoError(t, err)
+	inst.clients["b1:8282"].err = &StatusError{StatusCode: http.StatusServiceUnavailable}
+
+	for i := 0; i < 4; i++ {
+		client.BuscaTemperatura(context.Background(), "", "32450000")
+	}
+	assert.EqualValues(t, 2, inst.calls("b1:8282"))
+
+	// Com a b1 fora, todas as consultas utilizam a b2
+	for i := 0; i < 4; i++ {
+		_, err := client.BuscaTemperatura(context.Background(), "", "32450000")
+		assert.NoError(t, err)
+	}
+	assert.EqualValues(t, 2, inst.calls("b1:8282"))
+	assert.EqualValues(t, 6, inst.calls("b2:8282"))
+
+	// Depois da ejeção, a b1 volta e uma única falha a retira novamente
+	time.Sleep(150 * time.Millisecond)
+	for i := 0; i < 4; i++ {
+		client.BuscaTemperatura(context.Background(), "", "32450000")
+	}
+	assert.EqualValues(t, 3, inst.calls("b1:8282"))
+
+	// Respostas 404 e 422 não são falhas da instância
+	inst.clients["b2:8282"].err = &StatusError{StatusCode: http.StatusNotFound}
+	for i := 0; i < 4; i++ {
+		client.BuscaTemperatura(context.Background(), "", "00000000")
+	}
+	assert.EqualValues(t, 13, inst.calls("b2:8282"))
+	assert.True(t, client.endpoints[1].healthy(time.Now()))
+}
+
+// A instância travada, que esgota o prazo da consulta, sai do balanceamento. O cancelamento pelo
+// chamador (como o hedge que perdeu) não é falha da instância.
+func TestBalancedClientPrazoEsgotado(t *testing.T) {
+	var inst instancias
+	client, err := NewBalancedClient(inst.factory, []string{"b1:8282", "b2:8282"}, BalancerConfig{MaxFailures: 1, EjectionTime: time.Minute})
+	require.NoError(t, err)
+	inst.clients["b1:8282"].delay = time.Second
+	inst.clients["b2:8282"].delay = time.Second
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.BuscaTemperatura(ctx, "", "32450000")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, client.endpoints[0].healthy(time.Now()))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	_, err = client.BuscaTemperatura(ctx, "", "32450000")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, client.endpoints[1].healthy(time.Now()))
+}
+
+// O índice do round-robin continua válido quando o contador dá a volta
+func TestBalancedClientContadorMaximo(t *testing.T) {
+	var inst instancias
+	client, err := NewBalancedClient(inst.factory, []string{"b1:8282", "b2:8282", "b3:8282"}, BalancerConfig{})
+	require.NoError(t, err)
+	client.next.Store(math.MaxUint64 - 1)
+	for i := 0; i < 4; i++ {
+		_, err := client.BuscaTemperatura(context.Background(), "", "32450000")
+		assert.NoError(t, err)
+	}
+}
+
+// Com todas as instâncias fora do balanceamento, todas voltam a ser consideradas
+func TestBalancedClientTodasForaDoBalanceamento(t *testing.T) {
+	var inst instancias
+	client, err := NewBalancedClient(inst.factory, []string{"b1:8282"}, BalancerConfig{MaxFailures: 1})
+	require.NoError(t, err)
+	inst.clients["b1:8282"].err = errors.New("connection refused")
+
+	for i := 0; i < 3; i++ {
+		client.BuscaTemperatura(context.Background(), "", "32450000")
+	}
+	assert.EqualValues(t, 3, inst.calls("b1:8282"))
+}
+
+// A atualização preserva o estado das instâncias mantidas e encerra as removidas
+func TestBalancedClientUpdate(t *testing.T) {
+	var inst instancias
+	client, err := NewBalancedClient(inst.factory, []string{"b1:8282", "b2:8282"}, BalancerConfig{})
+	require.NoError(t, err)
+	b1 := client.endpoints[0]
+
+	require.NoError(t, client.Update([]string{"b1:8282", "b3:8282"}))
+	assert.Equal(t, []string{"b1:8282", "b3:8282"}, client.Addresses())
+	assert.Same(t, b1, client.endpoints[0])
+	assert.Equal(t, []string{"b2:8282"}, inst.closedList())
+
+	// Com erro na criação de um client, a lista é mantida e os clients criados são encerrados
+	inst.invalid = "b6:8282"
+	assert.Error(t, client.Update([]string{"b1:8282", "b5:8282", "b6:8282"}))
+	assert.Equal(t, []string{"b1:8282", "b3:8282"}, client.Addresses())
+	assert.Equal(t, []string{"b2:8282", "b5:8282"}, inst.closedList())
+
+	assert.ErrorIs(t, client.Update(nil), ErrInvalidBalancerConfig)
+	_, err = NewBalancedClient(inst.factory, []string{"b1:8282"}, BalancerConfig{Policy: "random"})
+	assert.ErrorIs(t, err, ErrInvalidBalancerConfig)
+
+	// Atualização periódica com o resolve
+	var resolves atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.Watch(ctx, func(ctx context.Context) ([]string, error) {
+		resolves.Add(1)
+		return []string{"b4:8282"}, nil
+	}, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return resolves.Load() > 0 && len(client.Addresses()) == 1 && client.Addresses()[0] == "b4:8282"
+	}, time.Second, 10*time.Millisecond)
+}
+
+// A instância removida só é encerrada depois que as consultas em andamento terminam
+func TestBalancedClientUpdateConsultaEmAndamento(t *testing.T) {
+	var inst instancias
+	client, err := NewBalancedClient(inst.factory, []string{"b1:8282"}, BalancerConfig{})
+	require.NoError(t, err)
+	inst.clients["b1:8282"].delay = 100 * time.Millisecond
+
+	done := make(chan error)
+	go func() {
+		_, err := client.BuscaTemperatura(context.Background(), "BR", "32450000")
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return client.endpoints[0].outstanding.Load() == 1 }, time.Second, time.Millisecond)
+
+	require.NoError(t, client.Update([]string{"b2:8282"}))
+	assert.Empty(t, inst.closedList())
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"b1:8282"}, inst.closedList())
+
+	require.NoError(t, client.Close())
+	require.NoError(t, client.Close())
+	assert.Equal(t, []string{"b1:8282", "b2:8282"}, inst.closedList())
+}
+
+// As atualizações concorrentes não criam clients duplicados nem deixam clients abertos
+func TestBalancedClientUpdateConcorrente(t *testing.T) {
+	var inst instancias
+	client, err := NewBalancedClient(inst.factory, []string{"b1:8282"}, BalancerConfig{})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, client.Update([]string{"b1:8282", "b2:8282"}))
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := client.BuscaTemperatura(context.Background(), "BR", "32450000")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"b1:8282", "b2:8282"}, client.Addresses())
+	assert.Empty(t, inst.closedList())
+}
+
+// Balanceamento entre instâncias HTTP reais
+func TestBalancedClientHTTP(t *testing.T) {
+	var contagem [2]atomic.Int32
+	var urls []string
+	for i := range contagem {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contagem[i].Add(1)
+			w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+		}))
+		defer server.Close()
+		urls = append(urls, server.URL)
+	}
+
+	client, err := NewBalancedClient(func(address string) (TemperaturaClient, error) {
+		return NewClient(address, nil), nil
+	}, urls, BalancerConfig{Policy: PolicyPowerOfTwo})
+	require.NoError(t, err)
+	for i := 0; i < 20; i++ {
+		_, err := client.BuscaTemperatura(context.Background(), "", "32450000")
+		require.NoError(t, err)
+	}
+	assert.Greater(t, contagem[0].Load(), int32(0))
+	assert.Greater(t, contagem[1].Load(), int32(0))
+}
+
+func TestLookupEndpoints(t *testing.T) {
+	addresses, err := LookupEndpoints(context.Background(), "localhost:8282")
+	require.NoError(t, err)
+	assert.NotEmpty(t, addresses)
+	for _, address := range addresses {
+		assert.Regexp(t, `:8282$`, address)
+	}
+
+	_, err = LookupEndpoints(context.Background(), "localhost")
+	assert.Error(t, err)
+}
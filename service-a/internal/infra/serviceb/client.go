@@ -0,0 +1,211 @@
+package serviceb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/deadline"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Erros que representam as respostas conhecidas do service-b
+var (
+	ErrInvalidZipcode  = errors.New("invalid zipcode")
+	ErrZipcodeNotFound = errors.New("can not find zipcode")
+)
+
+// Interface comum aos clients HTTP e gRPC do service-b. O país é o código ISO 3166-1 alpha-2 do
+// código postal (BR, PT ou AR); vazio é BR.
+type TemperaturaClient interface {
+	BuscaTemperatura(ctx context.Context, pais, cep string) (*ClimaCidade, error)
+}
+
+// Client que limita o tempo de cada consulta ao service-b. Quando o prazo do contexto é menor,
+// ele é mantido.
+type timeoutClient struct {
+	client  TemperaturaClient
+	timeout time.Duration
+}
+
+// Função que limita o tempo das consultas do client informado
+func WithTimeout(client TemperaturaClient, timeout time.Duration) TemperaturaClient {
+	return &timeoutClient{client: client, timeout: timeout}
+}
+
+func (c *timeoutClient) BuscaTemperatura(ctx context.Context, pais, cep string) (*ClimaCidade, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.BuscaTemperatura(ctx, pais, cep)
+}
+
+// Struct com a resposta de sucesso do service-b, definida no contrato comum aos serviços
+type ClimaCidade = domain.ClimaCidade
+
+// Struct com o endereço do CEP consultado
+type Endereco = domain.Endereco
+
+// Erro retornado quando o service-b responde com um status diferente de 200.
+// O Problem contém o corpo de erro enviado pelo service-b, quando existir.
+type StatusError struct {
+	StatusCode int
+	Problem    *problem.Details
+}
+
+func (e *StatusError) Error() string {
+	if e.Problem != nil && e.Problem.Message != "" {
+		return fmt.Sprintf("service-b respondeu com o status %d: %s", e.StatusCode, e.Problem.Message)
+	}
+	return fmt.Sprintf("service-b respondeu com o status %d", e.StatusCode)
+}
+
+// Permite utilizar errors.Is com os erros conhecidos do service-b
+func (e *StatusError) Is(target error) bool {
+	switch target {
+	case ErrInvalidZipcode:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case ErrZipcodeNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// Erro retornado quando o service-b responde 200, mas o corpo não pode ser interpretado
+type InvalidResponseError struct {
+	Err error
+}
+
+func (e *InvalidResponseError) Error() string {
+	return "resposta inválida do service-b: " + e.Err.Error()
+}
+
+func (e *InvalidResponseError) Unwrap() error {
+	return e.Err
+}
+
+// Struct do client HTTP do service-b
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// Função que cria um novo client do service-b. Caso o httpClient seja nil, será utilizado o http.DefaultClient.
+func NewClient(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+	}
+}
+
+// Função que busca a temperatura do CEP no service-b. O contexto de trace, o prazo restante e o
+// idioma da requisição são propagados nos headers e o país, quando informado, no parâmetro country.
+func (c *Client) BuscaTemperatura(ctx context.Context, pais, cep string) (*ClimaCidade, error) {
+	u := c.baseURL + url.PathEscape(cep)
+	if pais != "" {
+		u += "?country=" + url.QueryEscape(pais)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	// O contrato entre os serviços é a v1, sem depender da versão padrão da rota sem versão
+	req.Header.Set(apiversion.Header, apiversion.V1)
+
+	// Injetando o header do request id. Necessário para realizar o tracker
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	deadline.Inject(ctx, req.Header)
+	i18n.Inject(ctx, req.Header)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp.StatusCode, body)
+	}
+
+	var clima ClimaCidade
+	if err := json.Unmarshal(body, &clima); err != nil {
+		return nil, &InvalidResponseError{Err: err}
+	}
+	if clima.Cidade == "" {
+		return nil, &InvalidResponseError{Err: errors.New("campo city ausente")}
+	}
+	return &clima, nil
+}
+
+// Função que cria o StatusError a partir do corpo de erro do service-b. São aceitos tanto
+// o formato application/problem+json quanto o formato legado { "message": "..." }.
+func newStatusError(statusCode int, body []byte) *StatusError {
+	statusErr := &StatusError{StatusCode: statusCode}
+	var details problem.Details
+	if err := json.Unmarshal(body, &details); err == nil && (details.Message != "" || details.Title != "") {
+		if details.Message == "" {
+			details.Message = details.Title
+		}
+		statusErr.Problem = &details
+	}
+	return statusErr
+}
+
+// Função que converte o erro retornado pelo client no problema que o service-a deve responder.
+// 422 e 404 são repassados, 502, 503 e 504 são mantidos e qualquer outro status vira 502.
+// O trace_id e o detalhe enviados pelo service-b são preservados.
+func ToProblem(err error) *problem.Details {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		var p *problem.Details
+		switch statusErr.StatusCode {
+		case http.StatusUnprocessableEntity:
+			p = problem.InvalidZipcode("")
+		case http.StatusNotFound:
+			p = problem.ZipcodeNotFound("")
+		case http.StatusBadGateway:
+			p = problem.BadGateway("")
+		case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+			p = problem.ServiceUnavailable("")
+		case http.StatusGatewayTimeout:
+			p = problem.GatewayTimeout("")
+		default:
+			p = problem.BadGateway("").Detailf("%s responded with status %d", "service-b", statusErr.StatusCode)
+		}
+		if downstream := statusErr.Problem; downstream != nil {
+			if p.Detail == "" {
+				p.Detail = downstream.Detail
+			}
+			p.TraceID = downstream.TraceID
+		}
+		return p
+	}
+
+	var invalidErr *InvalidResponseError
+	if errors.As(err, &invalidErr) {
+		return problem.BadGateway("").Detailf("%s returned an invalid response", "service-b")
+	}
+	return problem.FromUpstreamError("service-b", err)
+}
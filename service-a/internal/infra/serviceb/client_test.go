@@ -0,0 +1,128 @@
+package serviceb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/deadline"
+)
+
+// Testes de contrato: cada resposta do service-b deve ser convertida no erro tipado e no status corretos
+func TestClientContrato(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	testes := []struct {
+		nome       string
+		status     int
+		body       string
+		erro       error
+		statusA    int
+		comTraceID bool
+	}{
+		{"422 problem+json", 422, `{"type": "x", "title": "invalid zipcode", "status": 422, "trace_id": "` + traceID + `", "message": "invalid zipcode"}`, ErrInvalidZipcode, 422, true},
+		{"404 problem+json", 404, `{"type": "x", "title": "can not find zipcode", "status": 404, "trace_id": "` + traceID + `", "message": "can not find zipcode"}`, ErrZipcodeNotFound, 404, true},
+		{"404 formato legado", 404, `{"message": "can not find zipcode"}`, ErrZipcodeNotFound, 404, false},
+		{"400", 400, ``, nil, 502, false},
+		{"500 sem body", 500, ``, nil, 502, false},
+		{"502", 502, `{"title": "bad gateway", "status": 502, "trace_id": "` + traceID + `"}`, nil, 502, true},
+		{"503", 503, `{"title": "service unavailable", "status": 503, "trace_id": "` + traceID + `"}`, nil, 503, true},
+		{"504", 504, `{"title": "gateway timeout", "status": 504, "trace_id": "` + traceID + `"}`, nil, 504, true},
+	}
+
+	for _, tt := range testes {
+		t.Run(tt.nome, func(t *testing.T) {
+			serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/32450000", r.URL.Path)
+				assert.Equal(t, http.MethodGet, r.Method)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer serverMock.Close()
+
+			clima, err := NewClient(serverMock.URL, nil).BuscaTemperatura(context.Background(), "", "32450000")
+			assert.Nil(t, clima)
+
+			var statusErr *StatusError
+			assert.True(t, errors.As(err, &statusErr))
+			assert.Equal(t, tt.status, statusErr.StatusCode)
+			if tt.erro != nil {
+				assert.ErrorIs(t, err, tt.erro)
+			}
+
+			details := ToProblem(err)
+			assert.Equal(t, tt.statusA, details.Status)
+			if tt.comTraceID {
+				assert.Equal(t, traceID, details.TraceID)
+			}
+		})
+	}
+}
+
+// Resposta 200 com body inválido deve virar 502
+func TestClientRespostaInvalida(t *testing.T) {
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{ "city: "cidade" }`))
+	}))
+	defer serverMock.Close()
+
+	_, err := NewClient(serverMock.URL, nil).BuscaTemperatura(context.Background(), "", "32450000")
+
+	var invalidErr *InvalidResponseError
+	assert.True(t, errors.As(err, &invalidErr))
+	assert.Equal(t, http.StatusBadGateway, ToProblem(err).Status)
+}
+
+// Falhas de conexão devem virar 503 e timeouts devem virar 504
+func TestClientFalhaTransporte(t *testing.T) {
+	indisponivel := httptest.NewServer(http.NotFoundHandler())
+	indisponivel.Close()
+
+	_, err := NewClient(indisponivel.URL, nil).BuscaTemperatura(context.Background(), "", "32450000")
+	assert.Equal(t, http.StatusServiceUnavailable, ToProblem(err).Status)
+
+	lento := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer lento.Close()
+
+	_, err = NewClient(lento.URL, &http.Client{Timeout: 50 * time.Millisecond}).BuscaTemperatura(context.Background(), "", "32450000")
+	assert.Equal(t, http.StatusGatewayTimeout, ToProblem(err).Status)
+}
+
+// O prazo restante do contexto é enviado ao service-b e o WithTimeout limita as consultas sem prazo
+func TestClientPrazo(t *testing.T) {
+	headers := make(chan string, 3)
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Get(deadline.Header)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+		w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+	}))
+	defer serverMock.Close()
+	client := NewClient(serverMock.URL, nil)
+
+	_, err := client.BuscaTemperatura(context.Background(), "", "32450000")
+	assert.NoError(t, err)
+	assert.Empty(t, <-headers)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = client.BuscaTemperatura(ctx, "", "32450000")
+	assert.NoError(t, err)
+	ms, err := strconv.Atoi(<-headers)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1000, ms, 100)
+
+	_, err = WithTimeout(client, 50*time.Millisecond).BuscaTemperatura(context.Background(), "", "32450000")
+	assert.Equal(t, http.StatusGatewayTimeout, ToProblem(err).Status)
+	ms, _ = strconv.Atoi(<-headers)
+	assert.LessOrEqual(t, ms, 50)
+}
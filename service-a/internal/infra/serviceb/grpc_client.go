@@ -0,0 +1,112 @@
+package serviceb
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/pb"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// Struct do client gRPC do service-b
+type GRPCClient struct {
+	conn   *grpc.ClientConn
+	client pb.TemperatureServiceClient
+}
+
+// Função que cria o client gRPC do service-b. A instrumentação do otelgrpc é adicionada
+// automaticamente, e as opções informadas são acrescentadas às opções padrão.
+func NewGRPCClient(target string, opts ...grpc.DialOption) (*GRPCClient, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}, opts...)
+
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCClient{
+		conn:   conn,
+		client: pb.NewTemperatureServiceClient(conn),
+	}, nil
+}
+
+// Função que busca a temperatura do CEP no service-b via gRPC. O idioma da requisição é enviado
+// no metadata e os erros são convertidos no mesmo StatusError retornado pelo client HTTP.
+func (c *GRPCClient) BuscaTemperatura(ctx context.Context, pais, cep string) (*ClimaCidade, error) {
+	ctx = i18n.OutgoingContext(ctx)
+	resp, err := c.client.GetByCEP(ctx, &pb.GetByCEPRequest{Cep: cep, Country: pais})
+	if err != nil {
+		return nil, statusErrorFromGRPC(err)
+	}
+	clima := &ClimaCidade{
+		Cidade:   resp.GetCity(),
+		TempC:    resp.GetTempC(),
+		TempF:    resp.GetTempF(),
+		TempK:    resp.GetTempK(),
+		Condicao: resp.GetCondition(),
+	}
+	if address := resp.GetAddress(); address != nil {
+		clima.Endereco = &Endereco{
+			Cep:        address.GetCep(),
+			Logradouro: address.GetStreet(),
+			Bairro:     address.GetNeighborhood(),
+			Cidade:     address.GetCity(),
+			Uf:         address.GetState(),
+			Pais:       address.GetCountry(),
+		}
+	}
+	return clima, nil
+}
+
+// Encerra a conexão com o service-b
+func (c *GRPCClient) Close() error {
+	return c.conn.Close()
+}
+
+// Função que converte o status gRPC no StatusError com o status HTTP equivalente.
+// O título, o detalhe e o trace_id são lidos do ErrorInfo enviado pelo service-b.
+func statusErrorFromGRPC(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	statusErr := &StatusError{StatusCode: httpStatus(st.Code())}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			statusErr.Problem = &problem.Details{
+				Type:    info.GetReason(),
+				Title:   info.GetMetadata()["title"],
+				Status:  statusErr.StatusCode,
+				Detail:  info.GetMetadata()["detail"],
+				TraceID: info.GetMetadata()["trace_id"],
+				Message: st.Message(),
+			}
+		}
+	}
+	return statusErr
+}
+
+// Mapeamento entre os códigos gRPC e os status HTTP
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument:
+		return http.StatusUnprocessableEntity
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusBadGateway
+}
@@ -0,0 +1,141 @@
+package serviceb
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/pb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const traceIDMock = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+// Service-b simulado via gRPC. O traceparent recebido fica registrado para validar a propagação.
+type serviceBGRPCMock struct {
+	pb.UnimplementedTemperatureServiceServer
+	traceparent string
+	idioma      string
+}
+
+func (s *serviceBGRPCMock) GetByCEP(ctx context.Context, in *pb.GetByCEPRequest) (*pb.ClimaCidade, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("traceparent")) > 0 {
+		s.traceparent = md.Get("traceparent")[0]
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(i18n.MetadataKey)) > 0 {
+		s.idioma = md.Get(i18n.MetadataKey)[0]
+	}
+
+	var code codes.Code
+	switch in.GetCep() {
+	case "32450000":
+		return &pb.ClimaCidade{City: "Ibirité", TempC: 28.5, TempF: 83.3, TempK: 301.5}, nil
+	case "1000001":
+		return &pb.ClimaCidade{City: "Lisboa", Address: &pb.Endereco{Cep: "1000-001", City: "Lisboa", Country: in.GetCountry()}}, nil
+	case "324500000":
+		code = codes.InvalidArgument
+	case "00000000":
+		code = codes.NotFound
+	case "11111111":
+		code = codes.Unavailable
+	case "22222222":
+		code = codes.DeadlineExceeded
+	default:
+		code = codes.Internal
+	}
+	st, _ := status.New(code, "erro").WithDetails(&errdetails.ErrorInfo{
+		Reason:   "x",
+		Domain:   "service-b",
+		Metadata: map[string]string{"trace_id": traceIDMock, "detail": "detalhe"},
+	})
+	return nil, st.Err()
+}
+
+// Sobe o service-b simulado em memória e retorna o client gRPC conectado a ele
+func newGRPCClient(t *testing.T) (*GRPCClient, *serviceBGRPCMock) {
+	listener := bufconn.Listen(1024 * 1024)
+	mock := &serviceBGRPCMock{}
+	server := grpc.NewServer()
+	pb.RegisterTemperatureServiceServer(server, mock)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	client, err := NewGRPCClient("passthrough:///bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+	assert.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client, mock
+}
+
+func TestGRPCClient(t *testing.T) {
+	client, mock := newGRPCClient(t)
+
+	clima, err := client.BuscaTemperatura(context.Background(), "", "32450000")
+	assert.NoError(t, err)
+	assert.Equal(t, &ClimaCidade{Cidade: "Ibirité", TempC: 28.5, TempF: 83.3, TempK: 301.5}, clima)
+
+	// O país é enviado no request e retornado no endereço
+	clima, err = client.BuscaTemperatura(context.Background(), "PT", "1000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "PT", clima.Endereco.Pais)
+	assert.Empty(t, mock.idioma, "sem idioma no contexto, o metadata não é enviado")
+
+	// O idioma do contexto é enviado no metadata
+	_, err = client.BuscaTemperatura(i18n.WithLanguage(context.Background(), i18n.Spanish), "", "32450000")
+	assert.NoError(t, err)
+	assert.Equal(t, i18n.Spanish, mock.idioma)
+}
+
+// Os códigos gRPC devem gerar os mesmos status que o client HTTP
+func TestGRPCClientContrato(t *testing.T) {
+	client, _ := newGRPCClient(t)
+
+	testes := []struct {
+		cep     string
+		statusA int
+	}{
+		{"324500000", http.StatusUnprocessableEntity},
+		{"00000000", http.StatusNotFound},
+		{"11111111", http.StatusServiceUnavailable},
+		{"22222222", http.StatusGatewayTimeout},
+		{"33333333", http.StatusBadGateway},
+	}
+
+	for _, tt := range testes {
+		t.Run(tt.cep, func(t *testing.T) {
+			_, err := client.BuscaTemperatura(context.Background(), "", tt.cep)
+			details := ToProblem(err)
+			assert.Equal(t, tt.statusA, details.Status)
+			assert.Equal(t, traceIDMock, details.TraceID)
+			assert.Equal(t, "detalhe", details.Detail)
+		})
+	}
+	_, err := client.BuscaTemperatura(context.Background(), "", "00000000")
+	assert.ErrorIs(t, err, ErrZipcodeNotFound)
+}
+
+// O contexto de trace deve ser propagado para o service-b
+func TestGRPCClientPropagaTrace(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	client, mock := newGRPCClient(t)
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer("teste").Start(context.Background(), "Consulta service-b")
+	defer span.End()
+
+	_, err := client.BuscaTemperatura(ctx, "", "32450000")
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(mock.traceparent, span.SpanContext().TraceID().String()))
+}
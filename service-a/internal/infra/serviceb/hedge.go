@@ -0,0 +1,180 @@
+package serviceb
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Valores padrão do hedging
+const (
+	DefaultHedgePercentile = 0.95
+	DefaultHedgeMinDelay   = 100 * time.Millisecond
+	DefaultHedgeWindow     = 200
+)
+
+// Quantidade mínima de latências observadas para utilizar o percentil
+const hedgeMinSamples = 10
+
+// Consultas em que o hedge foi enviado, pela tentativa que respondeu primeiro. Publicadas no /metrics.
+var hedgedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "serviceb_hedged_requests_total",
+	Help: "Quantidade de consultas ao service-b em que o hedge foi enviado, pela tentativa vencedora.",
+}, []string{"winner"})
+
+// Configuração do hedging
+type HedgeConfig struct {
+	// Percentil da latência das consultas utilizado como atraso do hedge (0.95 = p95)
+	Percentile float64
+	// Atraso mínimo do hedge, utilizado também enquanto não há latências suficientes
+	MinDelay time.Duration
+	// Quantidade de latências mais recentes utilizadas no cálculo do percentil
+	Window int
+	Tracer trace.Tracer
+}
+
+// Client que envia uma segunda consulta (hedge) a outra instância do service-b quando a primeira
+// demora mais que o percentil configurado da latência observada. A primeira resposta é utilizada
+// e a outra consulta é cancelada. Cada tentativa gera o seu próprio span, irmão das demais.
+type HedgedClient struct {
+	primary TemperaturaClient
+	hedge   TemperaturaClient
+	config  HedgeConfig
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+}
+
+// Função que cria o client com hedging. As consultas são enviadas primeiro ao primary e o hedge
+// é enviado ao client hedge, que deve apontar para outra instância (ou para o balanceador).
+func NewHedgedClient(primary, hedge TemperaturaClient, config HedgeConfig) *HedgedClient {
+	if config.Percentile <= 0 || config.Percentile > 1 {
+		config.Percentile = DefaultHedgePercentile
+	}
+	if config.MinDelay <= 0 {
+		config.MinDelay = DefaultHedgeMinDelay
+	}
+	if config.Window <= 0 {
+		config.Window = DefaultHedgeWindow
+	}
+	if config.Tracer == nil {
+		config.Tracer = otel.Tracer("serviceb")
+	}
+	return &HedgedClient{primary: primary, hedge: hedge, config: config}
+}
+
+// Resultado de uma tentativa
+type attempt struct {
+	number int
+	clima  *ClimaCidade
+	err    error
+}
+
+// Função que busca a temperatura, enviando o hedge após o atraso calculado. As respostas
+// definitivas do service-b (CEP inválido ou inexistente) também encerram a consulta.
+func (c *HedgedClient) BuscaTemperatura(ctx context.Context, pais, cep string) (*ClimaCidade, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan attempt, 2)
+	c.start(ctx, c.primary, pais, cep, 1, results)
+	timer := time.NewTimer(c.Delay())
+	defer timer.Stop()
+
+	pending, hedged := 1, false
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			hedged = true
+			pending++
+			c.start(ctx, c.hedge, pais, cep, 2, results)
+		case result := <-results:
+			pending--
+			if result.err == nil || definitive(result.err) {
+				if hedged {
+					winner := "primary"
+					if result.number == 2 {
+						winner = "hedge"
+					}
+					hedgedRequests.WithLabelValues(winner).Inc()
+				}
+				return result.clima, result.err
+			}
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			// Sem outra tentativa em andamento, a falha é retornada sem aguardar o hedge
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
+
+// Inicia uma tentativa com o seu próprio span, filho do contexto da consulta
+func (c *HedgedClient) start(ctx context.Context, client TemperaturaClient, pais, cep string, number int, results chan<- attempt) {
+	ctx, span := c.config.Tracer.Start(ctx, "Consulta service-b", trace.WithAttributes(
+		attribute.Int("hedge.attempt", number),
+		attribute.Bool("hedge.hedged", number > 1),
+	))
+	go func() {
+		defer span.End()
+		start := time.Now()
+		clima, err := client.BuscaTemperatura(ctx, pais, cep)
+		switch {
+		case err == nil:
+			c.observe(time.Since(start))
+		case errors.Is(ctx.Err(), context.Canceled):
+			// A outra tentativa respondeu primeiro
+			span.SetAttributes(attribute.Bool("hedge.cancelled", true))
+		default:
+			span.SetStatus(codes.Error, err.Error())
+			span.RecordError(err)
+		}
+		results <- attempt{number: number, clima: clima, err: err}
+	}()
+}
+
+// Registra a latência de uma consulta com sucesso
+func (c *HedgedClient) observe(latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.latencies) < c.config.Window {
+		c.latencies = append(c.latencies, latency)
+		return
+	}
+	c.latencies[c.next] = latency
+	c.next = (c.next + 1) % c.config.Window
+}
+
+// Atraso atual do hedge: o percentil configurado das latências observadas, respeitando o atraso mínimo
+func (c *HedgedClient) Delay() time.Duration {
+	c.mu.Lock()
+	if len(c.latencies) < hedgeMinSamples {
+		c.mu.Unlock()
+		return c.config.MinDelay
+	}
+	sorted := slices.Clone(c.latencies)
+	c.mu.Unlock()
+
+	slices.Sort(sorted)
+	index := int(math.Ceil(c.config.Percentile*float64(len(sorted)))) - 1
+	return max(sorted[max(index, 0)], c.config.MinDelay)
+}
+
+// Verifica se o erro é uma resposta definitiva do service-b, que não muda com o hedge
+func definitive(err error) bool {
+	return errors.Is(err, ErrInvalidZipcode) || errors.Is(err, ErrZipcodeNotFound)
+}
@@ -0,0 +1,131 @@
+package serviceb
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Client simulado que responde após o atraso informado, ou no cancelamento do contexto
+type clientMock struct {
+	delay time.Duration
+	err   error
+	calls atomic.Int32
+}
+
+func (c *clientMock) BuscaTemperatura(ctx context.Context, pais, cep string) (*ClimaCidade, error) {
+	c.calls.Add(1)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(c.delay):
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &ClimaCidade{Cidade: "Ibirité", TempC: 28.5}, nil
+}
+
+// Com o primary lento, o hedge responde primeiro e o primary é cancelado. As duas tentativas
+// geram spans irmãos.
+func TestHedgedClientHedgeVence(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	primary := &clientMock{delay: time.Second}
+	hedge := &clientMock{delay: 10 * time.Millisecond}
+	client := NewHedgedClient(primary, hedge, HedgeConfig{MinDelay: 50 * time.Millisecond, Tracer: tracer})
+
+	ctx, parent := tracer.Start(context.Background(), "Busca Temperatura")
+	start := time.Now()
+	clima, err := client.BuscaTemperatura(ctx, "", "32450000")
+	parent.End()
+	require.NoError(t, err)
+	assert.Equal(t, "Ibirité", clima.Cidade)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.EqualValues(t, 1, hedge.calls.Load())
+
+	assert.Eventually(t, func() bool { return len(recorder.Ended()) == 3 }, time.Second, 10*time.Millisecond)
+	attempts := map[int64]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		if span.Name() != "Consulta service-b" {
+			continue
+		}
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		for _, attr := range span.Attributes() {
+			if attr.Key == "hedge.attempt" {
+				attempts[attr.Value.AsInt64()] = span
+			}
+		}
+	}
+	require.Len(t, attempts, 2)
+	assert.Contains(t, attempts[1].Attributes(), attribute.Bool("hedge.cancelled", true))
+	assert.Contains(t, attempts[2].Attributes(), attribute.Bool("hedge.hedged", true))
+}
+
+// Com o primary rápido, o hedge não é enviado
+func TestHedgedClientSemHedge(t *testing.T) {
+	primary := &clientMock{delay: time.Millisecond}
+	hedge := &clientMock{}
+	client := NewHedgedClient(primary, hedge, HedgeConfig{MinDelay: 100 * time.Millisecond})
+
+	_, err := client.BuscaTemperatura(context.Background(), "", "32450000")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, hedge.calls.Load())
+}
+
+// Respostas definitivas encerram a consulta e falhas sem outra tentativa em andamento são retornadas
+func TestHedgedClientErros(t *testing.T) {
+	notFound := &StatusError{StatusCode: 404}
+	client := NewHedgedClient(&clientMock{err: notFound}, &clientMock{delay: time.Second}, HedgeConfig{MinDelay: time.Millisecond})
+	start := time.Now()
+	_, err := client.BuscaTemperatura(context.Background(), "", "00000000")
+	assert.ErrorIs(t, err, ErrZipcodeNotFound)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	// O primary falha depois do envio do hedge, que responde com sucesso
+	unavailable := &StatusError{StatusCode: 503}
+	client = NewHedgedClient(&clientMock{delay: 30 * time.Millisecond, err: unavailable}, &clientMock{delay: 50 * time.Millisecond}, HedgeConfig{MinDelay: 10 * time.Millisecond})
+	clima, err := client.BuscaTemperatura(context.Background(), "", "32450000")
+	assert.NoError(t, err)
+	assert.NotNil(t, clima)
+
+	// As duas tentativas falham
+	client = NewHedgedClient(&clientMock{delay: 30 * time.Millisecond, err: unavailable}, &clientMock{delay: 10 * time.Millisecond, err: errors.New("falha")}, HedgeConfig{MinDelay: 10 * time.Millisecond})
+	_, err = client.BuscaTemperatura(context.Background(), "", "32450000")
+	assert.Error(t, err)
+
+	// O prazo da consulta encerra as duas tentativas
+	client = NewHedgedClient(&clientMock{delay: time.Second}, &clientMock{delay: time.Second}, HedgeConfig{MinDelay: 10 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.BuscaTemperatura(ctx, "", "32450000")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// O atraso do hedge é o percentil das latências observadas, respeitando o atraso mínimo
+func TestHedgedClientDelay(t *testing.T) {
+	client := NewHedgedClient(nil, nil, HedgeConfig{Percentile: 0.95, MinDelay: time.Millisecond, Window: 100})
+	assert.Equal(t, time.Millisecond, client.Delay(), "sem latências suficientes")
+
+	for i := 1; i <= 100; i++ {
+		client.observe(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 95*time.Millisecond, client.Delay())
+
+	// A janela mantém somente as latências mais recentes
+	for i := 0; i < 100; i++ {
+		client.observe(10 * time.Millisecond)
+	}
+	assert.Equal(t, 10*time.Millisecond, client.Delay())
+
+	client.config.MinDelay = 50 * time.Millisecond
+	assert.Equal(t, 50*time.Millisecond, client.Delay())
+}
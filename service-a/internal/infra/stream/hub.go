@@ -0,0 +1,206 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Erro retornado pelo Subscribe depois que o hub foi encerrado
+var ErrClosed = errors.New("stream hub closed")
+
+// Struct de uma assinatura. O canal Updates recebe a temperatura sempre que ela mudar
+// e é fechado quando a assinatura é cancelada ou o hub é encerrado.
+type Subscription struct {
+	Updates <-chan *serviceb.ClimaCidade
+	updates chan *serviceb.ClimaCidade
+	poller  *poller
+}
+
+// Struct do poller de uma cidade. Um único poller consulta o service-b para todos os assinantes da cidade.
+type poller struct {
+	key         string
+	pais        string
+	cep         string
+	last        *serviceb.ClimaCidade
+	subscribers map[*Subscription]struct{}
+	stop        chan struct{}
+}
+
+// Struct que gerencia os pollers e as assinaturas
+type Hub struct {
+	client   serviceb.TemperaturaClient
+	tracer   trace.Tracer
+	interval time.Duration
+	mu       sync.Mutex
+	pollers  map[string]*poller
+	closed   bool
+	wg       sync.WaitGroup
+	// Contexto das consultas dos pollers, cancelado no Close
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Função que cria um novo hub, que consulta o service-b a cada interval
+func NewHub(client serviceb.TemperaturaClient, tracer trace.Tracer, interval time.Duration) *Hub {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Hub{
+		client:   client,
+		tracer:   tracer,
+		interval: interval,
+		pollers:  map[string]*poller{},
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Função que cria uma assinatura para a cidade do código postal do país informado (vazio é o Brasil).
+// A temperatura atual é consultada no service-b e enviada imediatamente; as próximas são enviadas
+// pelo poller da cidade quando houver mudança.
+func (h *Hub) Subscribe(ctx context.Context, pais, cep string) (*Subscription, error) {
+	clima, err := h.client.BuscaTemperatura(ctx, pais, cep)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, ErrClosed
+	}
+
+	key := cityKey(pais, clima)
+	p, ok := h.pollers[key]
+	if !ok {
+		p = &poller{
+			key:         key,
+			pais:        pais,
+			cep:         cep,
+			last:        clima,
+			subscribers: map[*Subscription]struct{}{},
+			stop:        make(chan struct{}),
+		}
+		h.pollers[key] = p
+		h.wg.Add(1)
+		go h.run(p)
+	}
+
+	updates := make(chan *serviceb.ClimaCidade, 1)
+	sub := &Subscription{Updates: updates, updates: updates, poller: p}
+	p.subscribers[sub] = struct{}{}
+	updates <- p.last
+	return sub, nil
+}
+
+// Função que cancela a assinatura. O poller da cidade é encerrado junto com o último assinante.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	p := sub.poller
+	if _, ok := p.subscribers[sub]; !ok {
+		return
+	}
+	delete(p.subscribers, sub)
+	close(sub.updates)
+	if len(p.subscribers) == 0 {
+		delete(h.pollers, p.key)
+		close(p.stop)
+	}
+}
+
+// Função que encerra todos os pollers e fecha as assinaturas. Utilizada no graceful shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	for key, p := range h.pollers {
+		for sub := range p.subscribers {
+			close(sub.updates)
+		}
+		p.subscribers = nil
+		delete(h.pollers, key)
+		close(p.stop)
+	}
+	h.mu.Unlock()
+	h.cancel()
+	h.wg.Wait()
+}
+
+// Quantidade de pollers ativos
+func (h *Hub) Pollers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.pollers)
+}
+
+// Função executada pelo poller até o último assinante sair
+func (h *Hub) run(p *poller) {
+	defer h.wg.Done()
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			h.poll(p)
+		}
+	}
+}
+
+// Função que consulta a temperatura e notifica os assinantes caso ela tenha mudado
+func (h *Hub) poll(p *poller) {
+	ctx, cancel := context.WithTimeout(h.ctx, h.interval)
+	defer cancel()
+	ctx, span := h.tracer.Start(ctx, "Stream Consulta service-b")
+	defer span.End()
+	span.SetAttributes(attribute.String("cep", p.cep), attribute.String("country", p.pais), attribute.String("city", p.key))
+
+	clima, err := h.client.BuscaTemperatura(ctx, p.pais, p.cep)
+	if err != nil {
+		// Mantém a última temperatura e tenta novamente no próximo ciclo
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		log.Printf("stream: falha ao consultar o cep %s: %v", p.cep, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !changed(p.last, clima) {
+		return
+	}
+	p.last = clima
+	for sub := range p.subscribers {
+		// O assinante lento recebe apenas a temperatura mais recente
+		select {
+		case <-sub.updates:
+		default:
+		}
+		sub.updates <- clima
+	}
+	span.SetAttributes(attribute.Int("subscribers", len(p.subscribers)))
+}
+
+// Chave que identifica a cidade do país. Sem o endereço, é utilizado apenas o nome da cidade.
+func cityKey(pais string, clima *serviceb.ClimaCidade) string {
+	key := strings.ToLower(pais + "/" + clima.Cidade)
+	if clima.Endereco != nil && clima.Endereco.Uf != "" {
+		key += "/" + strings.ToLower(clima.Endereco.Uf)
+	}
+	return key
+}
+
+// Verifica se a temperatura ou a condição do tempo mudaram
+func changed(old, new *serviceb.ClimaCidade) bool {
+	return old.TempC != new.TempC || old.TempF != new.TempF || old.TempK != new.TempK || old.Condicao != new.Condicao
+}
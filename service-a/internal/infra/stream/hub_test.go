@@ -0,0 +1,147 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"go.opentelemetry.io/otel"
+)
+
+// Client fake do service-b. Os CEPs 32450000 e 32450001 pertencem à mesma cidade.
+type fakeClient struct {
+	mu    sync.Mutex
+	temp  float64
+	calls map[string]int
+	// Consultas por país
+	paises map[string]int
+}
+
+func (f *fakeClient) BuscaTemperatura(ctx context.Context, pais, cep string) (*serviceb.ClimaCidade, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[cep]++
+	if f.paises != nil {
+		f.paises[pais]++
+	}
+	cidade := "Ibirité"
+	if cep == "01001000" {
+		cidade = "São Paulo"
+	}
+	return &serviceb.ClimaCidade{
+		Cidade:   cidade,
+		TempC:    f.temp,
+		Endereco: &serviceb.Endereco{Cep: cep, Cidade: cidade, Uf: "MG"},
+	}, nil
+}
+
+func (f *fakeClient) setTemp(temp float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.temp = temp
+}
+
+func receive(t *testing.T, sub *Subscription) *serviceb.ClimaCidade {
+	t.Helper()
+	select {
+	case clima := <-sub.Updates:
+		return clima
+	case <-time.After(time.Second):
+		t.Fatal("nenhuma atualização recebida")
+		return nil
+	}
+}
+
+// Os assinantes da mesma cidade devem compartilhar um único poller e receber apenas as mudanças
+func TestHubPollerCompartilhado(t *testing.T) {
+	client := &fakeClient{temp: 20, calls: map[string]int{}}
+	hub := NewHub(client, otel.Tracer("test"), 10*time.Millisecond)
+	defer hub.Close()
+
+	a, err := hub.Subscribe(context.Background(), "BR", "32450000")
+	require.NoError(t, err)
+	b, err := hub.Subscribe(context.Background(), "BR", "32450001")
+	require.NoError(t, err)
+	c, err := hub.Subscribe(context.Background(), "BR", "01001000")
+	require.NoError(t, err)
+	assert.Equal(t, 2, hub.Pollers())
+
+	// Temperatura atual enviada na assinatura
+	assert.Equal(t, 20.0, receive(t, a).TempC)
+	assert.Equal(t, 20.0, receive(t, b).TempC)
+	assert.Equal(t, 20.0, receive(t, c).TempC)
+
+	client.setTemp(25)
+	assert.Equal(t, 25.0, receive(t, a).TempC)
+	assert.Equal(t, 25.0, receive(t, b).TempC)
+	assert.Equal(t, 25.0, receive(t, c).TempC)
+
+	// Sem mudança, nenhuma atualização é enviada
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case clima := <-a.Updates:
+		t.Fatalf("atualização inesperada: %+v", clima)
+	default:
+	}
+
+	// O poller da cidade utiliza apenas o CEP do primeiro assinante
+	client.mu.Lock()
+	assert.Equal(t, 1, client.calls["32450001"])
+	assert.Greater(t, client.calls["32450000"], 1)
+	client.mu.Unlock()
+
+	// O poller é encerrado junto com o último assinante da cidade
+	hub.Unsubscribe(a)
+	assert.Equal(t, 2, hub.Pollers())
+	hub.Unsubscribe(b)
+	assert.Equal(t, 1, hub.Pollers())
+	_, ok := <-b.Updates
+	assert.False(t, ok)
+}
+
+// O Close deve fechar as assinaturas e recusar as novas
+func TestHubClose(t *testing.T) {
+	client := &fakeClient{temp: 20, calls: map[string]int{}}
+	hub := NewHub(client, otel.Tracer("test"), time.Hour)
+
+	sub, err := hub.Subscribe(context.Background(), "BR", "32450000")
+	require.NoError(t, err)
+	receive(t, sub)
+
+	hub.Close()
+	_, ok := <-sub.Updates
+	assert.False(t, ok)
+	assert.Equal(t, 0, hub.Pollers())
+
+	// Cancelar depois do Close não deve causar panic
+	hub.Unsubscribe(sub)
+
+	_, err = hub.Subscribe(context.Background(), "BR", "32450000")
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+// As cidades de países diferentes possuem pollers separados, que consultam o service-b com o país da assinatura
+func TestHubPais(t *testing.T) {
+	client := &fakeClient{temp: 20, calls: map[string]int{}, paises: map[string]int{}}
+	hub := NewHub(client, otel.Tracer("test"), 10*time.Millisecond)
+	defer hub.Close()
+
+	br, err := hub.Subscribe(context.Background(), "BR", "32450000")
+	require.NoError(t, err)
+	pt, err := hub.Subscribe(context.Background(), "PT", "1000001")
+	require.NoError(t, err)
+	assert.Equal(t, 2, hub.Pollers())
+	receive(t, br)
+	receive(t, pt)
+
+	client.setTemp(25)
+	assert.Equal(t, 25.0, receive(t, pt).TempC)
+	client.mu.Lock()
+	assert.Greater(t, client.paises["PT"], 1)
+	assert.Zero(t, client.paises[""])
+	client.mu.Unlock()
+}
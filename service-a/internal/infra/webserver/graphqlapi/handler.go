@@ -0,0 +1,154 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/ratelimit"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tamanho máximo do corpo da requisição GraphQL
+const maxBodySize = 1 << 20
+
+// Corpo da requisição GraphQL
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Struct do handler do endpoint /graphql
+type Handler struct {
+	schema graphql.Schema
+	client serviceb.TemperaturaClient
+	tracer trace.Tracer
+	limits Limits
+}
+
+// Função que cria o handler GraphQL. O schema é estático, então uma falha na sua criação
+// indica erro de programação e causa panic.
+func NewHandler(client serviceb.TemperaturaClient, tracer trace.Tracer, limits Limits) *Handler {
+	schema, err := NewSchema(tracer)
+	if err != nil {
+		panic(err)
+	}
+	return &Handler{
+		schema: schema,
+		client: client,
+		tracer: tracer,
+		limits: limits,
+	}
+}
+
+// Handler que executa as consultas recebidas via GET (?query=) ou POST (application/json)
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.tracer.Start(r.Context(), "GraphQL")
+	defer span.End()
+
+	var req Request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				writeErrors(w, http.StatusBadRequest, errors.New("variables must be a JSON object"))
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(&req); err != nil {
+			writeErrors(w, http.StatusBadRequest, errors.New("request body must be a JSON object with a query"))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeErrors(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+	span.SetAttributes(attribute.String("graphql.operation.name", req.OperationName))
+
+	if req.Query == "" {
+		writeErrors(w, http.StatusBadRequest, errors.New("query is required"))
+		return
+	}
+
+	// Os limites são verificados antes da execução, para que nenhuma consulta ao service-b seja realizada
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.limits.Check(doc, req.Variables); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		writeErrors(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Cada CEP consultado consome um token do rate limit do cliente
+	if !ratelimit.Charge(w, r.WithContext(ctx), Ceps(doc, req.Variables)) {
+		span.SetStatus(codes.Error, "rate limit exceeded")
+		return
+	}
+
+	ctx = WithLoader(ctx, NewLoader(h.client, h.tracer))
+	result := graphql.Do(graphql.Params{
+		Schema:         h.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+	for i := range result.Errors {
+		if result.Errors[i].Extensions == nil {
+			result.Errors[i].Extensions = extensions(result.Errors[i])
+		}
+	}
+
+	// Erros de validação não possuem dados e são tratados como requisição inválida
+	status := http.StatusOK
+	if result.Data == nil && result.HasErrors() {
+		status = http.StatusBadRequest
+		span.SetStatus(codes.Error, result.Errors[0].Message)
+	}
+	writeJSON(w, status, result)
+}
+
+// Função que recupera as extensions do erro original. Os erros retornados pelos thunks
+// são encapsulados pela biblioteca e perdem as extensions no caminho.
+func extensions(err error) map[string]interface{} {
+	for err != nil {
+		if extended, ok := err.(gqlerrors.ExtendedError); ok {
+			return extended.Extensions()
+		}
+		switch e := err.(type) {
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		default:
+			err = errors.Unwrap(err)
+		}
+	}
+	return nil
+}
+
+// Função que escreve uma resposta contendo apenas erros
+func writeErrors(w http.ResponseWriter, status int, errs ...error) {
+	writeJSON(w, status, &graphql.Result{Errors: gqlerrors.FormatErrors(errs...)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,271 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/ratelimit"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Client fake do service-b que conta as consultas realizadas por CEP
+type fakeClient struct {
+	mu    sync.Mutex
+	calls map[string]int
+	// Consultas por país e código postal
+	paises map[string]int
+}
+
+func (f *fakeClient) BuscaTemperatura(ctx context.Context, pais, cep string) (*serviceb.ClimaCidade, error) {
+	f.mu.Lock()
+	f.calls[cep]++
+	f.paises[pais+"/"+cep]++
+	f.mu.Unlock()
+
+	if cep == "00000000" {
+		details := problem.ZipcodeNotFound("zipcode 00000000 does not exist")
+		details.TraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		return nil, &serviceb.StatusError{StatusCode: http.StatusNotFound, Problem: details}
+	}
+	return &serviceb.ClimaCidade{
+		Cidade:   "Ibirité",
+		TempC:    28.5,
+		TempF:    83.3,
+		TempK:    301.5,
+		Condicao: "Sunny",
+		Endereco: &serviceb.Endereco{Cep: cep, Cidade: "Ibirité", Uf: "MG"},
+	}, nil
+}
+
+type response struct {
+	Data   map[string]any `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Path       []any          `json:"path"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func newTestHandler(limits Limits) (*Handler, *fakeClient, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("graphql-test")
+	client := &fakeClient{calls: map[string]int{}, paises: map[string]int{}}
+	return NewHandler(client, tracer, limits), client, recorder
+}
+
+func execute(t *testing.T, h *Handler, req Request) (int, response) {
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+
+	var resp response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func spanNames(recorder *tracetest.SpanRecorder) map[string]int {
+	names := map[string]int{}
+	for _, span := range recorder.Ended() {
+		names[span.Name()]++
+	}
+	return names
+}
+
+// Os CEPs de todos os campos da consulta devem ser buscados em um único lote, sem repetição
+func TestGraphQLLoteECache(t *testing.T) {
+	h, client, recorder := newTestHandler(Limits{MaxDepth: 5, MaxComplexity: 200})
+
+	status, resp := execute(t, h, Request{Query: `{
+		a: temperature(cep: "32450000") { city tempC }
+		b: temperature(cep: "32450000") { city }
+		temperatures(ceps: ["32450000", "01001000", "20040002"]) { cep city tempF tempK condition address { cep state } }
+	}`})
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]int{"32450000": 1, "01001000": 1, "20040002": 1}, client.calls)
+
+	a := resp.Data["a"].(map[string]any)
+	assert.Equal(t, "Ibirité", a["city"])
+	assert.Equal(t, 28.5, a["tempC"])
+
+	temperatures := resp.Data["temperatures"].([]any)
+	require.Len(t, temperatures, 3)
+	second := temperatures[1].(map[string]any)
+	assert.Equal(t, "01001000", second["cep"])
+	assert.Equal(t, "Sunny", second["condition"])
+	assert.Equal(t, map[string]any{"cep": "01001000", "state": "MG"}, second["address"])
+
+	names := spanNames(recorder)
+	assert.Equal(t, 1, names["GraphQL Lote de CEPs"])
+	assert.Equal(t, 2, names["GraphQL Query.temperature"])
+	assert.Equal(t, 3, names["GraphQL Query.temperatures"])
+	assert.Equal(t, 1, names["GraphQL"])
+}
+
+// A falha de um CEP não deve impedir o retorno dos demais. O erro indica o item e traz o código e o trace_id.
+func TestGraphQLErroPorItem(t *testing.T) {
+	h, _, _ := newTestHandler(Limits{MaxDepth: 5, MaxComplexity: 200})
+
+	status, resp := execute(t, h, Request{
+		Query:     `query Busca($ceps: [String!]!) { temperatures(ceps: $ceps) { cep city } }`,
+		Variables: map[string]any{"ceps": []any{"32450000", "00000000"}},
+	})
+
+	assert.Equal(t, http.StatusOK, status)
+	temperatures := resp.Data["temperatures"].([]any)
+	require.Len(t, temperatures, 2)
+	assert.NotNil(t, temperatures[0])
+	assert.Nil(t, temperatures[1])
+
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "can not find zipcode", resp.Errors[0].Message)
+	assert.Equal(t, []any{"temperatures", float64(1)}, resp.Errors[0].Path)
+	assert.Equal(t, "ZIPCODE_NOT_FOUND", resp.Errors[0].Extensions["code"])
+	assert.Equal(t, float64(http.StatusNotFound), resp.Errors[0].Extensions["status"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", resp.Errors[0].Extensions["trace_id"])
+}
+
+// Consultas acima dos limites são rejeitadas sem consultar o service-b
+func TestGraphQLLimites(t *testing.T) {
+	tests := []struct {
+		name     string
+		limits   Limits
+		req      Request
+		mensagem string
+	}{
+		{
+			name:     "profundidade",
+			limits:   Limits{MaxDepth: 2, MaxComplexity: 200},
+			req:      Request{Query: `{ temperature(cep: "32450000") { address { city } } }`},
+			mensagem: "query depth 3 exceeds the maximum allowed of 2",
+		},
+		{
+			name:     "complexidade com lista literal",
+			limits:   Limits{MaxDepth: 5, MaxComplexity: 10},
+			req:      Request{Query: `{ temperatures(ceps: ["1", "2", "3", "4"]) { cep city tempC } }`},
+			mensagem: "query complexity 13 exceeds the maximum allowed of 10",
+		},
+		{
+			name:   "complexidade com variáveis e fragmento",
+			limits: Limits{MaxDepth: 5, MaxComplexity: 10},
+			req: Request{
+				Query:     `query($ceps: [String!]!) { temperatures(ceps: $ceps) { ...campos } } fragment campos on Temperature { cep city }`,
+				Variables: map[string]any{"ceps": []any{"1", "2", "3", "4", "5"}},
+			},
+			mensagem: "query complexity 11 exceeds the maximum allowed of 10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, client, _ := newTestHandler(tt.limits)
+			status, resp := execute(t, h, tt.req)
+
+			assert.Equal(t, http.StatusBadRequest, status)
+			require.Len(t, resp.Errors, 1)
+			assert.Equal(t, tt.mensagem, resp.Errors[0].Message)
+			assert.Empty(t, client.calls)
+		})
+	}
+}
+
+// Consultas inválidas retornam 400
+func TestGraphQLConsultaInvalida(t *testing.T) {
+	h, _, _ := newTestHandler(Limits{MaxDepth: 5, MaxComplexity: 200})
+
+	status, resp := execute(t, h, Request{Query: `{ temperature { city } }`})
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.NotEmpty(t, resp.Errors)
+
+	status, _ = execute(t, h, Request{Query: `{ temperature(`})
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, `/graphql?query={temperature(cep:"32450000"){city}}`, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"temperature":{"city":"Ibirité"}}}`, w.Body.String())
+}
+
+// O país informado é validado e enviado ao service-b, e o mesmo código em países diferentes é
+// consultado separadamente
+func TestGraphQLPais(t *testing.T) {
+	h, client, _ := newTestHandler(Limits{MaxDepth: 5, MaxComplexity: 200})
+
+	status, resp := execute(t, h, Request{Query: `{
+		a: temperature(cep: "1000-001", country: "PT") { city }
+		b: temperatures(ceps: ["1000-001", "32450000"], country: "pt") { city }
+		c: temperature(cep: "32450000") { city }
+	}`})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]int{"PT/1000-001": 1, "PT/32450000": 1, "BR/32450000": 1}, client.paises)
+
+	status, resp = execute(t, h, Request{Query: `{ temperature(cep: "32450000", country: "US") { city } }`})
+	assert.Equal(t, http.StatusOK, status)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "INVALID_ZIPCODE", resp.Errors[0].Extensions["code"])
+	assert.Equal(t, "the country must be one of AR, BR, PT", resp.Errors[0].Extensions["detail"])
+	assert.Len(t, client.paises, 3)
+}
+
+// Cada CEP da consulta consome um token do rate limit, antes de qualquer consulta ao service-b
+func TestGraphQLRateLimitPorCep(t *testing.T) {
+	h, client, _ := newTestHandler(Limits{MaxDepth: 5, MaxComplexity: 200})
+	plans, err := ratelimit.ParsePlans("anonymous=4/1m")
+	require.NoError(t, err)
+	handler := ratelimit.Middleware(ratelimit.Config{
+		Limiter: ratelimit.NewMemory(),
+		Plans:   plans,
+		Tracer:  sdktrace.NewTracerProvider().Tracer("test"),
+	})(h)
+	serve := func(query string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(Request{Query: query})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body))))
+		return w
+	}
+
+	w := serve(`{ temperatures(ceps: ["1", "2", "3", "4", "5"]) { cep } }`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Empty(t, client.calls)
+
+	// A requisição rejeitada consumiu somente o token do middleware
+	w = serve(`{ a: temperature(cep: "1") { cep } b: temperatures(ceps: ["2", "3"]) { cep } }`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Len(t, client.calls, 3)
+
+	assert.Equal(t, http.StatusTooManyRequests, serve(`{ temperature(cep: "4") { cep } }`).Code)
+	assert.Len(t, client.calls, 3)
+}
+
+func TestCeps(t *testing.T) {
+	tests := []struct {
+		query     string
+		variables map[string]any
+		esperado  int
+	}{
+		{`{ temperature(cep: "1") { city } }`, nil, 1},
+		{`{ a: temperature(cep: "1") { city } b: temperatures(ceps: ["2", "3"]) { city } }`, nil, 3},
+		{`query($ceps: [String!]!) { temperatures(ceps: $ceps) { ...campos } } fragment campos on Temperature { cep }`, map[string]any{"ceps": []any{"1", "2", "3", "4"}}, 4},
+		{`{ __schema { types { name } } }`, nil, 0},
+	}
+	for _, tt := range tests {
+		doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(tt.query)})})
+		require.NoError(t, err)
+		assert.Equal(t, tt.esperado, Ceps(doc, tt.variables), tt.query)
+	}
+}
@@ -0,0 +1,152 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limites aplicados às consultas antes da execução
+type Limits struct {
+	// Profundidade máxima de campos aninhados
+	MaxDepth int
+	// Custo máximo da consulta. Cada campo custa 1, multiplicado pelo tamanho
+	// dos argumentos do tipo lista dos campos acima dele (ex.: temperatures(ceps: [...])).
+	MaxComplexity int
+}
+
+// Erro retornado quando a consulta ultrapassa algum dos limites
+type LimitError struct {
+	Limit string
+	Value int
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("query %s %d exceeds the maximum allowed of %d", e.Limit, e.Value, e.Max)
+}
+
+// Struct que percorre o documento calculando a profundidade e o custo
+type analyzer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool
+	depth     int
+	// Quantidade de CEPs informados nos argumentos cep e ceps
+	ceps int
+}
+
+// Função que cria o analyzer do documento, com os fragmentos indexados pelo nome
+func newAnalyzer(doc *ast.Document, variables map[string]interface{}) *analyzer {
+	a := &analyzer{
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+		visiting:  map[string]bool{},
+	}
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			a.fragments[fragment.Name.Value] = fragment
+		}
+	}
+	return a
+}
+
+// Função que verifica se as operações do documento respeitam os limites.
+// Os campos de introspecção (__schema, __type) não são contabilizados.
+func (l Limits) Check(doc *ast.Document, variables map[string]interface{}) error {
+	a := newAnalyzer(doc, variables)
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		a.depth = 0
+		complexity := a.selectionSet(op.SelectionSet, 1, 1)
+		if l.MaxDepth > 0 && a.depth > l.MaxDepth {
+			return &LimitError{Limit: "depth", Value: a.depth, Max: l.MaxDepth}
+		}
+		if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+			return &LimitError{Limit: "complexity", Value: complexity, Max: l.MaxComplexity}
+		}
+	}
+	return nil
+}
+
+// Função que retorna a quantidade de CEPs informados nos argumentos cep e ceps das operações do
+// documento, utilizada como custo da requisição no rate limit. Os CEPs repetidos são contabilizados,
+// assim como na complexidade.
+func Ceps(doc *ast.Document, variables map[string]interface{}) int {
+	a := newAnalyzer(doc, variables)
+	for _, def := range doc.Definitions {
+		if op, ok := def.(*ast.OperationDefinition); ok {
+			a.selectionSet(op.SelectionSet, 1, 1)
+		}
+	}
+	return a.ceps
+}
+
+// Função que retorna o custo do selection set, atualizando a maior profundidade encontrada
+func (a *analyzer) selectionSet(set *ast.SelectionSet, depth, multiplier int) int {
+	if set == nil {
+		return 0
+	}
+	cost := 0
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			if depth > a.depth {
+				a.depth = depth
+			}
+			for _, arg := range s.Arguments {
+				switch arg.Name.Value {
+				case "cep":
+					a.ceps += multiplier
+				case "ceps":
+					a.ceps += multiplier * a.argSize(arg)
+				}
+			}
+			cost += multiplier + a.selectionSet(s.SelectionSet, depth+1, multiplier*a.listSize(s))
+		case *ast.InlineFragment:
+			cost += a.selectionSet(s.SelectionSet, depth, multiplier)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			fragment, ok := a.fragments[name]
+			// Fragmentos cíclicos são rejeitados na validação do schema
+			if !ok || a.visiting[name] {
+				continue
+			}
+			a.visiting[name] = true
+			cost += a.selectionSet(fragment.SelectionSet, depth, multiplier)
+			a.visiting[name] = false
+		}
+	}
+	return cost
+}
+
+// Função que retorna o tamanho do maior argumento do tipo lista do campo (mínimo 1)
+func (a *analyzer) listSize(field *ast.Field) int {
+	size := 1
+	for _, arg := range field.Arguments {
+		if n := a.argSize(arg); n > size {
+			size = n
+		}
+	}
+	return size
+}
+
+// Função que retorna o tamanho do argumento do tipo lista, ou zero para os demais tipos
+func (a *analyzer) argSize(arg *ast.Argument) int {
+	switch v := arg.Value.(type) {
+	case *ast.ListValue:
+		return len(v.Values)
+	case *ast.Variable:
+		if list, ok := a.variables[v.Name.Value].([]interface{}); ok {
+			return len(list)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package graphqlapi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Quantidade de CEPs consultados em paralelo em cada lote
+const batchConcurrency = 8
+
+// Código postal de um país, chave do cache do loader
+type postalCode struct {
+	pais string
+	cep  string
+}
+
+// Resultado da consulta de um CEP. O canal done é fechado quando o resultado fica pronto.
+type entry struct {
+	done  chan struct{}
+	clima *serviceb.ClimaCidade
+	err   error
+}
+
+// Struct do dataloader de CEPs. Cada requisição GraphQL possui o seu próprio loader, que agrupa
+// os CEPs solicitados pelos resolvers em um único lote e guarda os resultados em cache, para que
+// o mesmo CEP não seja consultado duas vezes na mesma requisição.
+type Loader struct {
+	client  serviceb.TemperaturaClient
+	tracer  trace.Tracer
+	mu      sync.Mutex
+	cache   map[postalCode]*entry
+	pending []postalCode
+}
+
+// Função que cria um novo loader
+func NewLoader(client serviceb.TemperaturaClient, tracer trace.Tracer) *Loader {
+	return &Loader{
+		client: client,
+		tracer: tracer,
+		cache:  map[postalCode]*entry{},
+	}
+}
+
+// Função que registra o código postal do país no próximo lote e retorna o thunk que aguarda o
+// resultado. O lote é executado quando o primeiro thunk é chamado.
+func (l *Loader) Load(ctx context.Context, pais, cep string) func() (*serviceb.ClimaCidade, error) {
+	key := postalCode{pais: pais, cep: cep}
+	l.mu.Lock()
+	e, ok := l.cache[key]
+	if !ok {
+		e = &entry{done: make(chan struct{})}
+		l.cache[key] = e
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (*serviceb.ClimaCidade, error) {
+		l.dispatch(ctx)
+		<-e.done
+		return e.clima, e.err
+	}
+}
+
+// Função que executa o lote pendente, consultando os CEPs em paralelo no service-b
+func (l *Loader) dispatch(ctx context.Context) {
+	l.mu.Lock()
+	ceps := l.pending
+	l.pending = nil
+	entries := make([]*entry, len(ceps))
+	for i, key := range ceps {
+		entries[i] = l.cache[key]
+	}
+	l.mu.Unlock()
+
+	if len(ceps) == 0 {
+		return
+	}
+
+	ctx, span := l.tracer.Start(ctx, "GraphQL Lote de CEPs")
+	defer span.End()
+	span.SetAttributes(attribute.Int("graphql.batch.size", len(ceps)))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, batchConcurrency)
+	for i, key := range ceps {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(e *entry, key postalCode) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			e.clima, e.err = l.client.BuscaTemperatura(ctx, key.pais, key.cep)
+			close(e.done)
+		}(entries[i], key)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,191 @@
+package graphqlapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Chave do loader no contexto da requisição
+type loaderKey struct{}
+
+// Função que adiciona o loader ao contexto
+func WithLoader(ctx context.Context, loader *Loader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, loader)
+}
+
+// Função que recupera o loader do contexto
+func loaderFrom(ctx context.Context) *Loader {
+	loader, _ := ctx.Value(loaderKey{}).(*Loader)
+	return loader
+}
+
+// Erro de um CEP específico. As extensions são enviadas no campo "extensions" do erro GraphQL.
+type fieldError struct {
+	problem *problem.Details
+}
+
+func (e *fieldError) Error() string {
+	return e.problem.Message
+}
+
+func (e *fieldError) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{
+		"code":   code(e.problem.Type),
+		"status": e.problem.Status,
+	}
+	if e.problem.Detail != "" {
+		ext["detail"] = e.problem.Detail
+	}
+	if e.problem.TraceID != "" {
+		ext["trace_id"] = e.problem.TraceID
+	}
+	return ext
+}
+
+// Converte o type do problema no código utilizado nas extensions (ex.: ZIPCODE_NOT_FOUND)
+func code(typ string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimPrefix(typ, problem.TypeBaseURL), "-", "_"))
+}
+
+var addressType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Address",
+	Description: "Endereço do CEP consultado",
+	Fields: graphql.Fields{
+		"cep":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"street":       &graphql.Field{Type: graphql.String},
+		"neighborhood": &graphql.Field{Type: graphql.String},
+		"city":         &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"state":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var temperatureType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Temperature",
+	Description: "Temperatura atual da cidade do CEP",
+	Fields: graphql.Fields{
+		"cep":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"city":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"tempC":     &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		"tempF":     &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		"tempK":     &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		"condition": &graphql.Field{Type: graphql.String},
+		"address":   &graphql.Field{Type: addressType},
+	},
+})
+
+// Argumento opcional com o país dos códigos postais (BR, PT ou AR)
+var countryArg = &graphql.ArgumentConfig{
+	Type:        graphql.String,
+	Description: "País dos códigos postais: " + strings.Join(cep.Countries(), ", ") + ". O padrão é " + cep.DefaultCountry + ".",
+}
+
+// Função que cria o schema GraphQL. Os resolvers utilizam o loader presente no contexto
+// e criam um span para cada campo resolvido.
+func NewSchema(tracer trace.Tracer) (graphql.Schema, error) {
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"temperature": &graphql.Field{
+				Type:        temperatureType,
+				Description: "Consulta a temperatura de um CEP ou do código postal do país informado (padrão BR)",
+				Args: graphql.FieldConfigArgument{
+					"cep":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"country": countryArg,
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					cep, _ := p.Args["cep"].(string)
+					pais, _ := p.Args["country"].(string)
+					return resolveCep(p.Context, tracer, "Query.temperature", pais, cep), nil
+				},
+			},
+			"temperatures": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(temperatureType)),
+				Description: "Consulta a temperatura de vários CEPs ou códigos postais do país informado (padrão BR). Os CEPs são buscados em lote.",
+				Args: graphql.FieldConfigArgument{
+					"ceps":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+					"country": countryArg,
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					ceps, _ := p.Args["ceps"].([]interface{})
+					pais, _ := p.Args["country"].(string)
+					items := make([]interface{}, len(ceps))
+					for i, cep := range ceps {
+						items[i] = resolveCep(p.Context, tracer, "Query.temperatures", pais, cep.(string))
+					}
+					return items, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// Função que registra o CEP no loader e retorna o thunk que será resolvido após o lote.
+// O span do resolver cobre o tempo de espera do lote. Um país não suportado é retornado como erro
+// do campo, sem consultar o service-b.
+func resolveCep(ctx context.Context, tracer trace.Tracer, field, pais, codigo string) func() (interface{}, error) {
+	ctx, span := tracer.Start(ctx, "GraphQL "+field)
+	span.SetAttributes(attribute.String("cep", codigo), attribute.String("country", pais))
+
+	pais, err := cep.NormalizeCountry(pais)
+	if err != nil {
+		return func() (interface{}, error) {
+			defer span.End()
+			details := problem.InvalidZipcode("").Detailf("the country must be one of %s", strings.Join(cep.Countries(), ", "))
+			details.TraceID = span.SpanContext().TraceID().String()
+			span.SetStatus(codes.Error, details.Message)
+			return nil, &fieldError{problem: details}
+		}
+	}
+	load := loaderFrom(ctx).Load(ctx, pais, codigo)
+
+	return func() (interface{}, error) {
+		defer span.End()
+		clima, err := load()
+		if err != nil {
+			details := serviceb.ToProblem(err)
+			if details.TraceID == "" {
+				details.TraceID = span.SpanContext().TraceID().String()
+			}
+			span.RecordError(err)
+			span.SetStatus(codes.Error, details.Message)
+			return nil, &fieldError{problem: details}
+		}
+		return toMap(codigo, clima), nil
+	}
+}
+
+// Converte a resposta do service-b nos campos do tipo Temperature
+func toMap(cep string, clima *serviceb.ClimaCidade) map[string]interface{} {
+	result := map[string]interface{}{
+		"cep":       cep,
+		"city":      clima.Cidade,
+		"tempC":     clima.TempC,
+		"tempF":     clima.TempF,
+		"tempK":     clima.TempK,
+		"condition": nil,
+		"address":   nil,
+	}
+	if clima.Condicao != "" {
+		result["condition"] = clima.Condicao
+	}
+	if e := clima.Endereco; e != nil {
+		result["address"] = map[string]interface{}{
+			"cep":          e.Cep,
+			"street":       e.Logradouro,
+			"neighborhood": e.Bairro,
+			"city":         e.Cidade,
+			"state":        e.Uf,
+		}
+	}
+	return result
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/render"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/alert"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/auth"
+)
+
+// Valores padrão do scheduler de alertas
+const (
+	DefaultAlertInterval    = time.Minute
+	DefaultAlertMaxAttempts = 5
+	DefaultAlertBackoff     = time.Second
+)
+
+// Tamanho máximo do body das assinaturas, em bytes
+const alertMaxBodySize = 4 << 10
+
+// Exemplo de assinatura utilizado nas mensagens de erro do decode
+const exemploAlerta = `{"cep": "32450000", "threshold": 30, "direction": "above", "url": "https://example.com/hook"}`
+
+// Struct que será utilizada para receber a assinatura do body da requisição. O país é opcional (padrão BR).
+type AlertaRequest struct {
+	Country   string   `json:"country,omitempty"`
+	Cep       string   `json:"cep"`
+	Threshold *float64 `json:"threshold"`
+	Direction string   `json:"direction"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"`
+}
+
+// Função que registra as rotas do CRUD de assinaturas e da lista de falhas. As assinaturas
+// pertencem ao cliente autenticado que as criou; a lista de falhas, que contém as entregas de
+// todos os clientes, exige o escopo admin.
+func (h *Webserver) alertRoutes(router chi.Router) {
+	router.Post("/alerts", h.CriaAlertaHandler)
+	router.Get("/alerts", h.ListaAlertasHandler)
+	router.With(h.requireScope(auth.ScopeAdmin)).Get("/alerts/dead-letters", h.ListaFalhasAlertaHandler)
+	router.With(h.requireScope(auth.ScopeAdmin)).Post("/alerts/dead-letters/{deliveryID}/retry", h.ReenviaFalhaAlertaHandler)
+	router.Get("/alerts/{id}", h.BuscaAlertaHandler)
+	router.Put("/alerts/{id}", h.AtualizaAlertaHandler)
+	router.Delete("/alerts/{id}", h.RemoveAlertaHandler)
+}
+
+// Função que decodifica e valida a assinatura recebida. Em caso de erro, o problema já foi escrito na resposta.
+func decodeAlerta(w http.ResponseWriter, r *http.Request) (alert.Subscription, bool) {
+	var req AlertaRequest
+	if details := decodeJSONStrict(w, r, &req, alertMaxBodySize, exemploAlerta); details != nil {
+		problem.Write(r.Context(), w, r, details)
+		return alert.Subscription{}, false
+	}
+	cep, err := normalizaCodigoPostal(req.Country, req.Cep)
+	if err != nil {
+		problem.Write(r.Context(), w, r, problemaCepInvalido(err))
+		return alert.Subscription{}, false
+	}
+	if req.Threshold == nil {
+		problem.Write(r.Context(), w, r, problem.BadRequest(alert.ErrMissingThreshold.Error()))
+		return alert.Subscription{}, false
+	}
+
+	sub := alert.Subscription{
+		Owner:     auth.ClientID(r.Context()),
+		Country:   cep.Country,
+		Cep:       cep.String(),
+		Threshold: *req.Threshold,
+		Direction: req.Direction,
+		URL:       req.URL,
+		Secret:    req.Secret,
+	}
+	if err := sub.Validate(); err != nil {
+		problem.Write(r.Context(), w, r, problem.BadRequest(err.Error()))
+		return alert.Subscription{}, false
+	}
+	return sub, true
+}
+
+// Função que cria a assinatura. O segredo da assinatura HMAC é retornado somente nesta resposta.
+func (h *Webserver) CriaAlertaHandler(w http.ResponseWriter, r *http.Request) {
+	sub, ok := decodeAlerta(w, r)
+	if !ok {
+		return
+	}
+	created := h.Alerts.Store().Create(sub)
+	w.Header().Set("Location", "/alerts/"+created.ID)
+	render.Write(w, r, http.StatusCreated, created)
+}
+
+// Função que lista as assinaturas
+func (h *Webserver) ListaAlertasHandler(w http.ResponseWriter, r *http.Request) {
+	list := h.Alerts.Store().ListByOwner(auth.ClientID(r.Context()))
+	for _, sub := range list {
+		sub.Secret = ""
+	}
+	render.Write(w, r, http.StatusOK, list)
+}
+
+// Função que busca a assinatura pelo ID
+func (h *Webserver) BuscaAlertaHandler(w http.ResponseWriter, r *http.Request) {
+	sub, err := h.Alerts.Store().Get(auth.ClientID(r.Context()), chi.URLParam(r, "id"))
+	if err != nil {
+		problem.Write(r.Context(), w, r, problem.NotFound(err.Error()))
+		return
+	}
+	sub.Secret = ""
+	render.Write(w, r, http.StatusOK, sub)
+}
+
+// Função que atualiza a assinatura
+func (h *Webserver) AtualizaAlertaHandler(w http.ResponseWriter, r *http.Request) {
+	sub, ok := decodeAlerta(w, r)
+	if !ok {
+		return
+	}
+	updated, err := h.Alerts.Store().Update(sub.Owner, chi.URLParam(r, "id"), sub)
+	if err != nil {
+		problem.Write(r.Context(), w, r, problem.NotFound(err.Error()))
+		return
+	}
+	updated.Secret = ""
+	render.Write(w, r, http.StatusOK, updated)
+}
+
+// Função que remove a assinatura
+func (h *Webserver) RemoveAlertaHandler(w http.ResponseWriter, r *http.Request) {
+	if err := h.Alerts.Store().Delete(auth.ClientID(r.Context()), chi.URLParam(r, "id")); err != nil {
+		problem.Write(r.Context(), w, r, problem.NotFound(err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Função que lista os webhooks que falharam em todas as tentativas
+func (h *Webserver) ListaFalhasAlertaHandler(w http.ResponseWriter, r *http.Request) {
+	render.Write(w, r, http.StatusOK, h.Alerts.DeadLetters())
+}
+
+// Função que reenvia um webhook da lista de falhas
+func (h *Webserver) ReenviaFalhaAlertaHandler(w http.ResponseWriter, r *http.Request) {
+	err := h.Alerts.Redeliver(r.Context(), chi.URLParam(r, "deliveryID"))
+	if errors.Is(err, alert.ErrDeadLetterNotFound) {
+		problem.Write(r.Context(), w, r, problem.NotFound(err.Error()))
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
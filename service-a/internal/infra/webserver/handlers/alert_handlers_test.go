@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/alert"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/auth"
+	"go.opentelemetry.io/otel"
+)
+
+// CRUD das assinaturas de alerta
+func TestAlertasCRUD(t *testing.T) {
+	server := NewServer(&TemplateData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ServiceBClient:  &streamClient{},
+	})
+	router := server.CreateServer()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Criação: o segredo é retornado somente nesta resposta
+	w := do(http.MethodPost, "/alerts", `{"cep": "32450000", "threshold": 30, "direction": "above", "url": "https://example.com/hook"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created alert.Subscription
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.ID)
+	assert.NotEmpty(t, created.Secret)
+	assert.Equal(t, "/alerts/"+created.ID, w.Header().Get("Location"))
+
+	w = do(http.MethodGet, "/alerts", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list []alert.Subscription
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Empty(t, list[0].Secret)
+
+	assert.Equal(t, "BR", created.Country)
+
+	// O código postal é normalizado no formato do país informado
+	w = do(http.MethodPut, "/alerts/"+created.ID, `{"country": "PT", "cep": "1000-001", "threshold": 5, "direction": "below", "url": "https://example.com/hook"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = do(http.MethodGet, "/alerts/"+created.ID, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"direction":"below"`)
+	assert.Contains(t, w.Body.String(), `"country":"PT","cep":"1000001"`)
+	assert.NotContains(t, w.Body.String(), created.Secret)
+
+	w = do(http.MethodDelete, "/alerts/"+created.ID, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = do(http.MethodGet, "/alerts/"+created.ID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+	w = do(http.MethodGet, "/alerts/dead-letters", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+	w = do(http.MethodPost, "/alerts/dead-letters/inexistente/retry", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// Assinaturas inválidas são recusadas
+func TestAlertasInvalidos(t *testing.T) {
+	server := NewServer(&TemplateData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ServiceBClient:  &streamClient{},
+	})
+	router := server.CreateServer()
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{`, http.StatusBadRequest},
+		{`{"cep": "32450000", "threshold": 30, "direction": "above", "url": "https://example.com/hook", "extra": 1}`, http.StatusBadRequest},
+		{`{"cep": "32450000", "threshold": 30, "direction": "above", "url": "https://example.com/` + strings.Repeat("a", alertMaxBodySize) + `"}`, http.StatusRequestEntityTooLarge},
+		{`{"cep": "3245", "threshold": 30, "direction": "above", "url": "https://example.com/hook"}`, http.StatusUnprocessableEntity},
+		{`{"country": "US", "cep": "32450000", "threshold": 30, "direction": "above", "url": "https://example.com/hook"}`, http.StatusUnprocessableEntity},
+		{`{"country": "PT", "cep": "32450000", "threshold": 30, "direction": "above", "url": "https://example.com/hook"}`, http.StatusUnprocessableEntity},
+		{`{"cep": "32450000", "direction": "above", "url": "https://example.com/hook"}`, http.StatusBadRequest},
+		{`{"cep": "32450000", "threshold": 30, "direction": "sideways", "url": "https://example.com/hook"}`, http.StatusBadRequest},
+		{`{"cep": "32450000", "threshold": 30, "direction": "above", "url": "ftp://example.com/hook"}`, http.StatusBadRequest},
+		{`{"cep": "32450000", "threshold": 30, "direction": "above", "url": "http://169.254.169.254/latest/meta-data"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/alerts", strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.status, w.Code, tt.body)
+	}
+
+	// Sem o Content-Type application/json
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/alerts", strings.NewReader(exemploAlerta)))
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/alerts/inexistente", strings.NewReader(exemploAlerta))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// Com a autenticação, cada cliente acessa somente as próprias assinaturas e a lista de falhas exige o escopo admin
+func TestAlertasDono(t *testing.T) {
+	keys, err := auth.NewAPIKeys([]auth.APIKey{
+		{ClientID: "app-a", SHA256: auth.HashKey("chave-a"), Scopes: []string{auth.ScopeCurrent}},
+		{ClientID: "app-b", SHA256: auth.HashKey("chave-b"), Scopes: []string{auth.ScopeCurrent}},
+		{ClientID: "ops", SHA256: auth.HashKey("chave-ops"), Scopes: []string{auth.ScopeAdmin}},
+	})
+	require.NoError(t, err)
+	router := NewServer(&TemplateData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ServiceBClient:  &streamClient{},
+		Authenticator:   &auth.Authenticator{APIKeys: keys},
+	}).CreateServer()
+
+	do := func(method, path, body, apiKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(auth.APIKeyHeader, apiKey)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/alerts", exemploAlerta, "chave-a")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created alert.Subscription
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "app-a", created.Owner)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/alerts/"+created.ID, "", "chave-a").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/alerts/"+created.ID, "", "chave-b").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/alerts/"+created.ID, exemploAlerta, "chave-b").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/alerts/"+created.ID, "", "chave-b").Code)
+
+	w = do(http.MethodGet, "/alerts", "", "chave-b")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/alerts/dead-letters", "", "chave-a").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/alerts/dead-letters/inexistente/retry", "", "chave-a").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/alerts/dead-letters", "", "chave-ops").Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/alerts/"+created.ID, "", "chave-a").Code)
+}
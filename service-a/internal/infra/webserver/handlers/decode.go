@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+)
+
+// Tamanho máximo padrão do body do POST /cep, em bytes
+const DefaultCepMaxBodySize = 1 << 10
+
+// Função que decodifica o body JSON da requisição de forma estrita: exige o Content-Type
+// application/json, limita o tamanho do body a maxSize bytes, rejeita campos desconhecidos e
+// exige um único valor JSON. Em caso de erro, retorna o problema que deve ser respondido
+// (400, 413 ou 415). O example é utilizado na mensagem dos erros de formato.
+func decodeJSONStrict(w http.ResponseWriter, r *http.Request, v any, maxSize int64, example string) *problem.Details {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return problem.UnsupportedMediaType("the request body must be sent with Content-Type: application/json")
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return decodeProblem(err, maxSize, example)
+	}
+	// Qualquer conteúdo depois do primeiro valor JSON (exceto espaços) é rejeitado
+	if err := decoder.Decode(&json.RawMessage{}); !errors.Is(err, io.EOF) {
+		if err != nil {
+			if tooLarge := tooLargeProblem(err, maxSize); tooLarge != nil {
+				return tooLarge
+			}
+		}
+		return problem.BadRequest("").Detailf("the request body must contain a single JSON object like %s", example)
+	}
+	return nil
+}
+
+// Função que converte o erro do decode no problema correspondente, com o motivo da falha
+func decodeProblem(err error, maxSize int64, example string) *problem.Details {
+	if tooLarge := tooLargeProblem(err, maxSize); tooLarge != nil {
+		return tooLarge
+	}
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return problem.BadRequest("").Detailf("the field %q must be a %s", typeErr.Field, typeErr.Type)
+	case errors.Is(err, io.EOF):
+		return problem.BadRequest("").Detailf("the request body is empty, expected a JSON object like %s", example)
+	}
+	// Campo desconhecido. O encoding/json não possui um tipo de erro específico para esse caso.
+	var field string
+	if _, scanErr := fmt.Sscanf(err.Error(), "json: unknown field %q", &field); scanErr == nil {
+		return problem.BadRequest("").Detailf("the field %q is not allowed, expected a JSON object like %s", field, example)
+	}
+	return problem.BadRequest("").Detailf("the request body must be a JSON object like %s", example)
+}
+
+func tooLargeProblem(err error, maxSize int64) *problem.Details {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return problem.PayloadTooLarge("").Detailf("the request body must have at most %d bytes", maxSize)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/render"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/auth"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/history"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Valores padrão do histórico de consultas
+const (
+	DefaultHistoryRetention     = 30 * 24 * time.Hour
+	DefaultHistoryPurgeInterval = time.Hour
+)
+
+// Função que identifica o cliente da requisição: o cliente autenticado ou, sem autenticação, o IP.
+// A identificação informada pelo próprio cliente não é utilizada, pois não pode ser verificada.
+func clientID(r *http.Request) string {
+	if id := auth.ClientID(r.Context()); id != "" {
+		return id
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Função que registra a consulta no histórico, quando habilitado. As falhas são apenas registradas no log,
+// para não afetar a resposta da consulta.
+func (h *Webserver) registraHistorico(ctx context.Context, r *http.Request, cep string, status int, clima *ClimaCidade, start time.Time) {
+	if h.TemplateData.History == nil {
+		return
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	entry := &history.Entry{
+		Cep:       cep,
+		Status:    status,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		ClientID:  clientID(r),
+		CreatedAt: start,
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		entry.TraceID = spanContext.TraceID().String()
+	}
+	if clima != nil {
+		entry.City = clima.Cidade
+		entry.TempC = &clima.TempC
+		entry.TempF = &clima.TempF
+		entry.TempK = &clima.TempK
+	}
+
+	// A requisição já foi respondida, então o registro não depende do cancelamento do contexto dela
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := h.TemplateData.History.Save(ctx, entry); err != nil {
+		log.Printf("Erro ao registrar a consulta do cep %s no histórico: %s", cep, err)
+	}
+}
+
+// Função que consulta o histórico. Filtros: cep, city, status, client_id, from e to (RFC 3339).
+// A paginação utiliza os parâmetros limit e cursor (o next_cursor da página anterior).
+func (h *Webserver) HistoricoHandler(w http.ResponseWriter, r *http.Request) {
+	if h.TemplateData.History == nil {
+		problem.Write(r.Context(), w, r, problem.NotFound("the lookup history is not enabled"))
+		return
+	}
+
+	query := r.URL.Query()
+	filter := history.Filter{
+		Cep:      query.Get("cep"),
+		City:     query.Get("city"),
+		ClientID: query.Get("client_id"),
+	}
+	var err error
+	parseInt := func(name string) int64 {
+		value := query.Get(name)
+		if value == "" || err != nil {
+			return 0
+		}
+		var n int64
+		n, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			err = fmt.Errorf("invalid value for the %s parameter", name)
+		}
+		return n
+	}
+	parseTime := func(name string) time.Time {
+		value := query.Get(name)
+		if value == "" || err != nil {
+			return time.Time{}
+		}
+		var t time.Time
+		t, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			err = fmt.Errorf("invalid value for the %s parameter", name)
+		}
+		return t
+	}
+	filter.Status = int(parseInt("status"))
+	filter.Limit = int(parseInt("limit"))
+	filter.Cursor = parseInt("cursor")
+	filter.From = parseTime("from")
+	filter.To = parseTime("to")
+	if err != nil {
+		problem.Write(r.Context(), w, r, problem.BadRequest(err.Error()))
+		return
+	}
+
+	page, err := h.TemplateData.History.List(r.Context(), filter)
+	if err != nil {
+		log.Printf("Erro ao consultar o histórico: %s", err)
+		problem.Write(r.Context(), w, r, problem.Internal("failed to query the lookup history"))
+		return
+	}
+	if page.NextCursor != 0 {
+		w.Header().Set("X-Next-Cursor", strconv.FormatInt(page.NextCursor, 10))
+	}
+	render.Write(w, r, http.StatusOK, page)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/auth"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/history"
+	"go.opentelemetry.io/otel"
+)
+
+// As consultas do POST /cep devem ser registradas e retornadas pelo GET /history
+func TestHistorico(t *testing.T) {
+	repo, err := history.NewSQLiteRepository(filepath.Join(t.TempDir(), "history.db"), otel.Tracer("test"))
+	require.NoError(t, err)
+	defer repo.Close()
+
+	keys, err := auth.NewAPIKeys([]auth.APIKey{{ClientID: "app-teste", SHA256: auth.HashKey("chave-teste"), Scopes: []string{auth.ScopeAdmin}}})
+	require.NoError(t, err)
+	server := NewServer(&TemplateData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ServiceBClient:  &streamClient{temp: 28.5},
+		History:         repo,
+		Authenticator:   &auth.Authenticator{APIKeys: keys},
+	})
+	router := server.CreateServer()
+	serve := func(w http.ResponseWriter, req *http.Request) {
+		req.Header.Set(auth.APIKeyHeader, "chave-teste")
+		router.ServeHTTP(w, req)
+	}
+
+	// O cliente é o autenticado, mesmo que a requisição informe outra identificação
+	for _, cep := range []string{"32450000", "99999999", "123"} {
+		req := requisicaoCep(`{"cep": "` + cep + `"}`)
+		req.Header.Set("X-Client-ID", "outro-cliente")
+		serve(httptest.NewRecorder(), req)
+	}
+
+	w := httptest.NewRecorder()
+	serve(w, httptest.NewRequest(http.MethodGet, "/history?client_id=app-teste&limit=2", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var page history.Page
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, "123", page.Items[0].Cep)
+	assert.Equal(t, http.StatusUnprocessableEntity, page.Items[0].Status)
+	assert.Equal(t, "99999999", page.Items[1].Cep)
+	assert.Equal(t, http.StatusNotFound, page.Items[1].Status)
+	assert.NotZero(t, page.NextCursor)
+	assert.Equal(t, strconv.FormatInt(page.NextCursor, 10), w.Header().Get("X-Next-Cursor"))
+
+	// Mesma página em CSV, um registro por linha
+	req := httptest.NewRequest(http.MethodGet, "/history?client_id=app-teste&limit=2", nil)
+	req.Header.Set("Accept", "text/csv")
+	w = httptest.NewRecorder()
+	serve(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, strconv.FormatInt(page.NextCursor, 10), w.Header().Get("X-Next-Cursor"))
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, []string{"id", "cep", "city", "temp_C", "temp_F", "temp_K", "status", "latency_ms", "trace_id", "client_id", "created_at"}, rows[0])
+	assert.Equal(t, []string{"123", "", "", "", "", "422"}, rows[1][1:7])
+	assert.Equal(t, "app-teste", rows[1][9])
+
+	w = httptest.NewRecorder()
+	serve(w, httptest.NewRequest(http.MethodGet, "/history?status=200", nil))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "Ibirité", page.Items[0].City)
+	assert.Equal(t, 28.5, *page.Items[0].TempC)
+	assert.Equal(t, "app-teste", page.Items[0].ClientID)
+
+	w = httptest.NewRecorder()
+	serve(w, httptest.NewRequest(http.MethodGet, "/history?from=ontem", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// Sem repositório, o histórico fica desabilitado
+func TestHistoricoDesabilitado(t *testing.T) {
+	server := NewServer(&TemplateData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ServiceBClient:  &streamClient{},
+	})
+	w := httptest.NewRecorder()
+	server.CreateServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
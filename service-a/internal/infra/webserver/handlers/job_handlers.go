@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/render"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/job"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/ratelimit"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Valores padrão dos jobs assíncronos
+const (
+	DefaultJobWorkers   = 8
+	DefaultJobMaxCeps   = 10000
+	DefaultJobRetention = time.Hour
+	// Limites de jobs armazenados (em andamento e finalizados dentro da retenção) e de jobs pendentes
+	DefaultJobMaxJobs    = 1000
+	DefaultJobMaxPending = 100
+)
+
+// Intervalo sugerido no header Retry-After quando a fila de jobs está cheia
+const jobRetryAfter = 5 * time.Second
+
+// Tamanho máximo do corpo da requisição de criação do job
+const maxJobBodySize = 1 << 20
+
+// Struct que será utilizada para receber os CEPs do job. O país é opcional (padrão BR) e vale
+// para todos os códigos postais do job.
+type JobRequest struct {
+	Ceps    []string `json:"ceps"`
+	Country string   `json:"country,omitempty"`
+}
+
+// Função que cria o job de consulta dos CEPs e retorna o seu ID.
+// O processamento acontece em segundo plano, fora do timeout da requisição.
+func (h *Webserver) CriaJobHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := h.TemplateData.OTELTracer.Start(r.Context(), "Início Job "+h.TemplateData.RequestNameOTEL)
+	defer span.End()
+
+	var req JobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJobBodySize)).Decode(&req); err != nil {
+		problem.Write(ctx, w, r, problem.BadRequest(`the request body must be a JSON object like {"ceps": ["32450000", "01001000"]}`))
+		return
+	}
+	if len(req.Ceps) == 0 || len(req.Ceps) > h.TemplateData.JobMaxCeps {
+		problem.Write(ctx, w, r, problem.BadRequest("").Detailf("the job must contain between 1 and %d zipcodes", h.TemplateData.JobMaxCeps))
+		return
+	}
+	pais, err := cep.NormalizeCountry(req.Country)
+	if err != nil {
+		problem.Write(ctx, w, r, problemaCepInvalido(err))
+		return
+	}
+	// Cada CEP do job consome um token do rate limit do cliente
+	if !ratelimit.Charge(w, r.WithContext(ctx), len(req.Ceps)) {
+		span.SetStatus(codes.Error, "rate limit exceeded")
+		return
+	}
+
+	created, err := h.Jobs.Submit(ctx, pais, req.Ceps)
+	switch {
+	case errors.Is(err, job.ErrTooManyPending):
+		w.Header().Set("Retry-After", strconv.Itoa(int(jobRetryAfter.Seconds())))
+		problem.Write(ctx, w, r, problem.TooManyRequests(err.Error()))
+		return
+	case errors.Is(err, job.ErrFull):
+		problem.Write(ctx, w, r, problem.ServiceUnavailable(err.Error()))
+		return
+	case err != nil:
+		problem.Write(ctx, w, r, problem.ServiceUnavailable("the server is shutting down"))
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+created.ID)
+	render.Write(w, r, http.StatusAccepted, created)
+}
+
+// Função que retorna o progresso do job
+func (h *Webserver) BuscaJobHandler(w http.ResponseWriter, r *http.Request) {
+	found, err := h.Jobs.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		problem.Write(r.Context(), w, r, problem.NotFound(err.Error()))
+		return
+	}
+	render.Write(w, r, http.StatusOK, found)
+}
+
+// Função que retorna os resultados do job no formato negociado: JSON, XML ou, com ?format=csv ou
+// Accept: text/csv, em CSV para download. Os resultados só ficam disponíveis depois que o job termina.
+func (h *Webserver) ResultadosJobHandler(w http.ResponseWriter, r *http.Request) {
+	found, results, err := h.Jobs.Results(chi.URLParam(r, "id"))
+	if errors.Is(err, job.ErrNotFound) {
+		problem.Write(r.Context(), w, r, problem.NotFound(err.Error()))
+		return
+	}
+	if found.Status == job.StatusQueued || found.Status == job.StatusRunning {
+		problem.Write(r.Context(), w, r, problem.Conflict("").Detailf("the job is %s: %d of %d zipcodes processed", found.Status, found.Processed, found.Total))
+		return
+	}
+
+	if format, err := render.Negotiate(r, results); err == nil && format.Name == render.CSV.Name {
+		w.Header().Set("Content-Disposition", `attachment; filename="job-`+found.ID+`.csv"`)
+	}
+	render.Write(w, r, http.StatusOK, results)
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/job"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/ratelimit"
+	"go.opentelemetry.io/otel"
+)
+
+// Criação do job, consulta do progresso e dos resultados em JSON e CSV
+func TestJobs(t *testing.T) {
+	server := NewServer(&TemplateData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ServiceBClient:  &streamClient{temp: 28.5},
+		JobMaxCeps:      3,
+	})
+	defer server.Jobs.Stop()
+	router := server.CreateServer()
+
+	do := func(method, path, body string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if len(header) == 2 {
+			req.Header.Set(header[0], header[1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/jobs", `{"ceps": ["32450000", "99999999", "123"]}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	var created job.Job
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "/jobs/"+created.ID, w.Header().Get("Location"))
+	assert.Equal(t, 3, created.Total)
+
+	var status job.Job
+	for i := 0; i < 200 && status.Status != job.StatusDone; i++ {
+		time.Sleep(5 * time.Millisecond)
+		w = do(http.MethodGet, "/jobs/"+created.ID, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	}
+	assert.Equal(t, job.StatusDone, status.Status)
+	assert.Equal(t, 1, status.Succeeded)
+	assert.Equal(t, 2, status.Failed)
+
+	w = do(http.MethodGet, "/jobs/"+created.ID+"/results", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var results []job.Result
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	require.Len(t, results, 3)
+	assert.Equal(t, 28.5, results[0].Clima.TempC)
+	assert.Equal(t, http.StatusNotFound, results[1].Error.Status)
+	assert.Equal(t, http.StatusUnprocessableEntity, results[2].Error.Status)
+
+	w = do(http.MethodGet, "/jobs/"+created.ID+"/results", "", "Accept", "text/csv")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="job-`+created.ID+`.csv"`, w.Header().Get("Content-Disposition"))
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"cep", "city", "temp_C", "temp_F", "temp_K", "status", "error"},
+		{"32450000", "Ibirité", "28.5", "0", "0", "200", ""},
+		{"99999999", "", "", "", "", "404", "can not find zipcode"},
+		{"123", "", "", "", "", "422", "invalid zipcode"},
+	}, rows)
+
+	// Resultados em XML, sem download
+	w = do(http.MethodGet, "/jobs/"+created.ID+"/results", "", "Accept", "application/xml")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), "<item><cep>32450000</cep><result><city>Ibirité</city>")
+
+	// Os resultados não possuem representação em protobuf
+	w = do(http.MethodGet, "/jobs/"+created.ID+"/results?format=protobuf", "")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	w = do(http.MethodGet, "/jobs/inexistente", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	w = do(http.MethodGet, "/jobs/inexistente/results", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Quantidade de CEPs fora do limite
+	w = do(http.MethodPost, "/jobs", `{"ceps": []}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = do(http.MethodPost, "/jobs", `{"ceps": ["1", "2", "3", "4"]}`, "Accept-Language", "pt-BR")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "o job deve conter entre 1 e 3 CEPs")
+	w = do(http.MethodPost, "/jobs", `{`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// O país vale para todos os códigos postais do job
+	w = do(http.MethodPost, "/jobs", `{"ceps": ["1000-001"], "country": "pt"}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "PT", created.Country)
+	w = do(http.MethodPost, "/jobs", `{"ceps": ["32450000"], "country": "US"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "AR, BR, PT")
+}
+
+// Com a fila de jobs cheia, novos jobs recebem 429 com o header Retry-After
+func TestJobsLimitePendentes(t *testing.T) {
+	client := &streamClient{temp: 28.5}
+	server := NewServer(&TemplateData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ServiceBClient:  client,
+		JobMaxPending:   1,
+	})
+	defer server.Jobs.Stop()
+	router := server.CreateServer()
+
+	// O lock do client segura a consulta, mantendo o primeiro job pendente
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	post := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"ceps": ["32450000"]}`)))
+		return w
+	}
+	w := post()
+	require.Equal(t, http.StatusAccepted, w.Code)
+	location := w.Header().Get("Location")
+	w = post()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Retry-After"))
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+
+	// Os resultados do job pendente não estão disponíveis, com a mensagem no idioma negociado
+	req := httptest.NewRequest(http.MethodGet, location+"/results", nil)
+	req.Header.Set("Accept-Language", "es")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Regexp(t, `el job está (queued|running): 0 de 1 códigos postales procesados`, w.Body.String())
+}
+
+// Com o rate limit habilitado, cada CEP do job consome um token do plano do cliente
+func TestJobsRateLimitPorCep(t *testing.T) {
+	server := NewServer(&TemplateData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ServiceBClient:  &streamClient{temp: 28.5},
+		RateLimiter:     ratelimit.NewMemory(),
+		RateLimitPlans:  map[string]ratelimit.Plan{ratelimit.DefaultPlan: {Name: ratelimit.DefaultPlan, Limit: 4, Window: time.Minute}},
+	})
+	defer server.Jobs.Stop()
+	router := server.CreateServer()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(body))
+		req.Header.Set("Accept-Language", "pt-BR")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"ceps": ["1", "2", "3", "4", "5"]}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, w.Body.String(), "a requisição contém 5 CEPs e o plano anonymous permite no máximo 4 de uma vez")
+
+	w = post(`{"ceps": ["32450000", "01001000", "20040020"]}`)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = post(`{"ceps": ["32450000"]}`)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+}
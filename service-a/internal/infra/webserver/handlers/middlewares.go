@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/bootstrap"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/realip"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/webserver/httpmw"
+)
+
+// Middlewares aplicados a todas as rotas, na ordem de execução. Além dos middlewares comuns
+// aos serviços (bootstrap.Middlewares), o service-a possui security, cors e compress. O realip
+// comum é substituído pelo que aceita os headers dos proxies configurados.
+var DefaultMiddlewares = []string{"requestid", "realip", "recoverer", "logger", "deadline", "language", "security", "cors", "compress"}
+
+// Configuração padrão do CORS. Sem origens configuradas, o CORS fica desabilitado.
+const (
+	DefaultCORSAllowedMethods = "GET,POST,DELETE,OPTIONS"
+	DefaultCORSAllowedHeaders = "Accept,Content-Type,Authorization,X-API-Key,X-Request-Timeout-Ms,X-API-Version"
+	DefaultCORSExposedHeaders = "Location,Retry-After,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,RateLimit-Policy,X-Request-Id,X-Next-Cursor,Content-Disposition,X-API-Version,Deprecation,Link"
+	DefaultCORSMaxAge         = 10 * time.Minute
+)
+
+// Duração padrão do HSTS, enviado somente nas requisições HTTPS
+const DefaultHSTSMaxAge = 365 * 24 * time.Hour
+
+// Nível padrão da compressão gzip e brotli das respostas
+const DefaultCompressionLevel = 5
+
+// Timeout padrão das rotas da API. As rotas que dependem de várias consultas ao service-b
+// possuem um timeout maior, no formato aceito pelo httpmw.ParseRouteTimeouts.
+const (
+	DefaultRouteTimeout  = 15 * time.Second
+	DefaultRouteTimeouts = "/graphql=30s"
+)
+
+// Middlewares do service-a, pelo nome utilizado na lista de middlewares
+func (we *Webserver) middlewares() map[string]func(http.Handler) http.Handler {
+	return map[string]func(http.Handler) http.Handler{
+		"security": httpmw.Security(httpmw.SecurityConfig{
+			HSTSMaxAge: we.TemplateData.HSTSMaxAge,
+			DocsPaths:  []string{"/docs"},
+		}),
+		"cors":     httpmw.CORS(we.TemplateData.CORS),
+		"compress": httpmw.Compress(we.TemplateData.CompressionLevel),
+		"realip":   realip.Middleware(we.TemplateData.TrustedProxies),
+	}
+}
+
+// Cria o router com a lista de middlewares configurada. Um nome desconhecido é um erro de
+// configuração, então o router não é criado.
+func (we *Webserver) newRouter() *chi.Mux {
+	router, err := bootstrap.NewRouterWith(we.TemplateData.Middlewares, we.middlewares())
+	if err != nil {
+		panic(err)
+	}
+	return router
+}
+
+// Função que valida a lista de middlewares, permitindo identificar o erro na inicialização
+func ValidateMiddlewares(names []string) error {
+	_, err := bootstrap.NewRouterWith(names, (&Webserver{TemplateData: &TemplateData{}}).middlewares())
+	return err
+}
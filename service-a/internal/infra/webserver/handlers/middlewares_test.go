@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/auth"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/webserver/httpmw"
+	"go.opentelemetry.io/otel"
+)
+
+// O preflight do CORS é respondido antes da autenticação e as respostas recebem os headers
+// de segurança e a compressão.
+func TestCreateServerMiddlewares(t *testing.T) {
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+	}))
+	defer serverMock.Close()
+
+	keys, err := auth.NewAPIKeys([]auth.APIKey{{ClientID: "app-web", SHA256: auth.HashKey("chave-web"), Scopes: []string{auth.ScopeCurrent}}})
+	assert.NoError(t, err)
+	router := NewServer(&TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		Authenticator:   &auth.Authenticator{APIKeys: keys},
+		CORS: httpmw.CORSConfig{
+			AllowedOrigins: []string{"https://app.example.com"},
+			AllowedMethods: httpmw.SplitList(DefaultCORSAllowedMethods),
+			AllowedHeaders: httpmw.SplitList(DefaultCORSAllowedHeaders),
+			ExposedHeaders: httpmw.SplitList(DefaultCORSExposedHeaders),
+		},
+	}).CreateServer()
+
+	// Preflight sem chave de API
+	req := httptest.NewRequest(http.MethodOptions, "/cep", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type, X-API-Key")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Requisição do navegador, com a chave de API
+	req = requisicaoCep(`{"cep": "32450000"}`)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set(auth.APIKeyHeader, "chave-web")
+	req.Header.Set("Accept-Encoding", "gzip")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Retry-After")
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	// Página de documentação, com a CSP que permite o Swagger UI
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, httpmw.DocsContentSecurityPolicy, w.Header().Get("Content-Security-Policy"))
+}
+
+// A lista de middlewares é configurável e nomes desconhecidos são rejeitados
+func TestCreateServerListaMiddlewares(t *testing.T) {
+	router := NewServer(&TemplateData{
+		OTELTracer:  otel.Tracer("microservice-tracer-mock"),
+		Middlewares: []string{"recoverer"},
+	}).CreateServer()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-Content-Type-Options"))
+
+	assert.NoError(t, ValidateMiddlewares(DefaultMiddlewares))
+	err := ValidateMiddlewares(strings.Split("requestid,gzip", ","))
+	assert.ErrorContains(t, err, `unknown middleware "gzip"`)
+	assert.Panics(t, func() {
+		NewServer(&TemplateData{OTELTracer: otel.Tracer("microservice-tracer-mock"), Middlewares: []string{"gzip"}}).CreateServer()
+	})
+}
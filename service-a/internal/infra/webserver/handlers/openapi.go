@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+	v1 "github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion/v1"
+	v2 "github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion/v2"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/openapi"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/render"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/alert"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/auth"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/history"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/job"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/webserver/graphqlapi"
+)
+
+// Função que monta o documento OpenAPI do service-a a partir dos tipos utilizados pelos handlers.
+// Toda rota registrada no CreateServer deve estar descrita aqui (ver TestOpenAPIRotas).
+func (we *Webserver) OpenAPI() *openapi.Document {
+	doc := openapi.New(
+		"service-a",
+		"Recebe o CEP, valida o formato e consulta a temperatura da cidade no service-b.",
+		"1.0.0",
+	)
+	doc.Servers = []openapi.Server{{URL: "http://localhost:8181", Description: "Ambiente local"}}
+
+	dadosCep := doc.AddSchema("DadosCep", DadosCep{})
+	clima := doc.AddSchema("ClimaCidade", ClimaCidade{})
+	erro := doc.AddSchema("Problem", problem.Details{})
+
+	// Formato da resposta, negociado no header Accept ou informado no parâmetro format
+	formatParam := openapi.Parameter{Name: "format", In: "query", Description: "Formato da resposta, com prioridade sobre o header Accept: json (padrão), xml, csv ou protobuf. O csv não está disponível em todas as rotas.", Schema: &openapi.Schema{Type: "string", Enum: []string{render.JSON.Name, render.XML.Name, render.CSV.Name, render.Protobuf.Name}}}
+	notAcceptable := func(example any) *openapi.Response {
+		var formatos []string
+		for _, format := range render.Available(example) {
+			formatos = append(formatos, format.MediaTypes[0])
+		}
+		return problemResponse("Formato da resposta não suportado", erro, problem.NotAcceptable("the response is available as "+strings.Join(formatos, ", ")))
+	}
+
+	// Contrato da resposta do /cep em cada versão da API
+	contratos := map[string]*openapi.Schema{
+		apiversion.V1: doc.AddSchema("ClimaCidadeV1", v1.ClimaCidade{}),
+		apiversion.V2: doc.AddSchema("ClimaCidadeV2", v2.ClimaCidade{}),
+	}
+
+	// Respostas comuns às duas formas de consulta do CEP, no contrato da versão informada
+	cepResponses := func(version, badRequest string) map[string]*openapi.Response {
+		example := apiversion.ClimaCidade(apiversion.WithVersion(context.Background(), version), &ClimaCidade{Cidade: "Ibirité", TempC: 28.5, TempF: 83.3, TempK: 301.5, Condicao: "Sunny"})
+		return map[string]*openapi.Response{
+			"200": formatResponse("Temperatura da cidade", contratos[version], example),
+			"406": notAcceptable(example),
+			"400": problemResponse("Requisição inválida", erro, problem.BadRequest(badRequest)),
+			"404": problemResponse("CEP não encontrado", erro, problem.ZipcodeNotFound("zipcode 99999999 does not exist")),
+			"422": problemResponse("CEP com formato inválido", erro, problem.InvalidZipcode("the zipcode must contain exactly 8 digits")),
+			"502": problemResponse("Resposta inválida do service-b", erro, problem.BadGateway("service-b responded with status 500")),
+			"503": problemResponse("Service-b indisponível", erro, problem.ServiceUnavailable("service-b is unavailable")),
+			"504": problemResponse("Service-b não respondeu a tempo", erro, problem.GatewayTimeout("service-b did not respond in time")),
+		}
+	}
+
+	// País do código postal, informado na query string
+	countryQuery := openapi.Parameter{Name: "country", In: "query", Description: "País do código postal (ISO 3166-1 alpha-2). Padrão: BR.", Schema: &openapi.Schema{Type: "string", Enum: cep.Countries()}, Example: cep.DefaultCountry}
+
+	// Idioma negociado no Accept-Language, repassado ao service-b
+	acceptLanguage := openapi.Parameter{Name: "Accept-Language", In: "header", Description: "Idioma das mensagens de erro e da condição do tempo (pt-BR, en ou es). Padrão: en.", Schema: &openapi.Schema{Type: "string"}, Example: i18n.PortugueseBR}
+	// Versão escolhida no header, somente nas rotas sem versão
+	versionHeader := openapi.Parameter{Name: apiversion.Header, In: "header", Description: "Versão do contrato da resposta (v1 ou v2). Padrão: v1.", Schema: &openapi.Schema{Type: "string", Enum: apiversion.Versions()}, Example: apiversion.V2}
+
+	// Operações POST e GET do /cep. Nas rotas versionadas (/v1/cep e /v2/cep), a versão é a da
+	// rota; a rota /cep é obsoleta quando a versão não é informada no header X-API-Version.
+	cepOperations := func(path, version string) {
+		summary, suffix, description := " ("+version+")", strings.ToUpper(version), ""
+		params := []openapi.Parameter{acceptLanguage, formatParam}
+		if path == "/cep" {
+			summary, suffix = "", ""
+			description = " Rota obsoleta: sem o header X-API-Version, utiliza a v1 e responde com os headers Deprecation e Link (rota /v1/cep)."
+			params = append(params, versionHeader)
+		}
+
+		postCepResponses := cepResponses(version, `the field "uf" is not allowed, expected a JSON object like {"cep": "29902555"}`)
+		postCepResponses["413"] = problemResponse("Body maior que o limite aceito", erro, problem.PayloadTooLarge("the request body must have at most 1024 bytes"))
+		postCepResponses["415"] = problemResponse("Body sem o Content-Type application/json", erro, problem.UnsupportedMediaType("the request body must be sent with Content-Type: application/json"))
+		doc.AddOperation(http.MethodPost, path, &openapi.Operation{
+			Summary:     "Consulta a temperatura atual da cidade do CEP" + summary,
+			Description: "O body deve conter um único objeto JSON, sem campos desconhecidos, enviado com o Content-Type application/json." + description,
+			OperationID: "buscaTemperatura" + suffix,
+			Tags:        []string{"temperatura"},
+			Parameters:  params,
+			RequestBody: &openapi.RequestBody{
+				Description: "CEP com 8 dígitos, informado como string nos formatos 32450000, 32450-000 ou 32.450-000. Com o campo country (BR, PT ou AR), o código postal segue o formato do país: 1234-567 em PT e C1425ABC ou 4 dígitos em AR.",
+				Required:    true,
+				Content: map[string]*openapi.MediaType{
+					"application/json": {Schema: dadosCep, Example: DadosCep{Cep: "32450000"}},
+				},
+			},
+			Responses:  postCepResponses,
+			Deprecated: path == "/cep",
+		})
+
+		doc.AddOperation(http.MethodGet, path, &openapi.Operation{
+			Summary:     "Consulta a temperatura atual da cidade do CEP informado na URL" + summary,
+			Description: strings.TrimSpace(description),
+			OperationID: "buscaTemperaturaQuery" + suffix,
+			Tags:        []string{"temperatura"},
+			Parameters: append([]openapi.Parameter{
+				{Name: "cep", In: "query", Description: "CEP com 8 dígitos, nos formatos 32450000, 32450-000 ou 32.450-000, ou o código postal no formato do país", Required: true, Schema: &openapi.Schema{Type: "string"}, Example: "32450000"},
+				countryQuery,
+			}, params...),
+			Responses:  cepResponses(version, "the cep query parameter is required, like /cep?cep=29902555"),
+			Deprecated: path == "/cep",
+		})
+	}
+	cepOperations("/cep", apiversion.Default)
+	for _, version := range apiversion.Versions() {
+		cepOperations("/"+version+"/cep", version)
+	}
+
+	cepPath := openapi.Parameter{Name: "cep", In: "path", Description: "CEP com 8 dígitos, nos formatos 32450000, 32450-000 ou 32.450-000, ou o código postal no formato do país", Required: true, Schema: &openapi.Schema{Type: "string"}, Example: "32450000"}
+	streamErrors := map[string]*openapi.Response{
+		"404": problemResponse("CEP não encontrado", erro, problem.ZipcodeNotFound("zipcode 99999999 does not exist")),
+		"422": problemResponse("CEP com formato inválido", erro, problem.InvalidZipcode("the zipcode must contain exactly 8 digits")),
+		"502": problemResponse("Resposta inválida do service-b", erro, problem.BadGateway("service-b responded with status 500")),
+		"503": problemResponse("Service-b indisponível ou servidor em shutdown", erro, problem.ServiceUnavailable("service-b is unavailable")),
+		"504": problemResponse("Service-b não respondeu a tempo", erro, problem.GatewayTimeout("service-b did not respond in time")),
+	}
+
+	sseResponses := map[string]*openapi.Response{
+		"200": {
+			Description: "Stream de eventos. O evento temperature traz a ClimaCidade atual e é reenviado sempre que a temperatura mudar. " +
+				"Comentários de heartbeat são enviados periodicamente e o evento close indica o encerramento do servidor.",
+			Content: map[string]*openapi.MediaType{
+				"text/event-stream": {Schema: clima, Example: "event: temperature\ndata: {\"city\":\"Ibirité\",\"temp_C\":28.5,\"temp_F\":83.3,\"temp_K\":301.5}\n\n"},
+			},
+		},
+	}
+	for code, response := range streamErrors {
+		sseResponses[code] = response
+	}
+	doc.AddOperation(http.MethodGet, "/cep/{cep}/stream", &openapi.Operation{
+		Summary:     "Acompanha a temperatura da cidade do CEP via Server-Sent Events",
+		OperationID: "streamTemperatura",
+		Tags:        []string{"temperatura"},
+		Parameters:  []openapi.Parameter{cepPath, countryQuery},
+		Responses:   sseResponses,
+	})
+
+	wsResponses := map[string]*openapi.Response{
+		"101": {Description: "Conexão WebSocket. Cada mensagem tem o formato {\"event\": \"temperature\", \"data\": ClimaCidade}; o heartbeat utiliza frames de ping."},
+		"400": problemResponse("Requisição sem upgrade para WebSocket", erro, problem.BadRequest("this endpoint requires a WebSocket upgrade")),
+	}
+	for code, response := range streamErrors {
+		wsResponses[code] = response
+	}
+	doc.AddOperation(http.MethodGet, "/cep/{cep}/ws", &openapi.Operation{
+		Summary:     "Acompanha a temperatura da cidade do CEP via WebSocket",
+		OperationID: "streamTemperaturaWebSocket",
+		Tags:        []string{"temperatura"},
+		Parameters:  []openapi.Parameter{cepPath, countryQuery},
+		Responses:   wsResponses,
+	})
+
+	alertaRequest := doc.AddSchema("AlertaRequest", AlertaRequest{})
+	alerta := doc.AddSchema("AlertSubscription", alert.Subscription{})
+	doc.AddSchema("AlertEvent", alert.Event{})
+	deadLetter := doc.AddSchema("AlertDeadLetter", alert.DeadLetter{})
+	alertaID := openapi.Parameter{Name: "id", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}}
+	alertaExample := AlertaRequest{Cep: "32450000", Direction: alert.DirectionAbove, URL: "https://example.com/hook"}
+	alertaBody := &openapi.RequestBody{
+		Description: "Assinatura do alerta. O threshold é a temperatura em Celsius e a direction pode ser above ou below. O country (BR, PT ou AR, padrão BR) define o formato do código postal.",
+		Required:    true,
+		Content: map[string]*openapi.MediaType{
+			"application/json": {Schema: alertaRequest, Example: alertaExample},
+		},
+	}
+	alertaInvalido := problemResponse("Assinatura inválida", erro, problem.BadRequest(alert.ErrInvalidDirection.Error()))
+	alertaCepInvalido := problemResponse("CEP com formato inválido", erro, problem.InvalidZipcode("the zipcode must contain exactly 8 digits"))
+	alertaNaoEncontrado := problemResponse("Assinatura não encontrada", erro, problem.NotFound(alert.ErrNotFound.Error()))
+
+	doc.AddOperation(http.MethodPost, "/alerts", &openapi.Operation{
+		Summary: "Cria uma assinatura de alerta de temperatura",
+		Description: "Quando a temperatura do CEP cruzar o threshold na direção informada, um POST com o AlertEvent é enviado para a url. " +
+			"O corpo é assinado com HMAC-SHA256 no header X-Webhook-Signature (sha256=hex(hmac(secret, X-Webhook-Timestamp + \".\" + corpo))). " +
+			"O secret é gerado quando não informado e retornado somente nesta resposta.",
+		OperationID: "criaAlerta",
+		Tags:        []string{"alertas"},
+		RequestBody: alertaBody,
+		Responses: map[string]*openapi.Response{
+			"201": formatResponse("Assinatura criada", alerta, nil),
+			"400": alertaInvalido,
+			"422": alertaCepInvalido,
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/alerts", &openapi.Operation{
+		Summary:     "Lista as assinaturas de alerta",
+		OperationID: "listaAlertas",
+		Tags:        []string{"alertas"},
+		Responses: map[string]*openapi.Response{
+			"200": formatResponse("Assinaturas cadastradas", &openapi.Schema{Type: "array", Items: alerta}, nil),
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/alerts/{id}", &openapi.Operation{
+		Summary:     "Busca uma assinatura de alerta",
+		OperationID: "buscaAlerta",
+		Tags:        []string{"alertas"},
+		Parameters:  []openapi.Parameter{alertaID},
+		Responses: map[string]*openapi.Response{
+			"200": formatResponse("Assinatura", alerta, nil),
+			"404": alertaNaoEncontrado,
+		},
+	})
+	doc.AddOperation(http.MethodPut, "/alerts/{id}", &openapi.Operation{
+		Summary:     "Atualiza uma assinatura de alerta",
+		OperationID: "atualizaAlerta",
+		Tags:        []string{"alertas"},
+		Parameters:  []openapi.Parameter{alertaID},
+		RequestBody: alertaBody,
+		Responses: map[string]*openapi.Response{
+			"200": formatResponse("Assinatura atualizada", alerta, nil),
+			"400": alertaInvalido,
+			"404": alertaNaoEncontrado,
+			"422": alertaCepInvalido,
+		},
+	})
+	doc.AddOperation(http.MethodDelete, "/alerts/{id}", &openapi.Operation{
+		Summary:     "Remove uma assinatura de alerta",
+		OperationID: "removeAlerta",
+		Tags:        []string{"alertas"},
+		Parameters:  []openapi.Parameter{alertaID},
+		Responses: map[string]*openapi.Response{
+			"204": {Description: "Assinatura removida"},
+			"404": alertaNaoEncontrado,
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/alerts/dead-letters", &openapi.Operation{
+		Summary:     "Lista os webhooks que falharam em todas as tentativas",
+		OperationID: "listaFalhasAlerta",
+		Tags:        []string{"alertas"},
+		Responses: map[string]*openapi.Response{
+			"200": formatResponse("Webhooks não entregues", &openapi.Schema{Type: "array", Items: deadLetter}, nil),
+		},
+	})
+	doc.AddOperation(http.MethodPost, "/alerts/dead-letters/{deliveryID}/retry", &openapi.Operation{
+		Summary:     "Reenvia um webhook da lista de falhas",
+		OperationID: "reenviaFalhaAlerta",
+		Tags:        []string{"alertas"},
+		Parameters:  []openapi.Parameter{{Name: "deliveryID", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}}},
+		Responses: map[string]*openapi.Response{
+			"202": {Description: "Webhook removido da lista de falhas e enviado novamente"},
+			"404": problemResponse("Webhook não encontrado na lista de falhas", erro, problem.NotFound(alert.ErrDeadLetterNotFound.Error())),
+		},
+	})
+
+	jobRequest := doc.AddSchema("JobRequest", JobRequest{})
+	jobSchema := doc.AddSchema("Job", job.Job{})
+	jobResult := doc.AddSchema("JobResult", job.Result{})
+	jobID := openapi.Parameter{Name: "id", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}}
+	jobNaoEncontrado := problemResponse("Job não encontrado", erro, problem.NotFound(job.ErrNotFound.Error()))
+
+	doc.AddOperation(http.MethodPost, "/jobs", &openapi.Operation{
+		Summary:     "Cria um job assíncrono de consulta de CEPs",
+		Description: "Os CEPs são processados em segundo plano por um pool de workers. O progresso é consultado em /jobs/{id}. O country (BR, PT ou AR, padrão BR) vale para todos os códigos postais do job.",
+		OperationID: "criaJob",
+		Tags:        []string{"jobs"},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]*openapi.MediaType{
+				"application/json": {Schema: jobRequest, Example: JobRequest{Ceps: []string{"32450000", "01001000"}}},
+			},
+		},
+		Responses: map[string]*openapi.Response{
+			"202": formatResponse("Job criado. O header Location aponta para o job.", jobSchema, nil),
+			"400": problemResponse("Body inválido ou quantidade de CEPs fora do limite", erro, problem.BadRequest("the job must contain between 1 and 10000 zipcodes")),
+			"422": problemResponse("País não suportado", erro, problem.InvalidZipcode("the country must be one of AR, BR, PT")),
+			"429": problemResponse("Limite de requisições do plano ou de jobs pendentes excedido. O header Retry-After informa quando tentar novamente.", erro,
+				problem.TooManyRequests(job.ErrTooManyPending.Error())),
+			"503": problemResponse("Limite de jobs armazenados atingido ou servidor em shutdown", erro, problem.ServiceUnavailable(job.ErrFull.Error())),
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/jobs/{id}", &openapi.Operation{
+		Summary:     "Consulta o progresso do job",
+		OperationID: "buscaJob",
+		Tags:        []string{"jobs"},
+		Parameters:  []openapi.Parameter{jobID},
+		Responses: map[string]*openapi.Response{
+			"200": formatResponse("Situação do job", jobSchema, nil),
+			"404": jobNaoEncontrado,
+		},
+	})
+	doc.AddOperation(http.MethodGet, "/jobs/{id}/results", &openapi.Operation{
+		Summary:     "Retorna os resultados do job",
+		Description: "Os resultados seguem a ordem dos CEPs enviados. Com ?format=csv ou Accept: text/csv, a resposta é um CSV para download com as colunas cep, city, temp_C, temp_F, temp_K, status e error.",
+		OperationID: "resultadosJob",
+		Tags:        []string{"jobs"},
+		Parameters: []openapi.Parameter{
+			jobID,
+			formatParam,
+		},
+		Responses: map[string]*openapi.Response{
+			"200": formatResponse("Resultados do job", &openapi.Schema{Type: "array", Items: jobResult}, job.Results{
+				{Cep: "32450000", Clima: &serviceb.ClimaCidade{Cidade: "Ibirité", TempC: 28.5, TempF: 83.3, TempK: 301.5}},
+			}),
+			"404": jobNaoEncontrado,
+			"406": notAcceptable(job.Results{}),
+			"409": problemResponse("Job ainda em processamento", erro, problem.Conflict("the job is running: 10 of 100 zipcodes processed")),
+		},
+	})
+
+	historyPage := doc.AddSchema("HistoryPage", history.Page{})
+	doc.Components.Schemas["HistoryPage"].Properties["items"].Items = doc.AddSchema("HistoryEntry", history.Entry{})
+	queryParam := func(name, description string, schema *openapi.Schema) openapi.Parameter {
+		return openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+	}
+	doc.AddOperation(http.MethodGet, "/history", &openapi.Operation{
+		Summary:     "Consulta o histórico das consultas de CEP",
+		Description: "Disponível quando o histórico está habilitado (HISTORY_DB_PATH). Os registros mais recentes são retornados primeiro.",
+		OperationID: "historico",
+		Tags:        []string{"historico"},
+		Parameters: []openapi.Parameter{
+			queryParam("cep", "CEP consultado", &openapi.Schema{Type: "string"}),
+			queryParam("city", "Cidade (sem diferenciar maiúsculas e minúsculas)", &openapi.Schema{Type: "string"}),
+			queryParam("status", "Status HTTP da resposta", &openapi.Schema{Type: "integer"}),
+			queryParam("client_id", "Identificação do cliente (cliente autenticado ou IP)", &openapi.Schema{Type: "string"}),
+			queryParam("from", "Data inicial (RFC 3339)", &openapi.Schema{Type: "string", Format: "date-time"}),
+			queryParam("to", "Data final, exclusiva (RFC 3339)", &openapi.Schema{Type: "string", Format: "date-time"}),
+			queryParam("limit", "Tamanho da página (padrão 50, máximo 500)", &openapi.Schema{Type: "integer"}),
+			queryParam("cursor", "Valor de next_cursor da página anterior (ou do header X-Next-Cursor)", &openapi.Schema{Type: "integer"}),
+			formatParam,
+		},
+		Responses: map[string]*openapi.Response{
+			"200": formatResponse("Página do histórico", historyPage, &history.Page{}),
+			"400": problemResponse("Filtro inválido", erro, problem.BadRequest("invalid value for the status parameter")),
+			"406": notAcceptable(&history.Page{}),
+			"404": problemResponse("Histórico desabilitado", erro, problem.NotFound("the lookup history is not enabled")),
+		},
+	})
+
+	graphqlRequest := doc.AddSchema("GraphQLRequest", graphqlapi.Request{})
+	graphqlResponse := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"data":   {Type: "object"},
+			"errors": {Type: "array", Items: &openapi.Schema{Type: "object"}},
+		},
+	}
+	graphqlResponses := map[string]*openapi.Response{
+		"200": jsonResponse("Resultado da consulta. Falhas de CEPs individuais são retornadas em errors, com code, status e trace_id nas extensions.", graphqlResponse, nil),
+		"400": jsonResponse("Consulta inválida ou acima dos limites de profundidade e complexidade", graphqlResponse, nil),
+	}
+	graphqlExample := `{ temperatures(ceps: ["32450000", "01001000"]) { cep city tempC condition address { state } } }`
+	doc.AddOperation(http.MethodPost, "/graphql", &openapi.Operation{
+		Summary:     "Executa uma consulta GraphQL",
+		Description: "Schema: temperature(cep: String!): Temperature e temperatures(ceps: [String!]!): [Temperature]!. Os CEPs da consulta são buscados em lote no service-b.",
+		OperationID: "graphql",
+		Tags:        []string{"graphql"},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]*openapi.MediaType{
+				"application/json": {Schema: graphqlRequest, Example: graphqlapi.Request{Query: graphqlExample}},
+			},
+		},
+		Responses: graphqlResponses,
+	})
+	doc.AddOperation(http.MethodGet, "/graphql", &openapi.Operation{
+		Summary:     "Executa uma consulta GraphQL informada na query string",
+		OperationID: "graphqlGet",
+		Tags:        []string{"graphql"},
+		Parameters: []openapi.Parameter{
+			{Name: "query", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}, Example: graphqlExample},
+			{Name: "operationName", In: "query", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "variables", In: "query", Description: "Variáveis em JSON", Schema: &openapi.Schema{Type: "string"}},
+		},
+		Responses: graphqlResponses,
+	})
+
+	// Todas as rotas da API estão sujeitas ao rate limit, exceto a documentação e as métricas
+	rateLimited := problemResponse("Limite de requisições do plano excedido. O header Retry-After informa quando tentar novamente.", erro,
+		problem.TooManyRequests("the anonymous plan allows 60 requests every 1m0s"))
+	// Com a autenticação habilitada, as mesmas rotas exigem a chave de API ou o token JWT com o escopo da rota
+	authenticated := we.TemplateData.Authenticator != nil
+	if authenticated {
+		doc.AddSecurityScheme("apiKey", &openapi.SecurityScheme{
+			Type:        "apiKey",
+			Description: "Chave de API do cliente",
+			Name:        auth.APIKeyHeader,
+			In:          "header",
+		})
+		doc.AddSecurityScheme("bearer", &openapi.SecurityScheme{
+			Type:         "http",
+			Description:  "Token JWT assinado por uma das chaves do JWKS, com os escopos no claim scope",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+		})
+	}
+	unauthorized := problemResponse("Credenciais ausentes, inválidas ou expiradas", erro,
+		problem.Unauthorized("the request must have an X-API-Key header or an Authorization: Bearer token"))
+	for path, item := range doc.Paths {
+		if path == "/openapi.json" || path == "/docs" || path == "/metrics" {
+			continue
+		}
+		scope := routeScope(path)
+		forbidden := problemResponse("Cliente sem o escopo "+scope, erro, problem.Forbidden("the "+scope+" scope is required"))
+		for _, op := range []*openapi.Operation{item.Get, item.Post, item.Put, item.Patch, item.Delete} {
+			if op == nil {
+				continue
+			}
+			if op.Responses["429"] == nil {
+				op.Responses["429"] = rateLimited
+			}
+			if authenticated {
+				op.Security = []openapi.SecurityRequirement{{"apiKey": {}}, {"bearer": {scope}}}
+				op.Responses["401"] = unauthorized
+				op.Responses["403"] = forbidden
+			}
+		}
+	}
+
+	return doc
+}
+
+// Escopo exigido pela rota, conforme os grupos do CreateServer
+func routeScope(path string) string {
+	switch {
+	case path == "/history", strings.HasPrefix(path, "/alerts/dead-letters"):
+		return auth.ScopeAdmin
+	case strings.HasPrefix(path, "/jobs"):
+		return auth.ScopeBatch
+	default:
+		return auth.ScopeCurrent
+	}
+}
+
+// Resposta de sucesso nos formatos em que o exemplo pode ser representado (ver render.Formats).
+// O exemplo é serializado em CSV; em protobuf, somente o tipo binário é documentado.
+func formatResponse(description string, schema *openapi.Schema, example any) *openapi.Response {
+	response := jsonResponse(description, schema, example)
+	for _, format := range render.Available(example) {
+		switch format.Name {
+		case render.XML.Name:
+			response.Content[format.MediaTypes[0]] = &openapi.MediaType{Schema: schema}
+		case render.CSV.Name:
+			var csv bytes.Buffer
+			format.Encode(&csv, example)
+			response.Content[format.MediaTypes[0]] = &openapi.MediaType{Schema: &openapi.Schema{Type: "string"}, Example: csv.String()}
+		case render.Protobuf.Name:
+			response.Content[format.MediaTypes[0]] = &openapi.MediaType{Schema: &openapi.Schema{Type: "string", Format: "binary"}}
+		}
+	}
+	return response
+}
+
+// Resposta de sucesso em JSON
+func jsonResponse(description string, schema *openapi.Schema, example any) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content: map[string]*openapi.MediaType{
+			"application/json": {Schema: schema, Example: example},
+		},
+	}
+}
+
+// Resposta de erro no formato application/problem+json
+func problemResponse(description string, schema *openapi.Schema, example *problem.Details) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content: map[string]*openapi.MediaType{
+			problem.ContentType: {Schema: schema, Example: example},
+		},
+	}
+}
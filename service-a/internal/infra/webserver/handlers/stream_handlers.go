@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/stream"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Intervalos padrão das assinaturas de temperatura
+const (
+	DefaultStreamPollInterval      = 30 * time.Second
+	DefaultStreamHeartbeatInterval = 15 * time.Second
+)
+
+// Mensagem enviada pelo WebSocket
+type StreamMessage struct {
+	Event string       `json:"event"`
+	Data  *ClimaCidade `json:"data,omitempty"`
+}
+
+// Upgrader do WebSocket. Por padrão, somente conexões da mesma origem são aceitas.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// Função que valida o código postal do país informado no parâmetro country da URL (vazio é o Brasil)
+// e cria a assinatura. Em caso de erro, o problema já foi escrito na resposta.
+func (h *Webserver) subscribe(w http.ResponseWriter, r *http.Request, name string) (*stream.Subscription, trace.Span, bool) {
+	ctx, span := h.TemplateData.OTELTracer.Start(r.Context(), name+" "+h.TemplateData.RequestNameOTEL)
+
+	span.SetAttributes(attribute.String("cep", chi.URLParam(r, "cep")))
+	cep, err := normalizaCodigoPostal(r.URL.Query().Get("country"), chi.URLParam(r, "cep"))
+	if err != nil {
+		span.SetStatus(codes.Error, "invalid zipcode")
+		span.End()
+		problem.Write(ctx, w, r, problemaCepInvalido(err))
+		return nil, nil, false
+	}
+
+	sub, err := h.Stream.Subscribe(ctx, cep.Country, cep.String())
+	if err != nil {
+		details := problem.ServiceUnavailable("the server is shutting down")
+		if !errors.Is(err, stream.ErrClosed) {
+			details = serviceb.ToProblem(err)
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, details.Message)
+		span.End()
+		log.Printf("Erro ao assinar a temperatura do cep %s: %s", cep, err)
+		problem.Write(ctx, w, r, details)
+		return nil, nil, false
+	}
+	return sub, span, true
+}
+
+// Função que envia a temperatura do CEP via Server-Sent Events sempre que ela mudar.
+// Um comentário de heartbeat é enviado periodicamente para manter a conexão aberta.
+func (h *Webserver) StreamTemperaturaHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		problem.Write(r.Context(), w, r, problem.Internal("streaming is not supported"))
+		return
+	}
+
+	sub, span, ok := h.subscribe(w, r, "Início Stream SSE")
+	if !ok {
+		return
+	}
+	defer span.End()
+	defer h.Stream.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.TemplateData.StreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case clima, ok := <-sub.Updates:
+			if !ok {
+				// Hub encerrado (graceful shutdown)
+				fmt.Fprint(w, "event: close\ndata: {}\n\n")
+				flusher.Flush()
+				return
+			}
+			data, _ := json.Marshal(clima)
+			fmt.Fprintf(w, "event: temperature\ndata: %s\n\n", data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+// Função que envia a temperatura do CEP via WebSocket sempre que ela mudar.
+// O heartbeat utiliza frames de ping e a conexão é encerrada com o código 1001 no graceful shutdown.
+func (h *Webserver) StreamTemperaturaWebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		problem.Write(r.Context(), w, r, problem.BadRequest("this endpoint requires a WebSocket upgrade"))
+		return
+	}
+
+	sub, span, ok := h.subscribe(w, r, "Início Stream WebSocket")
+	if !ok {
+		return
+	}
+	defer span.End()
+	defer h.Stream.Unsubscribe(sub)
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		span.RecordError(err)
+		return
+	}
+	defer conn.Close()
+
+	heartbeat := h.TemplateData.StreamHeartbeatInterval
+	conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * heartbeat))
+	})
+
+	// As mensagens do cliente são descartadas. A leitura é necessária para processar os pongs e o fechamento.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case clima, ok := <-sub.Updates:
+			if !ok {
+				msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+				conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(heartbeat))
+			if err := conn.WriteJSON(StreamMessage{Event: "temperature", Data: clima}); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(heartbeat)); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"go.opentelemetry.io/otel"
+)
+
+// Client fake do service-b com temperatura configurável
+type streamClient struct {
+	mu   sync.Mutex
+	temp float64
+	// País da última consulta
+	pais string
+}
+
+func (c *streamClient) BuscaTemperatura(ctx context.Context, pais, cep string) (*serviceb.ClimaCidade, error) {
+	if cep == "99999999" {
+		return nil, &serviceb.StatusError{StatusCode: http.StatusNotFound}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pais = pais
+	return &serviceb.ClimaCidade{Cidade: "Ibirité", TempC: c.temp}, nil
+}
+
+func (c *streamClient) setTemp(temp float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.temp = temp
+}
+
+func newStreamServer(t *testing.T, client *streamClient) (*Webserver, *httptest.Server) {
+	server := NewServer(&TemplateData{
+		RequestNameOTEL:         "microservice-tracer-mock",
+		OTELTracer:              otel.Tracer("microservice-tracer-mock"),
+		ServiceBClient:          client,
+		StreamPollInterval:      10 * time.Millisecond,
+		StreamHeartbeatInterval: 20 * time.Millisecond,
+	})
+	httpServer := httptest.NewServer(server.CreateServer())
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(server.Stream.Close)
+	return server, httpServer
+}
+
+// O SSE deve enviar a temperatura atual, as mudanças, os heartbeats e o evento de encerramento
+func TestStreamTemperaturaSSE(t *testing.T) {
+	client := &streamClient{temp: 20}
+	server, httpServer := newStreamServer(t, client)
+
+	resp, err := http.Get(httpServer.URL + "/cep/32450000/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if line == "\n" {
+				return strings.Join(lines, "\n")
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+
+	assert.Equal(t, `event: temperature`+"\n"+`data: {"city":"Ibirité","temp_C":20,"temp_F":0,"temp_K":0}`, readEvent())
+
+	client.setTemp(25)
+	var heartbeat, update bool
+	for !heartbeat || !update {
+		switch event := readEvent(); {
+		case event == ": heartbeat":
+			heartbeat = true
+		case strings.Contains(event, `"temp_C":25`):
+			update = true
+		case strings.Contains(event, `"temp_C":20`):
+			t.Fatalf("evento repetido: %s", event)
+		}
+	}
+
+	server.Stream.Close()
+	for {
+		if event := readEvent(); strings.HasPrefix(event, "event: close") {
+			break
+		}
+	}
+}
+
+// O código postal é validado e consultado no país do parâmetro country
+func TestStreamTemperaturaPais(t *testing.T) {
+	client := &streamClient{temp: 20}
+	_, httpServer := newStreamServer(t, client)
+
+	resp, err := http.Get(httpServer.URL + "/cep/1000-001/stream?country=PT")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	client.mu.Lock()
+	assert.Equal(t, "PT", client.pais)
+	client.mu.Unlock()
+}
+
+// Erros de validação e do service-b são retornados como problem+json antes de iniciar o stream
+func TestStreamTemperaturaErros(t *testing.T) {
+	_, httpServer := newStreamServer(t, &streamClient{})
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/cep/1234/stream", http.StatusUnprocessableEntity},
+		{"/cep/32450000/stream?country=US", http.StatusUnprocessableEntity},
+		{"/cep/32450000/stream?country=PT", http.StatusUnprocessableEntity},
+		{"/cep/99999999/stream", http.StatusNotFound},
+		{"/cep/1234/ws", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(httpServer.URL + tt.path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, tt.status, resp.StatusCode, tt.path)
+		assert.Equal(t, problem.ContentType, resp.Header.Get("Content-Type"), tt.path)
+	}
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+"/cep/1234/ws", nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+// O WebSocket deve enviar a temperatura atual, as mudanças, os pings e o fechamento com o código 1001
+func TestStreamTemperaturaWebSocket(t *testing.T) {
+	client := &streamClient{temp: 20}
+	server, httpServer := newStreamServer(t, client)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http")+"/cep/32450000/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	pings := make(chan struct{}, 10)
+	conn.SetPingHandler(func(data string) error {
+		select {
+		case pings <- struct{}{}:
+		default:
+		}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+
+	// A leitura contínua é necessária para responder aos pings do servidor
+	messages := make(chan StreamMessage, 10)
+	closed := make(chan error, 1)
+	go func() {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				closed <- err
+				return
+			}
+			var msg StreamMessage
+			json.Unmarshal(data, &msg)
+			messages <- msg
+		}
+	}()
+	readMessage := func() StreamMessage {
+		select {
+		case msg := <-messages:
+			return msg
+		case <-time.After(time.Second):
+			t.Fatal("nenhuma mensagem recebida")
+			return StreamMessage{}
+		}
+	}
+
+	msg := readMessage()
+	assert.Equal(t, "temperature", msg.Event)
+	assert.Equal(t, 20.0, msg.Data.TempC)
+
+	client.setTemp(25)
+	msg = readMessage()
+	assert.Equal(t, 25.0, msg.Data.TempC)
+
+	select {
+	case <-pings:
+	case <-time.After(time.Second):
+		t.Fatal("nenhum ping recebido")
+	}
+
+	server.Stream.Close()
+	select {
+	case err := <-closed:
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "erro inesperado: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("conexão não foi fechada")
+	}
+}
@@ -1,59 +1,234 @@
 package handlers
 
 import (
-	"encoding/json"
-	"io"
+	"context"
+	"errors"
 	"log"
 	"net/http"
-	"strconv"
+	"net/netip"
+	"strings"
 	"time"
 
-	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"go.opentelemetry.io/otel"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/openapi"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/render"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/telemetry"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/alert"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/auth"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/history"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/job"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/ratelimit"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/stream"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/webserver/graphqlapi"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/webserver/httpmw"
 	"go.opentelemetry.io/otel/codes"
-	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
-// Struct que será utilizada para formar a resposta com o valor das temperaturas
-type ClimaCidade struct {
-	Cidade string  `json:"city"`
-	TempC  float64 `json:"temp_C"`
-	TempF  float64 `json:"temp_F"`
-	TempK  float64 `json:"temp_K"`
-}
+// Struct que será utilizada para formar a resposta com o valor das temperaturas.
+// A resposta do service-b é repassada sem alterações.
+type ClimaCidade = serviceb.ClimaCidade
 
 // Struct que será utilizada para receber o cep do body da requisição
-type DadosCep struct {
-	Cep string `json:"cep"`
-}
+type DadosCep = domain.DadosCep
 
 // Struct para receber os dados para o webserver. A função BuscaTemperaturaHandler está anexada nessa struct. Com isso, ela terá acesso aos dados.
 type Webserver struct {
 	TemplateData *TemplateData
+	ServiceB     serviceb.TemperaturaClient
+	GraphQL      *graphqlapi.Handler
+	Stream       *stream.Hub
+	Alerts       *alert.Scheduler
+	Jobs         *job.Manager
+	// Middleware de rate limit. Quando o TemplateData não possui RateLimiter, as requisições não são limitadas.
+	RateLimit func(http.Handler) http.Handler
+	// Middleware de autenticação. Quando o TemplateData não possui Authenticator, as rotas ficam abertas.
+	Authenticate func(http.Handler) http.Handler
 }
 
-// Função que cria um novo webserver com base nos dados informados.
+// Função que cria um novo webserver com base nos dados informados. Caso nenhum client do
+// service-b seja informado, será utilizado o client HTTP apontando para o ExternalCallURL.
 func NewServer(templateData *TemplateData) *Webserver {
+	serviceB := templateData.ServiceBClient
+	if serviceB == nil {
+		serviceB = serviceb.NewClient(templateData.ExternalCallURL, templateData.HTTPClient)
+	}
+	// Hedge para outra instância do service-b e o tempo máximo de cada consulta, incluindo o hedge
+	if templateData.ServiceBHedgeClient != nil {
+		serviceB = serviceb.NewHedgedClient(serviceB, templateData.ServiceBHedgeClient, serviceb.HedgeConfig{
+			Percentile: templateData.HedgePercentile,
+			MinDelay:   templateData.HedgeMinDelay,
+			Tracer:     templateData.OTELTracer,
+		})
+	}
+	if templateData.ServiceBTimeout == 0 {
+		templateData.ServiceBTimeout = DefaultServiceBTimeout
+	}
+	serviceB = serviceb.WithTimeout(serviceB, templateData.ServiceBTimeout)
+	limits := graphqlapi.Limits{
+		MaxDepth:      templateData.GraphQLMaxDepth,
+		MaxComplexity: templateData.GraphQLMaxComplexity,
+	}
+	if limits.MaxDepth == 0 {
+		limits.MaxDepth = DefaultGraphQLMaxDepth
+	}
+	if limits.MaxComplexity == 0 {
+		limits.MaxComplexity = DefaultGraphQLMaxComplexity
+	}
+	if templateData.StreamPollInterval == 0 {
+		templateData.StreamPollInterval = DefaultStreamPollInterval
+	}
+	if templateData.StreamHeartbeatInterval == 0 {
+		templateData.StreamHeartbeatInterval = DefaultStreamHeartbeatInterval
+	}
+	if templateData.AlertInterval == 0 {
+		templateData.AlertInterval = DefaultAlertInterval
+	}
+	if templateData.AlertMaxAttempts == 0 {
+		templateData.AlertMaxAttempts = DefaultAlertMaxAttempts
+	}
+	if templateData.AlertBackoff == 0 {
+		templateData.AlertBackoff = DefaultAlertBackoff
+	}
+	alerts := alert.NewScheduler(alert.NewStore(), serviceB, templateData.OTELTracer, alert.Config{
+		Interval:    templateData.AlertInterval,
+		MaxAttempts: templateData.AlertMaxAttempts,
+		Backoff:     templateData.AlertBackoff,
+	})
+	if templateData.JobWorkers == 0 {
+		templateData.JobWorkers = DefaultJobWorkers
+	}
+	if templateData.JobMaxCeps == 0 {
+		templateData.JobMaxCeps = DefaultJobMaxCeps
+	}
+	if templateData.JobRetention == 0 {
+		templateData.JobRetention = DefaultJobRetention
+	}
+	if templateData.JobMaxJobs == 0 {
+		templateData.JobMaxJobs = DefaultJobMaxJobs
+	}
+	if templateData.JobMaxPending == 0 {
+		templateData.JobMaxPending = DefaultJobMaxPending
+	}
+	if templateData.CepMaxBodySize == 0 {
+		templateData.CepMaxBodySize = DefaultCepMaxBodySize
+	}
+	if templateData.Middlewares == nil {
+		templateData.Middlewares = DefaultMiddlewares
+	}
+	if templateData.CompressionLevel == 0 {
+		templateData.CompressionLevel = DefaultCompressionLevel
+	}
+	if templateData.RouteTimeout == 0 {
+		templateData.RouteTimeout = DefaultRouteTimeout
+	}
+	jobs := job.NewManager(serviceB, templateData.OTELTracer, job.Config{
+		Workers:    templateData.JobWorkers,
+		Retention:  templateData.JobRetention,
+		MaxJobs:    templateData.JobMaxJobs,
+		MaxPending: templateData.JobMaxPending,
+		Normalize:  normalizaCEP,
+	})
+	authenticate := func(next http.Handler) http.Handler { return next }
+	if templateData.Authenticator != nil {
+		if templateData.Authenticator.Tracer == nil {
+			templateData.Authenticator.Tracer = templateData.OTELTracer
+		}
+		authenticate = templateData.Authenticator.Middleware
+	}
+	rateLimit := func(next http.Handler) http.Handler { return next }
+	if templateData.RateLimiter != nil {
+		if templateData.RateLimitPlans == nil {
+			templateData.RateLimitPlans = map[string]ratelimit.Plan{ratelimit.DefaultPlan: DefaultRateLimitPlan}
+		}
+		rateLimit = ratelimit.Middleware(ratelimit.Config{
+			Limiter:  templateData.RateLimiter,
+			Plans:    templateData.RateLimitPlans,
+			ClientID: auth.ClientID,
+			Clients:  templateData.RateLimitClients,
+			Tracer:   templateData.OTELTracer,
+		})
+	}
 	return &Webserver{
 		TemplateData: templateData,
+		ServiceB:     serviceB,
+		GraphQL:      graphqlapi.NewHandler(serviceB, templateData.OTELTracer, limits),
+		Stream:       stream.NewHub(serviceB, templateData.OTELTracer, templateData.StreamPollInterval),
+		Alerts:       alerts,
+		Jobs:         jobs,
+		RateLimit:    rateLimit,
+		Authenticate: authenticate,
 	}
 }
 
-// Cria um novo server utilizando o chi e acrescentando alguns midlewares importantes.
+// Middleware que exige o escopo informado do cliente autenticado. Sem autenticação configurada,
+// todas as requisições são permitidas.
+func (we *Webserver) requireScope(scope string) func(http.Handler) http.Handler {
+	if we.TemplateData.Authenticator == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return auth.Require(scope)
+}
+
+// Cria um novo server utilizando o router comum aos serviços, com a lista de middlewares configurada.
 func (we *Webserver) CreateServer() *chi.Mux {
-	router := chi.NewRouter()
-
-	router.Use(middleware.RequestID)
-	router.Use(middleware.RealIP)
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.Logger)
-	router.Use(middleware.Timeout(60 * time.Second))
-	// promhttp. Usado para gerar as métricas automáticas do prometheus
-	router.Handle("/metrics", promhttp.Handler())
-	router.Post("/cep", we.BuscaTemperaturaHandler)
+	router := we.newRouter()
+	// Contexto de trace e baggage extraídos uma única vez, antes da autenticação
+	router.Use(telemetry.ExtractHTTP)
+
+	// As assinaturas ficam abertas por tempo indeterminado e não utilizam o timeout das demais rotas
+	router.Group(func(router chi.Router) {
+		router.Use(we.Authenticate, we.RateLimit, we.requireScope(auth.ScopeCurrent))
+		router.Get("/cep/{cep}/stream", we.StreamTemperaturaHandler)
+		router.Get("/cep/{cep}/ws", we.StreamTemperaturaWebSocketHandler)
+	})
+
+	router.Group(func(router chi.Router) {
+		router.Use(httpmw.RouteTimeout(we.TemplateData.RouteTimeout, we.TemplateData.RouteTimeouts))
+		// promhttp. Usado para gerar as métricas automáticas do prometheus
+		router.Handle("/metrics", promhttp.Handler())
+		// Documentação da API no formato OpenAPI e a página do Swagger UI
+		router.Get("/openapi.json", openapi.Handler(we.OpenAPI()))
+		router.Get("/docs", openapi.DocsHandler("service-a", "/openapi.json"))
+		router.Get(openapi.AssetsPath+"*", openapi.AssetsHandler())
+
+		// Rotas da API, sujeitas à autenticação e ao rate limit por cliente
+		router.Group(func(router chi.Router) {
+			router.Use(we.Authenticate, we.RateLimit)
+			router.Group(func(router chi.Router) {
+				router.Use(we.requireScope(auth.ScopeCurrent))
+				// A rota sem versão utiliza a v1, exceto quando a versão é informada no header X-API-Version
+				unversioned := apiversion.Unversioned(we.TemplateData.APIDeprecation)
+				router.With(unversioned).Post("/cep", we.BuscaTemperaturaHandler)
+				router.With(unversioned).Get("/cep", we.BuscaTemperaturaHandler)
+				for _, version := range apiversion.Versions() {
+					router.With(apiversion.Fixed(version)).Post("/"+version+"/cep", we.BuscaTemperaturaHandler)
+					router.With(apiversion.Fixed(version)).Get("/"+version+"/cep", we.BuscaTemperaturaHandler)
+				}
+				// Consultas GraphQL sobre os dados do service-b
+				router.Get("/graphql", we.GraphQL.ServeHTTP)
+				router.Post("/graphql", we.GraphQL.ServeHTTP)
+				// Assinaturas de alerta de temperatura (webhooks)
+				we.alertRoutes(router)
+			})
+			// Jobs assíncronos para listas grandes de CEPs
+			router.Group(func(router chi.Router) {
+				router.Use(we.requireScope(auth.ScopeBatch))
+				router.Post("/jobs", we.CriaJobHandler)
+				router.Get("/jobs/{id}", we.BuscaJobHandler)
+				router.Get("/jobs/{id}/results", we.ResultadosJobHandler)
+			})
+			// Histórico das consultas
+			router.With(we.requireScope(auth.ScopeAdmin)).Get("/history", we.HistoricoHandler)
+		})
+	})
 	return router
 }
 
@@ -62,8 +237,77 @@ type TemplateData struct {
 	ExternalCallURL string
 	RequestNameOTEL string
 	OTELTracer      trace.Tracer
+	HTTPClient      *http.Client
+	ServiceBClient  serviceb.TemperaturaClient
+	// Tempo máximo de cada consulta ao service-b. O prazo restante da requisição é propagado ao
+	// service-b. Quando zerado, é utilizado o DefaultServiceBTimeout.
+	ServiceBTimeout time.Duration
+	// Client do service-b utilizado no hedge, apontando para outra instância. Quando nil, o hedge
+	// fica desabilitado. O hedge é enviado após o percentil da latência, respeitando o atraso mínimo.
+	ServiceBHedgeClient serviceb.TemperaturaClient
+	HedgePercentile     float64
+	HedgeMinDelay       time.Duration
+	// Limites das consultas GraphQL. Quando zerados, são utilizados os valores padrão.
+	GraphQLMaxDepth      int
+	GraphQLMaxComplexity int
+	// Intervalo de consulta ao service-b e de heartbeat das assinaturas (SSE e WebSocket)
+	StreamPollInterval      time.Duration
+	StreamHeartbeatInterval time.Duration
+	// Intervalo de avaliação, tentativas e backoff dos webhooks de alerta
+	AlertInterval    time.Duration
+	AlertMaxAttempts int
+	AlertBackoff     time.Duration
+	// Quantidade de workers, limite de CEPs por job e retenção dos jobs finalizados
+	JobWorkers   int
+	JobMaxCeps   int
+	JobRetention time.Duration
+	// Limites de jobs armazenados e de jobs pendentes. Quando zerados, são utilizados os valores padrão.
+	JobMaxJobs    int
+	JobMaxPending int
+	// Repositório do histórico de consultas. Quando nil, o histórico fica desabilitado.
+	History history.Repository
+	// Backend do rate limit, planos e plano de cada cliente autenticado.
+	// Quando o RateLimiter é nil, o rate limit fica desabilitado. Sem planos, é utilizado o DefaultRateLimitPlan.
+	RateLimiter      ratelimit.Limiter
+	RateLimitPlans   map[string]ratelimit.Plan
+	RateLimitClients map[string]string
+	// Tamanho máximo do body do POST /cep, em bytes. Quando zerado, é utilizado o DefaultCepMaxBodySize.
+	CepMaxBodySize int64
+	// Autenticação por chave de API e token JWT. Quando nil, as rotas não exigem autenticação.
+	Authenticator *auth.Authenticator
+	// Middlewares aplicados a todas as rotas, na ordem de execução. Quando nil, é utilizado o DefaultMiddlewares.
+	Middlewares []string
+	// CORS das chamadas dos navegadores. Sem origens, os headers de CORS não são enviados.
+	CORS httpmw.CORSConfig
+	// Duração do HSTS e nível da compressão das respostas
+	HSTSMaxAge       time.Duration
+	CompressionLevel int
+	// Timeout padrão das rotas da API e os timeouts específicos por rota ("[MÉTODO ]padrão").
+	// Quando zerado, é utilizado o DefaultRouteTimeout.
+	RouteTimeout  time.Duration
+	RouteTimeouts map[string]time.Duration
+	// Proxies confiáveis, dos quais os headers com o IP de origem são aceitos pelo middleware realip.
+	// Sem proxies, o IP de origem é o endereço da conexão.
+	TrustedProxies []netip.Prefix
+	// Data a partir da qual as rotas sem versão são obsoletas. Quando zerada, é utilizada a apiversion.DefaultDeprecation.
+	APIDeprecation time.Time
 }
 
+// Tempo máximo padrão de cada consulta ao service-b
+const DefaultServiceBTimeout = 10 * time.Second
+
+// Limites padrão das consultas GraphQL
+const (
+	DefaultGraphQLMaxDepth      = 5
+	DefaultGraphQLMaxComplexity = 200
+)
+
+// Planos padrão do rate limit, no formato aceito pelo ratelimit.ParsePlans
+const DefaultRateLimitPlans = "anonymous=60/1m"
+
+// Plano padrão dos clientes identificados pelo IP
+var DefaultRateLimitPlan = ratelimit.Plan{Name: ratelimit.DefaultPlan, Limit: 60, Window: time.Minute}
+
 // func init() {
 // 	//viper.AutomaticEnv()
 // 	// viper.SetDefault("TITLE", "Microservice Demo")
@@ -77,121 +321,117 @@ type TemplateData struct {
 // Função que busca a temperatura no service-b
 func (h *Webserver) BuscaTemperaturaHandler(w http.ResponseWriter, r *http.Request) {
 
-	// Carregandos o header para gerar o request id para conseguir a rastreabilidade
-	carrier := propagation.HeaderCarrier(r.Header)
-	ctx := r.Context()
-	ctx = otel.GetTextMapPropagator().Extract(ctx, carrier)
-
-	// Criação de span inicial
-	ctx, span := h.TemplateData.OTELTracer.Start(ctx, "Início Processamento "+h.TemplateData.RequestNameOTEL)
+	// Criação de span inicial. O contexto de trace recebido nos headers já foi extraído na
+	// entrada do serviço (telemetry.ExtractHTTP).
+	ctx, span := h.TemplateData.OTELTracer.Start(r.Context(), "Início Processamento "+h.TemplateData.RequestNameOTEL)
 	defer span.End()
 
+	// Registro da consulta no histórico, com o status efetivamente enviado na resposta
+	start := time.Now()
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	w = ww
+	var cepParam DadosCep
+	var clima *ClimaCidade
+	defer func(ctx context.Context) {
+		h.registraHistorico(ctx, r, cepParam.Cep, ww.Status(), clima, start)
+	}(ctx)
+
+	// Formato da resposta não suportado: o service-b não é consultado
+	if details := render.Acceptable(r, apiversion.ClimaCidade(ctx, &ClimaCidade{})); details != nil {
+		span.SetStatus(codes.Error, details.Message)
+		problem.Write(ctx, w, r, details)
+		return
+	}
+
 	// Criação de um span de validação CEP
 	ctx, spanCEP := h.TemplateData.OTELTracer.Start(ctx, "Formatação CEP")
 
-	//Coletando o CEP  partir do body da requisição
-	var cepParam DadosCep
-	err := json.NewDecoder(r.Body).Decode(&cepParam)
-	if err != nil {
+	//Coletando o CEP a partir do body da requisição (POST) ou do parâmetro cep da URL (GET)
+	if details := h.lerCep(w, r, &cepParam); details != nil {
 		spanCEP.SetStatus(codes.Error, "Erro Realizar decode do CEP")
-		w.WriteHeader(http.StatusBadRequest)
+		spanCEP.End()
+		problem.Write(ctx, w, r, details)
 		return
 	}
 
-	// Caso o cep não esteja em um formato válido, retora o código 422 e a mensagem de erro.
-	if !validarFormatoCEP(cepParam.Cep) {
+	// Caso o cep não esteja em um formato válido para o país, retora o código 422 e a mensagem de erro.
+	// Os formatos 32450-000 e 32.450-000 são normalizados para 32450000.
+	normalizado, err := normalizaCodigoPostal(cepParam.Pais, cepParam.Cep)
+	if err != nil {
 		spanCEP.SetStatus(codes.Error, "invalid zipcode")
+		spanCEP.End()
 		log.Printf("invalid zipcode: %s", cepParam)
-		msg := struct {
-			Message string `json:"message"`
-		}{
-			Message: "invalid zipcode",
-		}
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		json.NewEncoder(w).Encode(msg)
+		problem.Write(ctx, w, r, problemaCepInvalido(err))
 		return
 
 	}
+	cepParam.Cep, cepParam.Pais = normalizado.String(), normalizado.Country
 
 	spanCEP.End()
 
 	// Criação de um span de trace do service-b
 	ctx, spanServiceB := h.TemplateData.OTELTracer.Start(ctx, "Consulta service-b")
 
-	// Preparando a URL para a realização da request
-	url := h.TemplateData.ExternalCallURL + cepParam.Cep
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	// Consultando o service-b através do client tipado
+	clima, err = h.ServiceB.BuscaTemperatura(ctx, cepParam.Pais, cepParam.Cep)
 	if err != nil {
-		spanServiceB.SetStatus(codes.Error, "invalid url")
-		log.Printf("Erro formar a request para a url %s: %s", url, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+		spanServiceB.SetStatus(codes.Error, "Erro ao consultar o service-b")
+		spanServiceB.RecordError(err)
+		spanServiceB.End()
 
-	// Injetando o header do request id. Necessário para realizar o tracker
-	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
-
-	// Executando a request
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		spanServiceB.SetStatus(codes.Error, "error acces url")
-		log.Printf("Erro chamar a url %s: %s", url, err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Erro ao ler a resposta: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		// Converte a resposta do service-b no status adequado (422, 404, 502, 503 ou 504)
+		details := serviceb.ToProblem(err)
+		span.SetStatus(codes.Error, details.Message)
+		log.Printf("Erro ao consultar o service-b para o cep %s: %s", cepParam.Cep, err)
+		problem.Write(ctx, w, r, details)
 		return
 	}
 
 	// Finalização do span de cosulta ao service-b
 	spanServiceB.End()
 
-	// Caso o cep esteja em um formato válido, mas não seja encontrado
-	if resp.Status == "404 Not Found" {
-		span.SetStatus(codes.Error, "can not find zipcode")
-		log.Printf("can not find zipcode: %s", cepParam)
-		msg := struct {
-			Message string `json:"message"`
-		}{
-			Message: "can not find zipcode",
-		}
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(msg)
-		return
-	}
+	// Retornando a resposta no contrato da versão da rota e no formato negociado no header Accept
+	span.SetStatus(codes.Ok, "CEP consultado")
+	render.Write(w, r, http.StatusOK, apiversion.ClimaCidade(ctx, clima))
 
-	// Realizando o Unmarshal
-	var clima ClimaCidade
-	err = json.Unmarshal(body, &clima)
-	if err != nil {
-		span.SetStatus(codes.Error, "Erro ao fazer Unmarshal do JSON service-b")
-		log.Printf("Erro ao fazer Unmarshal do JSON service-b: %s", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
+}
 
-	// Retornando a resposta
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	span.SetStatus(codes.Ok, "CEP consultado")
+// Exemplo do body do POST /cep, utilizado nas mensagens de erro. O campo country é opcional.
+const exemploDadosCep = `{"cep": "29902555"}`
+
+// Função que lê o CEP da requisição. No GET, o CEP e o país são informados nos parâmetros cep e
+// country da URL; no POST, no body JSON, que é decodificado de forma estrita. Retorna o problema
+// em caso de erro.
+func (h *Webserver) lerCep(w http.ResponseWriter, r *http.Request, dados *DadosCep) *problem.Details {
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		if !query.Has("cep") {
+			return problem.BadRequest("").Detailf("the cep query parameter is required, like %s", "/cep?cep=29902555")
+		}
+		dados.Cep = query.Get("cep")
+		dados.Pais = query.Get("country")
+		return nil
+	}
+	return decodeJSONStrict(w, r, dados, h.TemplateData.CepMaxBodySize, exemploDadosCep)
+}
 
-	json.NewEncoder(w).Encode(clima)
+// Função que valida e normaliza o código postal do país informado por parâmetro e retorna somente
+// o código (ex.: 32.450-000 vira 32450000). Sem país, o código é um CEP brasileiro.
+func normalizaCEP(pais, parametro string) (string, error) {
+	c, err := normalizaCodigoPostal(pais, parametro)
+	return c.String(), err
+}
 
+// Função que valida e normaliza o código postal do país informado por parâmetro (ex.: 1000-001
+// vira 1000001 em PT). Sem país, o código é um CEP brasileiro.
+func normalizaCodigoPostal(pais, parametro string) (cep.PostalCode, error) {
+	return cep.ParsePostalCode(pais, parametro)
 }
 
-// Função que valida o formato CEP informado por parâmetro
-func validarFormatoCEP(parametro string) bool {
-	// Verifica se o parâmetro tem exatamente 8 caracteres
-	if len(parametro) != 8 {
-		return false
+// Problema do erro de validação do código postal, com os países aceitos quando o país não é suportado
+func problemaCepInvalido(err error) *problem.Details {
+	if errors.Is(err, cep.ErrUnsupportedCountry) {
+		return problem.InvalidZipcode("").Detailf("the country must be one of %s", strings.Join(cep.Countries(), ", "))
 	}
-
-	// Verifica se todos os caracteres são números inteiros
-	_, err := strconv.Atoi(parametro)
-	return err == nil
+	return problem.InvalidZipcode(err.Error())
 }
@@ -4,12 +4,32 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/deadline"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/pb"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/auth"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/ratelimit"
+	"github.com/wandermaia/desafio-temperatura-cep/service-a/internal/infra/serviceb"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/protobuf/proto"
 )
 
+// Requisição POST /cep com o body JSON informado
+func requisicaoCep(body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/cep", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
 // Cep Válido. Deve retornar Código 200 e o Response Body
 // no formato: { "city: "São Paulo", "temp_C": 28.5, "temp_F": 28.5, "temp_K": 28.5 }
 func TestBuscaTemperaturaHandler(t *testing.T) {
@@ -107,4 +127,587 @@ func TestBuscaTemperaturaHandlerCepNaoEncontrado(t *testing.T) {
 	assert.Empty(t, clima)
 }
 
+// Cep INVÁLIDO e service-b indisponível. As respostas devem seguir o formato application/problem+json
+func TestBuscaTemperaturaHandlerProblemJSON(t *testing.T) {
+
+	// Server mock fechado para simular o service-b fora do ar
+	serverMock := httptest.NewServer(http.NotFoundHandler())
+	serverMock.Close()
+
+	templateData := &TemplateData{
+		ExternalCallURL: serverMock.URL + "/",
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	}
+	router := NewServer(templateData).CreateServer()
+
+	testes := []struct {
+		nome   string
+		body   string
+		status int
+		tipo   string
+	}{
+		{"body inválido", `{"cep": `, http.StatusBadRequest, problem.TypeBadRequest},
+		{"cep inválido", `{"cep": "324500000"}`, http.StatusUnprocessableEntity, problem.TypeInvalidZipcode},
+		{"cep com sinal", `{"cep": "+3245000"}`, http.StatusUnprocessableEntity, problem.TypeInvalidZipcode},
+		{"cep fora das faixas", `{"cep": "00000000"}`, http.StatusUnprocessableEntity, problem.TypeInvalidZipcode},
+		{"service-b indisponível", `{"cep": "32450000"}`, http.StatusServiceUnavailable, problem.TypeServiceUnavailable},
+	}
+
+	for _, tt := range testes {
+		t.Run(tt.nome, func(t *testing.T) {
+			req := requisicaoCep(tt.body)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var details problem.Details
+			err := json.Unmarshal(w.Body.Bytes(), &details)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.tipo, details.Type)
+			assert.Equal(t, "/cep", details.Instance)
+			assert.NotEmpty(t, details.Message)
+		})
+	}
+}
+
+// Os formatos 32450-000 e 32.450-000 devem ser normalizados antes da consulta ao service-b
+func TestBuscaTemperaturaHandlerFormatosCEP(t *testing.T) {
+	var consultados []string
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		consultados = append(consultados, r.URL.Path)
+		w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+	}))
+	defer serverMock.Close()
+
+	router := NewServer(&TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	}).CreateServer()
+
+	for _, cep := range []string{"32450-000", "32.450-000", " 32450000 "} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, requisicaoCep(`{"cep": "`+cep+`"}`))
+		assert.Equal(t, http.StatusOK, w.Code, cep)
+	}
+	assert.Equal(t, []string{"/32450000", "/32450000", "/32450000"}, consultados)
+}
+
+// Teste de contrato: o status e o trace_id retornados pelo service-b devem ser repassados pelo service-a
+func TestBuscaTemperaturaHandlerContratoServiceB(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	testes := []struct {
+		statusB int
+		statusA int
+	}{
+		{http.StatusUnprocessableEntity, http.StatusUnprocessableEntity},
+		{http.StatusNotFound, http.StatusNotFound},
+		{http.StatusInternalServerError, http.StatusBadGateway},
+		{http.StatusBadGateway, http.StatusBadGateway},
+		{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		{http.StatusGatewayTimeout, http.StatusGatewayTimeout},
+	}
+
+	for _, tt := range testes {
+		t.Run(http.StatusText(tt.statusB), func(t *testing.T) {
+			serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", problem.ContentType)
+				w.WriteHeader(tt.statusB)
+				w.Write([]byte(`{"title": "erro", "status": ` + strconv.Itoa(tt.statusB) + `, "trace_id": "` + traceID + `", "message": "erro"}`))
+			}))
+			defer serverMock.Close()
+
+			templateData := &TemplateData{
+				ExternalCallURL: serverMock.URL,
+				RequestNameOTEL: "microservice-tracer-mock",
+				OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+			}
+			router := NewServer(templateData).CreateServer()
+
+			req := requisicaoCep(`{"cep": "32450000"}`)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var details problem.Details
+			err := json.Unmarshal(w.Body.Bytes(), &details)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.statusA, w.Code)
+			assert.Equal(t, tt.statusA, details.Status)
+			assert.Equal(t, traceID, details.TraceID)
+		})
+	}
+}
+
+// Resposta de sucesso do service-b deve ser repassada com status 200
+func TestBuscaTemperaturaHandlerServiceBOk(t *testing.T) {
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5 }`))
+	}))
+	defer serverMock.Close()
+
+	templateData := &TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	}
+	router := NewServer(templateData).CreateServer()
+
+	req := requisicaoCep(`{"cep": "32450000"}`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var clima ClimaCidade
+	err := json.Unmarshal(w.Body.Bytes(), &clima)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Ibirité", clima.Cidade)
+	assert.Equal(t, 301.5, clima.TempK)
+}
+
 //https://medium.com/zus-health/mocking-outbound-http-requests-in-go-youre-probably-doing-it-wrong-60373a38d2aa
+
+// Com o rate limit habilitado, as rotas da API são limitadas por cliente. A documentação não é limitada.
+func TestBuscaTemperaturaHandlerRateLimit(t *testing.T) {
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+	}))
+	defer serverMock.Close()
+
+	router := NewServer(&TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		RateLimiter:     ratelimit.NewMemory(),
+		RateLimitPlans:  map[string]ratelimit.Plan{ratelimit.DefaultPlan: {Name: ratelimit.DefaultPlan, Limit: 1, Window: time.Minute}},
+	}).CreateServer()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, requisicaoCep(`{"cep": "32450000"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, requisicaoCep(`{"cep": "32450000"}`))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+// Com a autenticação habilitada, as rotas exigem a chave de API com o escopo da rota e o
+// client.id é propagado no baggage para o service-b.
+func TestBuscaTemperaturaHandlerAutenticacao(t *testing.T) {
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	defer otel.SetTextMapPropagator(propagator)
+
+	var baggage string
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		baggage = r.Header.Get("baggage")
+		w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+	}))
+	defer serverMock.Close()
+
+	keys, err := auth.NewAPIKeys([]auth.APIKey{{ClientID: "app-mobile", SHA256: auth.HashKey("chave-mobile"), Scopes: []string{auth.ScopeCurrent}}})
+	assert.NoError(t, err)
+	router := NewServer(&TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		Authenticator:   &auth.Authenticator{APIKeys: keys},
+	}).CreateServer()
+
+	serve := func(method, path, body, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey != "" {
+			req.Header.Set(auth.APIKeyHeader, apiKey)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "/cep", `{"cep": "32450000"}`, "").Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodGet, "/history", "", "chave-mobile").Code)
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodPost, "/jobs", `{"ceps": ["32450000"]}`, "chave-mobile").Code)
+	assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/openapi.json", "", "").Code)
+
+	w := serve(http.MethodPost, "/cep", `{"cep": "32450000"}`, "chave-mobile")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "client.id=app-mobile", baggage)
+
+	// O client.id enviado pelo cliente no header baggage não substitui o cliente autenticado
+	req := httptest.NewRequest(http.MethodPost, "/cep", strings.NewReader(`{"cep": "32450000"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(auth.APIKeyHeader, "chave-mobile")
+	req.Header.Set("baggage", "client.id=victim")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "client.id=app-mobile", baggage)
+}
+
+// Sem autenticação, o client.id recebido no header baggage não é repassado ao service-b
+func TestBuscaTemperaturaHandlerBaggageForjado(t *testing.T) {
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	defer otel.SetTextMapPropagator(propagator)
+
+	baggage := "não chamado"
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		baggage = r.Header.Get("baggage")
+		w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+	}))
+	defer serverMock.Close()
+
+	router := NewServer(&TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	}).CreateServer()
+
+	req := requisicaoCep(`{"cep": "32450000"}`)
+	req.Header.Set("baggage", "client.id=victim")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, baggage)
+}
+
+// O body do POST /cep deve ser um único objeto JSON, sem campos desconhecidos, com o
+// Content-Type application/json e dentro do limite de tamanho.
+func TestBuscaTemperaturaHandlerBodyEstrito(t *testing.T) {
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+	}))
+	defer serverMock.Close()
+
+	router := NewServer(&TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		CepMaxBodySize:  64,
+	}).CreateServer()
+
+	testes := []struct {
+		nome        string
+		contentType string
+		body        string
+		status      int
+		mensagem    string
+	}{
+		{"válido", "application/json", `{"cep": "32450000"}`, http.StatusOK, ""},
+		{"charset e espaços no final", "application/json; charset=utf-8", "{\"cep\": \"32450000\"}\n  ", http.StatusOK, ""},
+		{"sem content-type", "", `{"cep": "32450000"}`, http.StatusUnsupportedMediaType, "the request body must be sent with Content-Type: application/json"},
+		{"content-type de texto", "text/plain", `{"cep": "32450000"}`, http.StatusUnsupportedMediaType, "the request body must be sent with Content-Type: application/json"},
+		{"campo desconhecido", "application/json", `{"cep": "32450000", "uf": "MG"}`, http.StatusBadRequest, `the field "uf" is not allowed, expected a JSON object like {"cep": "29902555"}`},
+		{"tipo inválido", "application/json", `{"cep": 32450000}`, http.StatusBadRequest, `the field "cep" must be a string`},
+		{"conteúdo depois do objeto", "application/json", `{"cep": "32450000"} lixo`, http.StatusBadRequest, `the request body must contain a single JSON object like {"cep": "29902555"}`},
+		{"dois objetos", "application/json", `{"cep": "32450000"}{"cep": "01001000"}`, http.StatusBadRequest, `the request body must contain a single JSON object like {"cep": "29902555"}`},
+		{"body vazio", "application/json", ``, http.StatusBadRequest, `the request body is empty, expected a JSON object like {"cep": "29902555"}`},
+		{"body maior que o limite", "application/json", `{"cep": "32450000", "x": "` + strings.Repeat("a", 100) + `"}`, http.StatusRequestEntityTooLarge, "the request body must have at most 64 bytes"},
+		{"lixo depois do limite", "application/json", `{"cep": "32450000"}` + strings.Repeat(" ", 100), http.StatusRequestEntityTooLarge, "the request body must have at most 64 bytes"},
+	}
+
+	for _, tt := range testes {
+		t.Run(tt.nome, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/cep", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.mensagem != "" {
+				var details problem.Details
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+				assert.Equal(t, tt.mensagem, details.Detail)
+			}
+		})
+	}
+}
+
+// O CEP também pode ser informado no parâmetro cep da URL, com GET
+func TestBuscaTemperaturaHandlerQuery(t *testing.T) {
+	var consultados []string
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		consultados = append(consultados, r.URL.Path)
+		w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+	}))
+	defer serverMock.Close()
+
+	router := NewServer(&TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	}).CreateServer()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cep?cep=32450-000", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"/32450000"}, consultados)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cep", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cep?cep=123", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+// O país é informado no parâmetro country (GET) ou no campo country do body (POST) e o código
+// postal é validado e normalizado de acordo com o país antes da consulta ao service-b
+func TestBuscaTemperaturaHandlerPais(t *testing.T) {
+	consultados := make(chan string, 3)
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		consultados <- r.URL.RequestURI()
+		w.Write([]byte(`{"city": "Lisboa", "temp_C": 18.5, "temp_F": 65.3, "temp_K": 291.5}`))
+	}))
+	defer serverMock.Close()
+
+	router := NewServer(&TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	}).CreateServer()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cep?cep=1000-001&country=pt", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/1000001?country=PT", <-consultados)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, requisicaoCep(`{"cep": "c1425abc", "country": "AR"}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/C1425ABC?country=AR", <-consultados)
+
+	// Formato inválido para o país e país não suportado
+	testes := []struct {
+		body   string
+		detail string
+	}{
+		{`{"cep": "32450000", "country": "PT"}`, "the postal code must be in the format 1234-567"},
+		{`{"cep": "10001", "country": "US"}`, "the country must be one of AR, BR, PT"},
+	}
+	for _, tt := range testes {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, requisicaoCep(tt.body))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		var details problem.Details
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+		assert.Equal(t, tt.detail, details.Detail)
+	}
+	assert.Empty(t, consultados)
+}
+
+// O idioma negociado no Accept-Language traduz os erros do service-a e é repassado ao service-b,
+// cujo detalhe já traduzido é mantido na resposta
+func TestBuscaTemperaturaHandlerIdioma(t *testing.T) {
+	idiomas := make(chan string, 1)
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idiomas <- r.Header.Get(i18n.Header)
+		w.Header().Set("Content-Type", problem.ContentType)
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"title": "CEP não encontrado", "status": 404, "detail": "o CEP 99999999 não existe", "message": "CEP não encontrado"}`))
+	}))
+	defer serverMock.Close()
+
+	router := NewServer(&TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	}).CreateServer()
+
+	req := httptest.NewRequest(http.MethodGet, "/cep?cep=99999999", nil)
+	req.Header.Set(i18n.Header, "pt-BR,pt;q=0.9,en;q=0.8")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, i18n.PortugueseBR, <-idiomas)
+	assert.Equal(t, i18n.PortugueseBR, w.Header().Get("Content-Language"))
+	var details problem.Details
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+	assert.Equal(t, "CEP não encontrado", details.Message)
+	assert.Equal(t, "o CEP 99999999 não existe", details.Detail)
+
+	// Erros de validação, sem consulta ao service-b
+	req = requisicaoCep(`{"cep": "10001", "country": "US"}`)
+	req.Header.Set(i18n.Header, "es")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+	assert.Equal(t, "código postal inválido", details.Title)
+	assert.Equal(t, "el país debe ser uno de los siguientes: AR, BR, PT", details.Detail)
+	assert.Empty(t, idiomas)
+}
+
+// O prazo informado pelo cliente é propagado ao service-b e, com o service-b lento, o hedge
+// enviado à outra instância responde primeiro.
+func TestBuscaTemperaturaHandlerPrazoHedge(t *testing.T) {
+	prazos := make(chan string, 2)
+	lento := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prazos <- r.Header.Get(deadline.Header)
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+		w.Write([]byte(`{"city": "Lenta", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+	}))
+	defer lento.Close()
+	rapido := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prazos <- r.Header.Get(deadline.Header)
+		w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5}`))
+	}))
+	defer rapido.Close()
+
+	router := NewServer(&TemplateData{
+		ExternalCallURL:     lento.URL,
+		RequestNameOTEL:     "microservice-tracer-mock",
+		OTELTracer:          otel.Tracer("microservice-tracer-mock"),
+		ServiceBHedgeClient: serviceb.NewClient(rapido.URL, nil),
+		HedgeMinDelay:       50 * time.Millisecond,
+	}).CreateServer()
+
+	req := requisicaoCep(`{"cep": "32450000"}`)
+	req.Header.Set(deadline.Header, "1500")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Ibirité")
+	for i := 0; i < 2; i++ {
+		ms, err := strconv.Atoi(<-prazos)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, ms, 1500)
+		assert.Greater(t, ms, 1000)
+	}
+}
+
+// Teste da resposta nos formatos negociados no header Accept. Sem formato aceitável, a resposta
+// é 406 sem consultar o service-b.
+func TestBuscaTemperaturaHandlerFormatosResposta(t *testing.T) {
+	consultas := 0
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		consultas++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{ "city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5, "condition": "Sunny" }`))
+	}))
+	defer serverMock.Close()
+
+	router := NewServer(&TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	}).CreateServer()
+	busca := func(accept string) *httptest.ResponseRecorder {
+		req := requisicaoCep(`{"cep": "32450000"}`)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := busca("application/xml;q=0.9, application/json;q=0.5")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<response><city>Ibirité</city><temp_C>28.5</temp_C>")
+
+	w = busca("text/csv")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "cep,country,city,state,temp_C,temp_F,temp_K,condition\n,,Ibirité,,28.5,83.3,301.5,Sunny\n", w.Body.String())
+
+	w = busca("application/x-protobuf")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var clima pb.ClimaCidade
+	assert.NoError(t, proto.Unmarshal(w.Body.Bytes(), &clima))
+	assert.Equal(t, "Ibirité", clima.City)
+	assert.Equal(t, 301.5, clima.TempK)
+
+	w = busca("*/*")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, 4, consultas)
+
+	w = busca("application/yaml")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, 4, consultas)
+}
+
+// Teste das rotas versionadas do /cep. O service-b é sempre consultado na v1, e a resposta é
+// convertida no contrato da versão da rota.
+func TestBuscaTemperaturaHandlerVersoes(t *testing.T) {
+	versoes := make(chan string, 10)
+	serverMock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versoes <- r.Header.Get(apiversion.Header)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"city": "Ibirité", "temp_C": 28.5, "temp_F": 83.3, "temp_K": 301.5, "address": {"cep": "32450-000", "city": "Ibirité", "state": "MG", "country": "BR"}, "condition": "Sunny"}`))
+	}))
+	defer serverMock.Close()
+
+	router := NewServer(&TemplateData{
+		ExternalCallURL: serverMock.URL,
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	}).CreateServer()
+	busca := func(req *http.Request, header ...string) *httptest.ResponseRecorder {
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	post := func(path string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"cep": "32450000"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+	v1JSON := `{"city":"Ibirité","temp_C":28.5,"temp_F":83.3,"temp_K":301.5,"address":{"cep":"32450-000","city":"Ibirité","state":"MG","country":"BR"},"condition":"Sunny"}`
+	v2JSON := `{"location":{"postal_code":"32450-000","country":"BR","state":"MG","city":"Ibirité"},"temperature":{"celsius":28.5,"fahrenheit":83.3,"kelvin":301.5},"conditions":{"description":"Sunny"}}`
+
+	w := busca(post("/v1/cep"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, v1JSON, w.Body.String())
+	assert.Equal(t, apiversion.V1, w.Header().Get(apiversion.Header))
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	w = busca(httptest.NewRequest(http.MethodGet, "/v2/cep?cep=32450000", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, v2JSON, w.Body.String())
+	assert.Equal(t, apiversion.V2, w.Header().Get(apiversion.Header))
+
+	// Rota sem versão: v1 obsoleta ou a versão do header
+	w = busca(post("/cep"))
+	assert.JSONEq(t, v1JSON, w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("Deprecation"))
+	assert.Equal(t, `</v1/cep>; rel="successor-version"`, w.Header().Get("Link"))
+
+	w = busca(post("/cep"), apiversion.Header, "v2")
+	assert.JSONEq(t, v2JSON, w.Body.String())
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	assert.Len(t, versoes, 4)
+	for len(versoes) > 0 {
+		assert.Equal(t, apiversion.V1, <-versoes)
+	}
+
+	// Versão desconhecida no header e formato sem representação na v2, sem consulta ao service-b
+	w = busca(post("/cep"), apiversion.Header, "3", i18n.Header, "pt-BR")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var details problem.Details
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+	assert.Equal(t, "o header X-API-Version deve ser um dos seguintes: v1, v2", details.Detail)
+	w = busca(post("/v2/cep"), "Accept", "application/x-protobuf")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Empty(t, versoes)
+}
@@ -0,0 +1,176 @@
+// Package httpmw reúne os middlewares HTTP do service-a voltados aos clientes: CORS, headers
+// de segurança, compressão das respostas e timeout por rota.
+package httpmw
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+)
+
+// Erro retornado quando a configuração dos timeouts é inválida
+var ErrInvalidConfig = errors.New("invalid middleware config")
+
+// Configuração do CORS. Sem origens, o middleware não adiciona os headers.
+type CORSConfig struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	MaxAge         time.Duration
+}
+
+// Middleware de CORS, que também responde as requisições de preflight (OPTIONS)
+func CORS(config CORSConfig) func(http.Handler) http.Handler {
+	if len(config.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return cors.Handler(cors.Options{
+		AllowedOrigins: config.AllowedOrigins,
+		AllowedMethods: config.AllowedMethods,
+		AllowedHeaders: config.AllowedHeaders,
+		ExposedHeaders: config.ExposedHeaders,
+		MaxAge:         int(config.MaxAge.Seconds()),
+	})
+}
+
+// Content-Security-Policy das respostas da API, que não carregam nenhum recurso
+const DefaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+// Content-Security-Policy da página de documentação, que carrega o Swagger UI publicado pelo próprio serviço e o /openapi.json
+const DocsContentSecurityPolicy = "default-src 'none'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
+
+// Configuração dos headers de segurança
+type SecurityConfig struct {
+	// Duração do HSTS. O header é enviado somente nas requisições HTTPS; zerado, não é enviado.
+	HSTSMaxAge time.Duration
+	// Paths que utilizam a DocsContentSecurityPolicy
+	DocsPaths []string
+}
+
+// Middleware que adiciona os headers de segurança padrão a todas as respostas
+func Security(config SecurityConfig) func(http.Handler) http.Handler {
+	docs := map[string]bool{}
+	for _, path := range config.DocsPaths {
+		docs[path] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := w.Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+			header.Set("X-Frame-Options", "DENY")
+			header.Set("Referrer-Policy", "no-referrer")
+			if docs[r.URL.Path] {
+				header.Set("Content-Security-Policy", DocsContentSecurityPolicy)
+			} else {
+				header.Set("Content-Security-Policy", DefaultContentSecurityPolicy)
+			}
+			if config.HSTSMaxAge > 0 && (r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https") {
+				header.Set("Strict-Transport-Security", "max-age="+strconv.Itoa(int(config.HSTSMaxAge.Seconds()))+"; includeSubDomains")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Content-Types das respostas comprimidas
+var compressibleTypes = []string{
+	"application/json",
+	"application/xml",
+	"application/problem+json",
+	"text/html",
+	"text/csv",
+	"text/plain",
+}
+
+// Middleware que comprime as respostas com brotli ou gzip, conforme o Accept-Encoding do cliente.
+// As assinaturas (SSE e WebSocket) não são comprimidas, para que cada evento seja entregue
+// imediatamente e para não interferir no upgrade da conexão.
+func Compress(level int) func(http.Handler) http.Handler {
+	compressor := middleware.NewCompressor(level, compressibleTypes...)
+	compressor.SetEncoder("br", func(w io.Writer, level int) io.Writer {
+		return brotli.NewWriterLevel(w, level)
+	})
+	return func(next http.Handler) http.Handler {
+		compressed := compressor.Handler(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isStream(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			compressed.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Função que identifica as requisições de SSE e de upgrade para WebSocket
+func isStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream") ||
+		strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		strings.HasSuffix(r.URL.Path, "/stream") ||
+		strings.HasSuffix(r.URL.Path, "/ws")
+}
+
+// Middleware que aplica o timeout da rota. O timeout é procurado pelo método e padrão da rota
+// ("POST /jobs"), depois somente pelo padrão ("/graphql"); sem configuração, é utilizado o
+// timeout padrão. Deve ser utilizado dentro de um grupo do chi, após o roteamento.
+func RouteTimeout(defaultTimeout time.Duration, routes map[string]time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := defaultTimeout
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				pattern := rctx.RoutePattern()
+				if d, ok := routes[r.Method+" "+pattern]; ok {
+					timeout = d
+				} else if d, ok := routes[pattern]; ok {
+					timeout = d
+				}
+			}
+			middleware.Timeout(timeout)(next).ServeHTTP(w, r)
+		})
+	}
+}
+
+// Função que interpreta a lista de timeouts por rota no formato "[MÉTODO ]padrão=duração",
+// separados por vírgula. Exemplo: "/graphql=30s,POST /jobs=5s".
+func ParseRouteTimeouts(s string) (map[string]time.Duration, error) {
+	routes := map[string]time.Duration{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(item, "=")
+		route = strings.TrimSpace(route)
+		if !ok || !strings.Contains(route, "/") {
+			return nil, fmt.Errorf("%w: route timeouts must be in the format [METHOD ]/pattern=duration", ErrInvalidConfig)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%w: invalid timeout for %s", ErrInvalidConfig, route)
+		}
+		routes[route] = d
+	}
+	return routes, nil
+}
+
+// Função que separa uma lista de valores separados por vírgula, ignorando os itens vazios
+func SplitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,187 @@
+package httpmw
+
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, `{"temp_C":28.5,"temp_F":83.3,"temp_K":301.5,"city":"São Paulo"}`+strings.Repeat(" ", 2048))
+})
+
+func TestCORS(t *testing.T) {
+	handler := CORS(CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+		ExposedHeaders: []string{"Retry-After"},
+		MaxAge:         10 * time.Minute,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEqual(t, http.MethodOptions, r.Method, "o preflight não deve chegar ao handler")
+	}))
+
+	// Preflight
+	req := httptest.NewRequest(http.MethodOptions, "/cep", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type, X-API-Key")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "POST", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+
+	// Requisição simples
+	req = httptest.NewRequest(http.MethodPost, "/cep", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Retry-After", w.Header().Get("Access-Control-Expose-Headers"))
+
+	// Origem não permitida
+	req = httptest.NewRequest(http.MethodPost, "/cep", nil)
+	req.Header.Set("Origin", "https://outro.example.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSDesabilitado(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/cep", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	CORS(CORSConfig{})(ok).ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestSecurity(t *testing.T) {
+	handler := Security(SecurityConfig{HSTSMaxAge: time.Hour, DocsPaths: []string{"/docs"}})(ok)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cep", nil))
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+	assert.Equal(t, DefaultContentSecurityPolicy, w.Header().Get("Content-Security-Policy"))
+	assert.Empty(t, w.Header().Get("Strict-Transport-Security"), "HSTS somente em HTTPS")
+
+	// Documentação, acessada via HTTPS
+	req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	req.TLS = &tls.ConnectionState{}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, DocsContentSecurityPolicy, w.Header().Get("Content-Security-Policy"))
+	assert.Equal(t, "max-age=3600; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+
+	// HTTPS terminado no proxy
+	req = httptest.NewRequest(http.MethodGet, "/cep", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	assert.NotEmpty(t, w.Header().Get("Strict-Transport-Security"))
+}
+
+func TestCompress(t *testing.T) {
+	handler := Compress(5)(ok)
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	}
+	for encoding, decode := range decoders {
+		t.Run(encoding, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/cep?cep=01001000", nil)
+			req.Header.Set("Accept-Encoding", encoding)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			assert.Equal(t, encoding, w.Header().Get("Content-Encoding"))
+			reader, err := decode(w.Body)
+			require.NoError(t, err)
+			body, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Contains(t, string(body), `"city":"São Paulo"`)
+		})
+	}
+
+	// Sem Accept-Encoding
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cep", nil))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+}
+
+// As assinaturas não são comprimidas, para que os eventos sejam entregues imediatamente
+func TestCompressIgnoraStream(t *testing.T) {
+	handler := Compress(5)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, strings.Repeat("evento ", 1024))
+	}))
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/cep/01001000/stream", nil),
+		httptest.NewRequest(http.MethodGet, "/cep/01001000/ws", nil),
+	} {
+		req.Header.Set("Accept-Encoding", "gzip, br")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Empty(t, w.Header().Get("Content-Encoding"), req.URL.Path)
+	}
+}
+
+func TestRouteTimeout(t *testing.T) {
+	deadline := func(w http.ResponseWriter, r *http.Request) {
+		d, ok := r.Context().Deadline()
+		require.True(t, ok)
+		io.WriteString(w, time.Until(d).Round(time.Second).String())
+	}
+	router := chi.NewRouter()
+	router.Group(func(router chi.Router) {
+		router.Use(RouteTimeout(15*time.Second, map[string]time.Duration{
+			"/graphql":   30 * time.Second,
+			"POST /jobs": 5 * time.Second,
+		}))
+		router.Get("/cep", deadline)
+		router.Get("/graphql", deadline)
+		router.Get("/jobs", deadline)
+		router.Post("/jobs", deadline)
+	})
+
+	for request, expected := range map[string]string{
+		"GET /cep":     "15s",
+		"GET /graphql": "30s",
+		"GET /jobs":    "15s",
+		"POST /jobs":   "5s",
+	} {
+		method, path, _ := strings.Cut(request, " ")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		assert.Equal(t, expected, w.Body.String(), request)
+	}
+}
+
+func TestParseRouteTimeouts(t *testing.T) {
+	routes, err := ParseRouteTimeouts(" /graphql=30s, POST /jobs=5s ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Duration{"/graphql": 30 * time.Second, "POST /jobs": 5 * time.Second}, routes)
+
+	for _, invalid := range []string{"/graphql", "graphql=30s", "/graphql=abc", "/graphql=0s"} {
+		_, err := ParseRouteTimeouts(invalid)
+		assert.ErrorIs(t, err, ErrInvalidConfig, invalid)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, SplitList(" https://a.example.com,,https://b.example.com "))
+	assert.Nil(t, SplitList(""))
+}
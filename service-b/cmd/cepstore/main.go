@@ -0,0 +1,56 @@
+// Comando administrativo que importa ou atualiza a base local de CEPs do service-b.
+//
+//	go run ./cmd/cepstore -db ceps.db -file ceps.csv
+//	go run ./cmd/cepstore -db ceps.db -file ceps.json -replace
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/cepstore"
+	"go.opentelemetry.io/otel"
+)
+
+func main() {
+	dbPath := flag.String("db", "ceps.db", "caminho do banco SQLite da base local")
+	file := flag.String("file", "", "dump com os CEPs (CSV ou JSON)")
+	format := flag.String("format", "", "formato do dump: csv ou json (padrão: extensão do arquivo)")
+	replace := flag.Bool("replace", false, "substitui toda a base pelo conteúdo do dump (refresh completo)")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+
+	store, err := cepstore.Open(*dbPath, otel.Tracer("cepstore"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	dump, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer dump.Close()
+
+	ctx := context.Background()
+	count, err := store.Import(ctx, dump, *format, *replace)
+	if err != nil {
+		log.Fatalf("falha ao importar %s: %s", *file, err)
+	}
+	total, err := store.Count(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%d CEPs importados de %s. A base %s possui %d CEPs.", count, *file, *dbPath, total)
+}
@@ -1,92 +1,64 @@
 package main
 
 import (
-	"context"
-	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/bootstrap"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/pb"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/realip"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/telemetry"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/address"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/cepstore"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/grpc/service"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/weather"
 	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/webserver/handlers"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/propagation"
-	"go.opentelemetry.io/otel/sdk/resource"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
 )
 
 // load env vars cfg
 func init() {
-	//viper.AutomaticEnv()
-	viper.SetDefault("OTEL_SERVICE_NAME", "service-b")
-	viper.SetDefault("REQUEST_NAME_OTEL", "service-b-request")
-	viper.SetDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "otel-collector:4317")
-	viper.SetDefault("HTTP_PORT", ":8282")
-}
-
-func initProvider(serviceName, collectorURL string) (func(context.Context) error, error) {
-	ctx := context.Background()
-
-	// Criando nome do recurso para ser utilizando no jaeger, por exemplo
-	res, err := resource.New(ctx,
-		resource.WithAttributes(
-			semconv.ServiceName(serviceName),
-		),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create resource: %w", err)
-	}
-
-	// Alterado para contexto com timeout
-	ctx, cancel := context.WithTimeout(ctx, time.Second)
-	defer cancel()
-
-	//Criando a chamada grpc
-	conn, err := grpc.NewClient(collectorURL, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create gRPC connection to collector: %w", err)
-	}
-
-	// Configurar o exporter do trace com grpc (poderia ser http também)
-	traceExporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithGRPCConn(conn))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
-	}
-
-	// Criar o span em formato batch
-	bsp := sdktrace.NewBatchSpanProcessor(traceExporter)
-
-	// Vai fazer a consolidação das informações
-	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()), // A amostragem que será enviada no trace.
-		sdktrace.WithResource(res),
-		sdktrace.WithSpanProcessor(bsp),
-	)
-	otel.SetTracerProvider(tracerProvider)
-
-	// Propagar a informação utilizando os dados de tracing
-	otel.SetTextMapPropagator(propagation.TraceContext{})
-
-	// Shutdown graceful
-	return tracerProvider.Shutdown, nil
+	// As variáveis de ambiente têm prioridade sobre os valores padrão abaixo
+	viper.AutomaticEnv()
+	bootstrap.SetDefaults("service-b", ":8282")
+	viper.SetDefault("GRPC_PORT", ":50051")
+	viper.SetDefault("GRPC_MAX_CEPS", service.DefaultMaxCeps)
+	viper.SetDefault("VIACEP_URL", handlers.DefaultViaCEPURL)
+	viper.SetDefault("WEATHERAPI_URL", handlers.DefaultWeatherAPIURL)
+	viper.SetDefault("WEATHERAPI_KEY", handlers.DefaultWeatherAPIKey)
+	// Caminho da base local de CEPs. Vazio desabilita a base local.
+	viper.SetDefault("CEP_STORE_PATH", "")
+	viper.SetDefault("CEP_STORE_MODE", handlers.CepStoreModeFallback)
+	// Cache dos CEPs encontrados no ViaCEP. Tamanho zero desabilita o cache.
+	viper.SetDefault("CEP_CACHE_TTL", handlers.DefaultCepCacheTTL)
+	viper.SetDefault("CEP_CACHE_SIZE", handlers.DefaultCepCacheSize)
+	// Provedores de clima, separados por vírgula, e a estratégia de combinação das leituras
+	viper.SetDefault("WEATHER_PROVIDERS", handlers.DefaultWeatherProviders)
+	viper.SetDefault("WEATHER_STRATEGY", handlers.DefaultWeatherStrategy)
+	viper.SetDefault("WEATHER_DISAGREEMENT_THRESHOLD", weather.DefaultDisagreementThreshold)
+	viper.SetDefault("OPEN_METEO_GEOCODING_URL", weather.DefaultOpenMeteoGeocodingURL)
+	viper.SetDefault("OPEN_METEO_URL", weather.DefaultOpenMeteoURL)
+	// Provedor de endereços dos códigos postais de Portugal e da Argentina
+	viper.SetDefault("ZIPPOPOTAM_URL", address.DefaultZippopotamURL)
 }
 
 func main() {
 
 	// Sinais para graceful shutdown
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt)
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := bootstrap.SignalContext()
 	defer cancel()
 
 	// Shutdown do provider
-	shutdown, err := initProvider(viper.GetString("OTEL_SERVICE_NAME"), viper.GetString("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	shutdown, err := telemetry.InitProvider(viper.GetString("OTEL_SERVICE_NAME"), viper.GetString("OTEL_EXPORTER_OTLP_ENDPOINT"))
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -100,34 +72,102 @@ func main() {
 	tracer := otel.Tracer("microservice-tracer")
 
 	// Dados para a criação do servidor
-	templateData := &handlers.TemplateOtelData{
-		RequestNameOTEL: viper.GetString("REQUEST_NAME_OTEL"),
-		OTELTracer:      tracer,
+	templateData := newTemplateData(tracer)
+
+	// Endereços dos códigos postais dos demais países. Os CEPs brasileiros são consultados no ViaCEP.
+	zippopotam := &address.Zippopotam{URL: viper.GetString("ZIPPOPOTAM_URL"), Client: &http.Client{Timeout: 10 * time.Second}}
+	templateData.AddressProviders = map[string]address.Provider{
+		cep.CountryPortugal:  zippopotam,
+		cep.CountryArgentina: zippopotam,
+	}
+	// Proxies dos quais os headers com o IP de origem são aceitos
+	templateData.TrustedProxies, err = realip.ParseTrustedProxies(viper.GetString("TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	templateData.APIDeprecation, err = apiversion.ParseDeprecation(viper.GetString("API_DEPRECATION_DATE"))
+	if err != nil {
+		log.Fatalf("invalid API_DEPRECATION_DATE: %s", viper.GetString("API_DEPRECATION_DATE"))
+	}
+
+	// Base local de CEPs, importada com o comando cmd/cepstore
+	if path := viper.GetString("CEP_STORE_PATH"); path != "" {
+		switch templateData.CepStoreMode {
+		case handlers.CepStoreModeFirst, handlers.CepStoreModeFallback:
+		default:
+			log.Fatalf("invalid CEP_STORE_MODE: %s", templateData.CepStoreMode)
+		}
+		store, err := cepstore.Open(path, tracer)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+		templateData.CepStore = store
 	}
 
 	// Criação do server
-	server := handlers.NewServer(templateData)
+	server, err := handlers.NewServer(templateData)
+	if err != nil {
+		log.Fatal(err)
+	}
 	router := server.CreateServer()
 
-	// Servidor iniciando em outra thread
-	go func() {
-		log.Println("Starting server on port", viper.GetString("HTTP_PORT"))
-		if err := http.ListenAndServe(viper.GetString("HTTP_PORT"), router); err != nil {
-			log.Fatal(err)
-		}
-	}()
+	// TLS dos servidores HTTP e gRPC, com os certificados recarregados durante a execução
+	tlsConfig, err := bootstrap.ServerTLS(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Servidor gRPC com a instrumentação do otelgrpc
+	grpcOptions := []grpc.ServerOption{grpc.StatsHandler(otelgrpc.NewServerHandler())}
+	if tlsConfig != nil {
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(grpcOptions...)
+	temperatureService := service.NewTemperatureService(server)
+	temperatureService.MaxCeps = viper.GetInt("GRPC_MAX_CEPS")
+	pb.RegisterTemperatureServiceServer(grpcServer, temperatureService)
+
+	// Servidores HTTP e gRPC executados até o sinal de término, seguidos do graceful shutdown
+	httpServer := &http.Server{
+		Addr:      viper.GetString("HTTP_PORT"),
+		Handler:   router,
+		TLSConfig: tlsConfig,
+	}
+	if err := bootstrap.Run(ctx, bootstrap.HTTP(httpServer), bootstrap.GRPC(viper.GetString("GRPC_PORT"), grpcServer)); err != nil {
+		log.Println(err)
+	}
+}
 
-	// Select para realizar o gracefull shutdown
-	select {
-	case <-sigCh:
-		log.Println("Shutting down gracefully, CTRL+C pressed...")
-	case <-ctx.Done():
-		log.Println("Shutting down due to other reason...")
+// Função que monta os dados para a criação do servidor a partir das variáveis de ambiente
+func newTemplateData(tracer trace.Tracer) *handlers.TemplateOtelData {
+	return &handlers.TemplateOtelData{
+		RequestNameOTEL: viper.GetString("REQUEST_NAME_OTEL"),
+		OTELTracer:      tracer,
+		ViaCEPURL:       viper.GetString("VIACEP_URL"),
+		WeatherAPIURL:   viper.GetString("WEATHERAPI_URL"),
+		WeatherAPIKey:   viper.GetString("WEATHERAPI_KEY"),
+		CepStoreMode:    viper.GetString("CEP_STORE_MODE"),
+
+		WeatherProviders:             splitList(viper.GetString("WEATHER_PROVIDERS")),
+		WeatherStrategy:              viper.GetString("WEATHER_STRATEGY"),
+		WeatherDisagreementThreshold: viper.GetFloat64("WEATHER_DISAGREEMENT_THRESHOLD"),
+		OpenMeteoGeocodingURL:        viper.GetString("OPEN_METEO_GEOCODING_URL"),
+		OpenMeteoURL:                 viper.GetString("OPEN_METEO_URL"),
+		CepCacheTTL:                  viper.GetDuration("CEP_CACHE_TTL"),
+		CepCacheSize:                 viper.GetInt("CEP_CACHE_SIZE"),
 	}
+}
 
-	// Create a timeout context for the graceful shutdown
-	_, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer shutdownCancel()
+// Função que separa a lista de valores por vírgula, ignorando os espaços e os itens vazios
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // go mod init github.com/wandermaia/desafio-opentelemetry
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/webserver/handlers"
+	"go.opentelemetry.io/otel"
+)
+
+// As variáveis de ambiente devem sobrescrever os valores padrão definidos no init
+func TestConfiguracaoPorVariaveisDeAmbiente(t *testing.T) {
+	t.Setenv("WEATHER_PROVIDERS", "weatherapi, open-meteo")
+	t.Setenv("WEATHER_STRATEGY", "average")
+	t.Setenv("CEP_CACHE_TTL", "2m")
+	t.Setenv("CEP_CACHE_SIZE", "50")
+	t.Setenv("VIACEP_URL", "http://viacep.interno")
+
+	templateData := newTemplateData(otel.Tracer("microservice-tracer-mock"))
+	assert.Equal(t, []string{"weatherapi", "open-meteo"}, templateData.WeatherProviders)
+	assert.Equal(t, "average", templateData.WeatherStrategy)
+	assert.Equal(t, 2*time.Minute, templateData.CepCacheTTL)
+	assert.Equal(t, 50, templateData.CepCacheSize)
+	assert.Equal(t, "http://viacep.interno", templateData.ViaCEPURL)
+}
+
+// Sem as variáveis de ambiente, os valores padrão são utilizados
+func TestConfiguracaoPadrao(t *testing.T) {
+	templateData := newTemplateData(otel.Tracer("microservice-tracer-mock"))
+	assert.Equal(t, handlers.DefaultViaCEPURL, templateData.ViaCEPURL)
+	assert.Equal(t, []string{handlers.DefaultWeatherProviders}, templateData.WeatherProviders)
+	assert.Equal(t, handlers.DefaultWeatherStrategy, templateData.WeatherStrategy)
+}
@@ -0,0 +1,103 @@
+// Package address consulta o endereço dos códigos postais dos países atendidos pelo service-b
+// além do Brasil, onde o endereço é consultado no ViaCEP e na base local de CEPs.
+package address
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+)
+
+// Erro retornado quando o código postal não existe
+var ErrNotFound = errors.New("postal code not found")
+
+// Interface dos provedores de endereço de um país
+type Provider interface {
+	Name() string
+	Lookup(ctx context.Context, code cep.PostalCode) (*domain.Endereco, error)
+}
+
+// Nome do provedor Zippopotam
+const ProviderZippopotam = "zippopotam"
+
+// Endereço padrão do Zippopotam, que não exige chave
+const DefaultZippopotamURL = "https://api.zippopotam.us/"
+
+// Provedor que consulta os códigos postais no Zippopotam (https://zippopotam.us). A resposta
+// contém a localidade e a região, sem o logradouro. Sem Client, é utilizado o http.DefaultClient.
+type Zippopotam struct {
+	URL    string
+	Client *http.Client
+}
+
+// Resposta da consulta do Zippopotam
+type zippopotamResponse struct {
+	Places []struct {
+		PlaceName string `json:"place name"`
+		State     string `json:"state"`
+	} `json:"places"`
+}
+
+func (p *Zippopotam) Name() string {
+	return ProviderZippopotam
+}
+
+// Função que consulta o endereço do código postal. O Zippopotam responde 404 para os códigos inexistentes.
+func (p *Zippopotam) Lookup(ctx context.Context, code cep.PostalCode) (*domain.Endereco, error) {
+	u := p.URL + strings.ToLower(code.Country) + "/" + url.PathEscape(zippopotamCode(code))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, &problem.UpstreamError{Service: ProviderZippopotam, StatusCode: resp.StatusCode}
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var data zippopotamResponse
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	if len(data.Places) == 0 {
+		return nil, ErrNotFound
+	}
+	return &domain.Endereco{
+		Cep:    code.Formatted(),
+		Cidade: data.Places[0].PlaceName,
+		Uf:     data.Places[0].State,
+		Pais:   code.Country,
+	}, nil
+}
+
+// Código no formato do Zippopotam: Portugal utiliza o formato 1234-567 e a Argentina somente os
+// 4 dígitos do código antigo, presentes também no CPA.
+func zippopotamCode(code cep.PostalCode) string {
+	if code.Country == cep.CountryArgentina && len(code.Code) == 8 {
+		return code.Code[1:5]
+	}
+	return code.Formatted()
+}
@@ -0,0 +1,54 @@
+package address
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+)
+
+// O Zippopotam utiliza o formato 1234-567 em Portugal e os 4 dígitos do CPA na Argentina
+func TestZippopotam(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pt/1000-001", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"post code": "1000-001", "country": "Portugal", "places": [{"place name": "Lisboa", "state": "Lisboa"}]}`))
+	})
+	mux.HandleFunc("/ar/1425", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"post code": "1425", "country": "Argentina", "places": [{"place name": "Buenos Aires", "state": "Ciudad Autónoma de Buenos Aires"}]}`))
+	})
+	mux.HandleFunc("/ar/9999", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	provider := &Zippopotam{URL: server.URL + "/"}
+
+	lookup := func(pais, code string) (*domain.Endereco, error) {
+		postalCode, err := cep.ParsePostalCode(pais, code)
+		require.NoError(t, err)
+		return provider.Lookup(context.Background(), postalCode)
+	}
+
+	endereco, err := lookup("PT", "1000001")
+	require.NoError(t, err)
+	assert.Equal(t, domain.Endereco{Cep: "1000-001", Cidade: "Lisboa", Uf: "Lisboa", Pais: "PT"}, *endereco)
+
+	endereco, err = lookup("AR", "C1425ABC")
+	require.NoError(t, err)
+	assert.Equal(t, "C1425ABC", endereco.Cep)
+	assert.Equal(t, "Buenos Aires", endereco.Cidade)
+
+	_, err = lookup("PT", "4470-558")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = lookup("AR", "9999")
+	var upstreamErr *problem.UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, ProviderZippopotam, upstreamErr.Service)
+}
@@ -0,0 +1,285 @@
+package cepstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	_ "modernc.org/sqlite"
+)
+
+// Formatos aceitos na importação
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Erros retornados pela base local
+var (
+	ErrNotFound      = errors.New("cep not found in the local store")
+	ErrInvalidFormat = errors.New(`format must be "csv" or "json"`)
+)
+
+// Struct de um CEP da base local. As tags json seguem os nomes dos campos do ViaCEP,
+// permitindo importar os dumps no mesmo formato.
+type Record struct {
+	Cep        string `json:"cep"`
+	Logradouro string `json:"logradouro"`
+	Bairro     string `json:"bairro"`
+	Localidade string `json:"localidade"`
+	Uf         string `json:"uf"`
+	Ibge       string `json:"ibge"`
+}
+
+// Schema da tabela de CEPs
+const schema = `
+CREATE TABLE IF NOT EXISTS ceps (
+	cep        TEXT PRIMARY KEY,
+	logradouro TEXT NOT NULL DEFAULT '',
+	bairro     TEXT NOT NULL DEFAULT '',
+	localidade TEXT NOT NULL,
+	uf         TEXT NOT NULL,
+	ibge       TEXT NOT NULL DEFAULT ''
+) WITHOUT ROWID;
+`
+
+// Struct da base local de CEPs no SQLite
+type Store struct {
+	db     *sql.DB
+	tracer trace.Tracer
+}
+
+// Função que abre (ou cria) a base local no caminho informado
+func Open(path string, tracer trace.Tracer) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cep store: %w", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create cep store schema: %w", err)
+	}
+	return &Store{db: db, tracer: tracer}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Função que busca o CEP na base local. Quando o CEP exato não existe, é realizada a busca pela faixa
+// do CEP (mesmos 5 primeiros dígitos): se todos os CEPs da faixa pertencem à mesma cidade, o CEP é
+// tratado como um CEP genérico da cidade (ex.: 32450000), sem logradouro e bairro.
+func (s *Store) Lookup(ctx context.Context, cep string) (record *Record, err error) {
+	ctx, span := s.tracer.Start(ctx, "SQLite SELECT ceps",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "sqlite"),
+			attribute.String("db.operation", "SELECT"),
+			attribute.String("db.sql.table", "ceps"),
+			attribute.String("cep", cep),
+		),
+	)
+	defer func() {
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	// CEPs inválidos não existem na base
+	cep, err = normalize(cep)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	record = &Record{}
+	err = s.db.QueryRowContext(ctx,
+		"SELECT cep, logradouro, bairro, localidade, uf, ibge FROM ceps WHERE cep = ?", cep,
+	).Scan(&record.Cep, &record.Logradouro, &record.Bairro, &record.Localidade, &record.Uf, &record.Ibge)
+	if err == nil {
+		span.SetAttributes(attribute.String("cepstore.match", "exact"))
+		return record, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// Busca pela faixa do CEP
+	prefix := cep[:5]
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT DISTINCT localidade, uf, ibge FROM ceps WHERE cep BETWEEN ? AND ? LIMIT 2",
+		prefix+"000", prefix+"999",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cities []Record
+	for rows.Next() {
+		city := Record{Cep: cep}
+		if err := rows.Scan(&city.Localidade, &city.Uf, &city.Ibge); err != nil {
+			return nil, err
+		}
+		cities = append(cities, city)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(cities) != 1 {
+		return nil, ErrNotFound
+	}
+	span.SetAttributes(attribute.String("cepstore.match", "range"))
+	return &cities[0], nil
+}
+
+// Função que importa os CEPs do dump (CSV ou JSON) em uma única transação. Os CEPs existentes são
+// atualizados. Com replace, a base é substituída pelo conteúdo do dump (refresh completo).
+// Retorna a quantidade de CEPs importados.
+func (s *Store) Import(ctx context.Context, r io.Reader, format string, replace bool) (count int, err error) {
+	ctx, span := s.tracer.Start(ctx, "Importação Base CEP",
+		trace.WithAttributes(attribute.String("format", format), attribute.Bool("replace", replace)),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Int("records", count))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	var next func() (*Record, error)
+	switch format {
+	case FormatCSV:
+		next, err = csvReader(r)
+	case FormatJSON:
+		next, err = jsonReader(r)
+	default:
+		err = ErrInvalidFormat
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM ceps"); err != nil {
+			return 0, err
+		}
+	}
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO ceps (cep, logradouro, bairro, localidade, uf, ibge) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (cep) DO UPDATE SET logradouro = excluded.logradouro, bairro = excluded.bairro,
+		localidade = excluded.localidade, uf = excluded.uf, ibge = excluded.ibge`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for line := 1; ; line++ {
+		record, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("record %d: %w", line, err)
+		}
+		record.Cep, err = normalize(record.Cep)
+		if err != nil {
+			return 0, fmt.Errorf("record %d: %w", line, err)
+		}
+		if record.Localidade == "" || record.Uf == "" {
+			return 0, fmt.Errorf("record %d: cep, localidade and uf are required", line)
+		}
+		if _, err := stmt.ExecContext(ctx, record.Cep, record.Logradouro, record.Bairro, record.Localidade, record.Uf, record.Ibge); err != nil {
+			return 0, fmt.Errorf("record %d: %w", line, err)
+		}
+		count++
+	}
+	return count, tx.Commit()
+}
+
+// Função que retorna a quantidade de CEPs da base
+func (s *Store) Count(ctx context.Context) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM ceps").Scan(&count)
+	return count, err
+}
+
+// Função que valida e remove a formatação do CEP (ex.: 32450-000 vira 32450000)
+func normalize(valor string) (string, error) {
+	c, err := cep.Parse(valor)
+	return c.String(), err
+}
+
+// Leitor do CSV. A primeira linha deve conter os nomes das colunas, em qualquer ordem.
+func csvReader(r io.Reader) (func() (*Record, error), error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"cep", "localidade", "uf"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv header must contain the %s column", required)
+		}
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	return func() (*Record, error) {
+		row, err := reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		return &Record{
+			Cep:        field(row, "cep"),
+			Logradouro: field(row, "logradouro"),
+			Bairro:     field(row, "bairro"),
+			Localidade: field(row, "localidade"),
+			Uf:         field(row, "uf"),
+			Ibge:       field(row, "ibge"),
+		}, nil
+	}, nil
+}
+
+// Leitor do JSON. O dump deve ser um array de objetos no formato do ViaCEP.
+func jsonReader(r io.Reader) (func() (*Record, error), error) {
+	decoder := json.NewDecoder(r)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, errors.New("json dump must be an array of objects")
+	}
+	return func() (*Record, error) {
+		if !decoder.More() {
+			return nil, io.EOF
+		}
+		var record Record
+		if err := decoder.Decode(&record); err != nil {
+			return nil, err
+		}
+		return &record, nil
+	}, nil
+}
@@ -0,0 +1,103 @@
+package cepstore
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func newTestStore(t *testing.T) *Store {
+	store, err := Open(filepath.Join(t.TempDir(), "ceps.db"), otel.Tracer("test"))
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+const dumpCSV = `uf,cep,localidade,logradouro,bairro,ibge
+MG,32450-001,Ibirité,Rua A,Centro,3129806
+MG,32450-150,Ibirité,Rua B,Durval de Barros,3129806
+SP,01001-000,São Paulo,Praça da Sé,Sé,3550308
+SP,01001-001,São Paulo,Praça da Sé,Sé,3550308
+MG,30110-001,Belo Horizonte,Rua C,Funcionários,3106200
+MG,30110-900,Contagem,Rua D,Centro,3118601
+`
+
+// A importação do CSV deve aceitar as colunas em qualquer ordem e CEPs formatados
+func TestImportCSVELookup(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	count, err := store.Import(ctx, strings.NewReader(dumpCSV), FormatCSV, false)
+	require.NoError(t, err)
+	assert.Equal(t, 6, count)
+
+	// CEP exato
+	record, err := store.Lookup(ctx, "01001000")
+	require.NoError(t, err)
+	assert.Equal(t, Record{Cep: "01001000", Logradouro: "Praça da Sé", Bairro: "Sé", Localidade: "São Paulo", Uf: "SP", Ibge: "3550308"}, *record)
+
+	// CEP genérico da cidade, encontrado pela faixa
+	record, err = store.Lookup(ctx, "32450000")
+	require.NoError(t, err)
+	assert.Equal(t, Record{Cep: "32450000", Localidade: "Ibirité", Uf: "MG", Ibge: "3129806"}, *record)
+
+	// Faixa com mais de uma cidade não é resolvida
+	_, err = store.Lookup(ctx, "30110000")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = store.Lookup(ctx, "99999999")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// O JSON segue o formato do ViaCEP. Com replace, a base é substituída.
+func TestImportJSONReplace(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	_, err := store.Import(ctx, strings.NewReader(dumpCSV), FormatCSV, false)
+	require.NoError(t, err)
+
+	dump := `[
+		{"cep": "32450-001", "logradouro": "Rua Nova", "bairro": "Centro", "localidade": "Ibirité", "uf": "MG", "ibge": "3129806"},
+		{"cep": "20040-002", "logradouro": "Rua da Assembleia", "bairro": "Centro", "localidade": "Rio de Janeiro", "uf": "RJ", "ibge": "3304557"}
+	]`
+	count, err := store.Import(ctx, strings.NewReader(dump), FormatJSON, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	total, err := store.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	record, err := store.Lookup(ctx, "32450-001")
+	require.NoError(t, err)
+	assert.Equal(t, "Rua Nova", record.Logradouro)
+	_, err = store.Lookup(ctx, "01001000")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// Um dump inválido não deve alterar a base
+func TestImportInvalido(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	_, err := store.Import(ctx, strings.NewReader(dumpCSV), FormatCSV, false)
+	require.NoError(t, err)
+
+	_, err = store.Import(ctx, strings.NewReader("cep,localidade,uf\n32450001,Ibirité,MG\n123,Ibirité,MG\n"), FormatCSV, true)
+	assert.ErrorContains(t, err, "record 2")
+	_, err = store.Import(ctx, strings.NewReader("cep,logradouro\n32450001,Rua A\n"), FormatCSV, false)
+	assert.ErrorContains(t, err, "localidade")
+	_, err = store.Import(ctx, strings.NewReader(`{"cep": "32450001"}`), FormatJSON, false)
+	assert.Error(t, err)
+	_, err = store.Import(ctx, strings.NewReader(""), "xml", false)
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	total, err := store.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 6, total)
+}
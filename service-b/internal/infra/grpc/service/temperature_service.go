@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+
+	v1 "github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion/v1"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/pb"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/webserver/handlers"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domínio utilizado no ErrorInfo dos erros gRPC
+const ErrorDomain = "service-b"
+
+// Quantidade de CEPs consultados em paralelo pelo GetMany
+const getManyConcurrency = 4
+
+// Quantidade máxima padrão de CEPs por requisição do GetMany
+const DefaultMaxCeps = 100
+
+// Struct que implementa o TemperatureService. A busca é a mesma utilizada pelo handler HTTP,
+// para que os dois protocolos gerem respostas e traces equivalentes.
+type TemperatureService struct {
+	pb.UnimplementedTemperatureServiceServer
+	Webserver *handlers.Webserver
+	// Quantidade máxima de CEPs por requisição do GetMany
+	MaxCeps int
+}
+
+// Função que cria o serviço gRPC a partir do webserver
+func NewTemperatureService(webserver *handlers.Webserver) *TemperatureService {
+	return &TemperatureService{
+		Webserver: webserver,
+		MaxCeps:   DefaultMaxCeps,
+	}
+}
+
+// Consulta a temperatura atual da cidade do código postal do país informado (vazio é BR)
+func (s *TemperatureService) GetByCEP(ctx context.Context, in *pb.GetByCEPRequest) (*pb.ClimaCidade, error) {
+	clima, details := s.busca(ctx, in.GetCountry(), in.GetCep())
+	if details != nil {
+		return nil, toStatus(details).Err()
+	}
+
+	// Retornando a resposta
+	_, spanEnviandoResposta := s.tracer().Start(ctx, "Enviando resposta")
+	defer spanEnviandoResposta.End()
+	return toPB(clima), nil
+}
+
+// Consulta vários CEPs em paralelo, enviando cada resultado no stream assim que fica pronto
+func (s *TemperatureService) GetMany(in *pb.GetManyRequest, stream pb.TemperatureService_GetManyServer) error {
+	ctx := stream.Context()
+	if len(in.GetCeps()) > s.MaxCeps {
+		return status.Error(grpccodes.InvalidArgument, fmt.Sprintf("the request must contain at most %d zipcodes", s.MaxCeps))
+	}
+
+	var mu sync.Mutex
+	var sendErr error
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, getManyConcurrency)
+
+	for _, cep := range in.GetCeps() {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(cep string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp := &pb.GetManyResponse{Cep: cep}
+			clima, details := s.busca(ctx, in.GetCountry(), cep)
+			if details != nil {
+				resp.Result = &pb.GetManyResponse_Error{Error: &pb.Error{
+					Code:    int32(toStatus(details).Code()),
+					Message: details.Message,
+					Detail:  details.Detail,
+					TraceId: details.TraceID,
+				}}
+			} else {
+				resp.Result = &pb.GetManyResponse_Clima{Clima: toPB(clima)}
+			}
+
+			// O Send não pode ser chamado em paralelo
+			mu.Lock()
+			defer mu.Unlock()
+			if sendErr == nil {
+				_, spanEnviandoResposta := s.tracer().Start(ctx, "Enviando resposta")
+				sendErr = stream.Send(resp)
+				spanEnviandoResposta.End()
+			}
+		}(cep)
+	}
+	wg.Wait()
+	return sendErr
+}
+
+// Função que executa a busca dentro do span inicial, da mesma forma que o handler HTTP.
+// Em caso de erro, retorna o problema já com o trace_id preenchido e traduzido para o idioma
+// recebido no metadata accept-language.
+func (s *TemperatureService) busca(ctx context.Context, pais, cep string) (*handlers.ClimaCidade, *problem.Details) {
+	ctx = i18n.IncomingContext(ctx)
+
+	// Criação de span inicial
+	ctx, span := s.tracer().Start(ctx, "Início Processamento "+s.Webserver.OtelData.RequestNameOTEL)
+	defer span.End()
+
+	clima, err := s.Webserver.BuscaTemperatura(ctx, pais, cep)
+	if err != nil {
+		details := handlers.ToProblem(cep, err)
+		span.SetStatus(codes.Error, details.Message)
+		log.Printf("%s: %s: %s", details.Message, cep, err)
+		details.Localize(i18n.Language(ctx))
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+			details.TraceID = spanContext.TraceID().String()
+		}
+		return nil, details
+	}
+	return clima, nil
+}
+
+func (s *TemperatureService) tracer() trace.Tracer {
+	return s.Webserver.OtelData.OTELTracer
+}
+
+// Função que converte o problema HTTP no status gRPC equivalente. O título, o detalhe e o
+// trace_id seguem no ErrorInfo para que o cliente consiga montar a mesma resposta do HTTP.
+func toStatus(details *problem.Details) *status.Status {
+	st := status.New(grpcCode(details.Status), details.Message)
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: details.Type,
+		Domain: ErrorDomain,
+		Metadata: map[string]string{
+			"title":    details.Title,
+			"detail":   details.Detail,
+			"trace_id": details.TraceID,
+		},
+	})
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// Mapeamento entre os status HTTP e os códigos gRPC
+func grpcCode(httpStatus int) grpccodes.Code {
+	switch httpStatus {
+	case http.StatusUnprocessableEntity:
+		return grpccodes.InvalidArgument
+	case http.StatusNotFound:
+		return grpccodes.NotFound
+	case http.StatusServiceUnavailable:
+		return grpccodes.Unavailable
+	case http.StatusGatewayTimeout:
+		return grpccodes.DeadlineExceeded
+	}
+	return grpccodes.Internal
+}
+
+// O contrato gRPC segue a v1 da API, então a conversão é a mesma das respostas HTTP da v1 em protobuf
+func toPB(clima *handlers.ClimaCidade) *pb.ClimaCidade {
+	return v1.FromDomain(clima).ToProto().(*pb.ClimaCidade)
+}
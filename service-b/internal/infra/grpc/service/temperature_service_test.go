@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/pb"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/webserver/handlers"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Nome do tracer utilizado pelo webserver nos testes
+const tracerName = "microservice-tracer-mock"
+
+// Server mock para simular o ViaCEP e a WeatherAPI
+func newUpstreamMock(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/32450000/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cep": "32450-000", "localidade": "Ibirité", "uf": "MG"}`))
+	})
+	mux.HandleFunc("/ws/99999999/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"erro": true}`))
+	})
+	mux.HandleFunc("/v1/current.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"current": {"temp_c": 28.5, "temp_f": 83.3, "condition": {"text": "Ensolarado"}}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Sobe o webserver e o servidor gRPC em memória, registrando os spans no recorder
+func newTestServer(t *testing.T) (*handlers.Webserver, pb.TemperatureServiceClient, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	upstream := newUpstreamMock(t)
+	webserver, err := handlers.NewServer(&handlers.TemplateOtelData{
+		RequestNameOTEL: "service-b-request",
+		OTELTracer:      tp.Tracer(tracerName),
+		ViaCEPURL:       upstream.URL + "/ws/",
+		WeatherAPIURL:   upstream.URL + "/v1/",
+	})
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithTracerProvider(tp))))
+	pb.RegisterTemperatureServiceServer(grpcServer, NewTemperatureService(webserver))
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return webserver, pb.NewTemperatureServiceClient(conn), recorder
+}
+
+func TestGetByCEP(t *testing.T) {
+	_, client, _ := newTestServer(t)
+
+	clima, err := client.GetByCEP(context.Background(), &pb.GetByCEPRequest{Cep: "32450000"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ibirité", clima.GetCity())
+	assert.Equal(t, 28.5, clima.GetTempC())
+	assert.Equal(t, 301.5, clima.GetTempK())
+	assert.Equal(t, "Ensolarado", clima.GetCondition())
+	assert.Equal(t, "MG", clima.GetAddress().GetState())
+	assert.Equal(t, "BR", clima.GetAddress().GetCountry())
+}
+
+// Os erros devem ser convertidos nos códigos gRPC equivalentes, com o ErrorInfo preenchido
+func TestGetByCEPErros(t *testing.T) {
+	_, client, _ := newTestServer(t)
+
+	testes := []struct {
+		cep     string
+		country string
+		code    grpccodes.Code
+	}{
+		{"324500000", "", grpccodes.InvalidArgument},
+		{"99999999", "", grpccodes.NotFound},
+		{"1000-001", "US", grpccodes.InvalidArgument},
+	}
+
+	for _, tt := range testes {
+		t.Run(tt.cep, func(t *testing.T) {
+			_, err := client.GetByCEP(context.Background(), &pb.GetByCEPRequest{Cep: tt.cep, Country: tt.country})
+			st := status.Convert(err)
+			assert.Equal(t, tt.code, st.Code())
+
+			assert.Len(t, st.Details(), 1)
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			assert.True(t, ok)
+			assert.Equal(t, ErrorDomain, info.GetDomain())
+			assert.NotEmpty(t, info.GetMetadata()["trace_id"])
+		})
+	}
+}
+
+// O idioma recebido no metadata accept-language traduz a mensagem e o detalhe do erro
+func TestGetByCEPIdioma(t *testing.T) {
+	_, client, _ := newTestServer(t)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), i18n.MetadataKey, i18n.PortugueseBR)
+	_, err := client.GetByCEP(ctx, &pb.GetByCEPRequest{Cep: "99999999"})
+	st := status.Convert(err)
+	assert.Equal(t, "CEP não encontrado", st.Message())
+	info := st.Details()[0].(*errdetails.ErrorInfo)
+	assert.Equal(t, "CEP não encontrado", info.GetMetadata()["title"])
+	assert.Equal(t, "o CEP 99999999 não existe", info.GetMetadata()["detail"])
+}
+
+// O GetMany deve enviar um resultado por CEP, sem interromper o stream nos erros
+func TestGetMany(t *testing.T) {
+	_, client, _ := newTestServer(t)
+
+	stream, err := client.GetMany(context.Background(), &pb.GetManyRequest{Ceps: []string{"32450000", "99999999", "324500000"}})
+	assert.NoError(t, err)
+
+	resultados := map[string]*pb.GetManyResponse{}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		resultados[resp.GetCep()] = resp
+	}
+
+	assert.Len(t, resultados, 3)
+	assert.Equal(t, "Ibirité", resultados["32450000"].GetClima().GetCity())
+	assert.Equal(t, int32(grpccodes.NotFound), resultados["99999999"].GetError().GetCode())
+	assert.Equal(t, int32(grpccodes.InvalidArgument), resultados["324500000"].GetError().GetCode())
+	assert.Equal(t, "can not find zipcode", resultados["99999999"].GetError().GetMessage())
+}
+
+// Requisições com mais CEPs que o limite devem ser rejeitadas antes de qualquer consulta
+func TestGetManyLimite(t *testing.T) {
+	_, client, recorder := newTestServer(t)
+
+	ceps := make([]string, DefaultMaxCeps+1)
+	for i := range ceps {
+		ceps[i] = "32450000"
+	}
+	stream, err := client.GetMany(context.Background(), &pb.GetManyRequest{Ceps: ceps})
+	assert.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, grpccodes.InvalidArgument, status.Code(err))
+	for _, span := range recorder.Ended() {
+		assert.NotContains(t, span.Name(), "Início Processamento")
+	}
+}
+
+// As chamadas via HTTP e via gRPC devem gerar os mesmos spans da aplicação
+func TestTracesEquivalentes(t *testing.T) {
+	webserver, client, recorder := newTestServer(t)
+
+	req, _ := http.NewRequest("GET", "/32450000", nil)
+	webserver.CreateServer().ServeHTTP(httptest.NewRecorder(), req)
+	spansHTTP := recorder.Ended()
+
+	_, err := client.GetByCEP(context.Background(), &pb.GetByCEPRequest{Cep: "32450000"})
+	assert.NoError(t, err)
+	spansGRPC := recorder.Ended()[len(spansHTTP):]
+
+	assert.Equal(t, []string{
+		"Busca CEP",
+		"Busca Temperatura",
+		"Enviando resposta",
+		"Início Processamento service-b-request",
+		"Provedor weatherapi",
+		"Validar Formatação CEP",
+	}, nomesDosSpans(spansHTTP))
+	assert.Equal(t, nomesDosSpans(spansHTTP), nomesDosSpans(spansGRPC))
+}
+
+// Retorna os nomes ordenados dos spans gerados pelo tracer da aplicação
+func nomesDosSpans(spans []sdktrace.ReadOnlySpan) []string {
+	var nomes []string
+	for _, span := range spans {
+		if span.InstrumentationScope().Name == tracerName {
+			nomes = append(nomes, span.Name())
+		}
+	}
+	sort.Strings(nomes)
+	return nomes
+}
@@ -0,0 +1,82 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+)
+
+// Nome do provedor Open-Meteo
+const ProviderOpenMeteo = "openmeteo"
+
+// Endereços padrão das APIs do Open-Meteo, que não exigem chave
+const (
+	DefaultOpenMeteoGeocodingURL = "https://geocoding-api.open-meteo.com/v1/"
+	DefaultOpenMeteoURL          = "https://api.open-meteo.com/v1/"
+)
+
+// Erro retornado quando o Open-Meteo não encontra a cidade
+var ErrCityNotFound = errors.New("city not found")
+
+// Provedor que consulta a temperatura no Open-Meteo (https://open-meteo.com). A cidade é
+// convertida em coordenadas na API de geocoding antes da consulta da previsão.
+type OpenMeteo struct {
+	GeocodingURL string
+	URL          string
+	Client       *http.Client
+}
+
+// Resposta da API de geocoding do Open-Meteo
+type openMeteoGeocoding struct {
+	Results []struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	} `json:"results"`
+}
+
+// Resposta da API de previsão do Open-Meteo
+type openMeteoForecast struct {
+	Current struct {
+		Temperature float64 `json:"temperature_2m"`
+	} `json:"current"`
+}
+
+func (p *OpenMeteo) Name() string {
+	return ProviderOpenMeteo
+}
+
+// Função que consulta a temperatura atual da cidade, buscando as coordenadas somente no país
+// informado e no idioma do contexto. O Open-Meteo não retorna a condição em texto.
+func (p *OpenMeteo) Current(ctx context.Context, local Location) (*Reading, error) {
+	body, err := get(ctx, p.Client, ProviderOpenMeteo, p.GeocodingURL+"search?name="+url.QueryEscape(local.Cidade)+"&count=1&language="+i18n.Base(i18n.Language(ctx))+"&countryCode="+url.QueryEscape(local.Pais)+"&format=json")
+	if err != nil {
+		return nil, err
+	}
+	var geocoding openMeteoGeocoding
+	if err := json.Unmarshal(body, &geocoding); err != nil {
+		return nil, err
+	}
+	if len(geocoding.Results) == 0 {
+		return nil, ErrCityNotFound
+	}
+
+	coordenadas := geocoding.Results[0]
+	body, err = get(ctx, p.Client, ProviderOpenMeteo, p.URL+"forecast?latitude="+strconv.FormatFloat(coordenadas.Latitude, 'f', -1, 64)+
+		"&longitude="+strconv.FormatFloat(coordenadas.Longitude, 'f', -1, 64)+"&current=temperature_2m")
+	if err != nil {
+		return nil, err
+	}
+	var forecast openMeteoForecast
+	if err := json.Unmarshal(body, &forecast); err != nil {
+		return nil, err
+	}
+	return &Reading{
+		TempC: forecast.Current.Temperature,
+		TempF: arredonda(Fahrenheit(forecast.Current.Temperature)),
+	}, nil
+}
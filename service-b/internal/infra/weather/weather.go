@@ -0,0 +1,270 @@
+// Package weather consulta a temperatura atual de uma cidade em um ou mais provedores de clima.
+// Os provedores são consultados em paralelo e o resultado é a primeira resposta com sucesso ou
+// a mediana/média das leituras, de acordo com a estratégia configurada.
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+)
+
+// Estratégias de combinação das leituras dos provedores
+const (
+	// A primeira leitura com sucesso é utilizada e as demais consultas são canceladas
+	StrategyFirst = "first"
+	// A temperatura é a mediana das leituras com sucesso
+	StrategyMedian = "median"
+	// A temperatura é a média das leituras com sucesso
+	StrategyAverage = "average"
+)
+
+// Diferença padrão, em graus Celsius, entre a maior e a menor leitura a partir da qual os
+// provedores são considerados divergentes
+const DefaultDisagreementThreshold = 2.0
+
+// Erro retornado quando a configuração dos provedores é inválida
+var ErrInvalidConfig = errors.New("invalid weather config")
+
+// Leitura da temperatura atual retornada por um provedor
+type Reading struct {
+	TempC    float64
+	TempF    float64
+	Condicao string
+}
+
+// Localidade consultada: a cidade, a região e o país (código ISO 3166-1 alpha-2, ex.: BR)
+// do endereço do código postal
+type Location struct {
+	Cidade string
+	Uf     string
+	Pais   string
+}
+
+// Interface dos provedores de clima
+type Provider interface {
+	Name() string
+	Current(ctx context.Context, local Location) (*Reading, error)
+}
+
+// Erro retornado quando todos os provedores falham. Contém a falha do primeiro provedor configurado.
+type Error struct {
+	Provider string
+	Err      error
+}
+
+func (e *Error) Error() string {
+	return e.Provider + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Configuração da consulta aos provedores
+type Config struct {
+	Strategy              string
+	DisagreementThreshold float64
+	Tracer                trace.Tracer
+}
+
+// Resultado da consulta. O consenso só é preenchido nas estratégias median e average.
+type Result struct {
+	Reading
+	Consenso *domain.Consenso
+}
+
+// Struct que consulta os provedores configurados
+type Resolver struct {
+	providers []Provider
+	config    Config
+}
+
+// Função que cria o resolver com os provedores informados, na ordem de preferência
+func NewResolver(providers []Provider, config Config) (*Resolver, error) {
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("%w: no weather provider", ErrInvalidConfig)
+	}
+	switch config.Strategy {
+	case "":
+		config.Strategy = StrategyFirst
+	case StrategyFirst, StrategyMedian, StrategyAverage:
+	default:
+		return nil, fmt.Errorf("%w: unknown strategy %q", ErrInvalidConfig, config.Strategy)
+	}
+	if config.DisagreementThreshold <= 0 {
+		config.DisagreementThreshold = DefaultDisagreementThreshold
+	}
+	if config.Tracer == nil {
+		config.Tracer = otel.Tracer("weather")
+	}
+	return &Resolver{providers: providers, config: config}, nil
+}
+
+// Resultado da consulta a um provedor
+type leitura struct {
+	reading *Reading
+	err     error
+}
+
+// Função que consulta todos os provedores em paralelo, cada um com o seu próprio span. Na
+// estratégia first, a primeira leitura com sucesso cancela as demais consultas.
+func (r *Resolver) Current(ctx context.Context, local Location) (*Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	leituras := make([]leitura, len(r.providers))
+	var (
+		once  sync.Once
+		first = -1
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	for i, provider := range r.providers {
+		g.Go(func() error {
+			reading, err := r.consulta(gctx, provider, local)
+			leituras[i] = leitura{reading: reading, err: err}
+			if err == nil && r.config.Strategy == StrategyFirst {
+				once.Do(func() {
+					first = i
+					cancel()
+				})
+			}
+			// As falhas de um provedor não interrompem os demais
+			return nil
+		})
+	}
+	g.Wait()
+
+	if first >= 0 {
+		return &Result{Reading: *leituras[first].reading}, nil
+	}
+	var sucesso []int
+	for i, l := range leituras {
+		if l.err == nil {
+			sucesso = append(sucesso, i)
+		}
+	}
+	if len(sucesso) == 0 {
+		return nil, &Error{Provider: r.providers[0].Name(), Err: leituras[0].err}
+	}
+	return r.consenso(leituras, sucesso), nil
+}
+
+// Consulta um provedor com o seu próprio span
+func (r *Resolver) consulta(ctx context.Context, provider Provider, local Location) (*Reading, error) {
+	ctx, span := r.config.Tracer.Start(ctx, "Provedor "+provider.Name(), trace.WithAttributes(
+		attribute.String("weather.provider", provider.Name()),
+		attribute.String("weather.strategy", r.config.Strategy),
+		attribute.String("weather.country", local.Pais),
+	))
+	defer span.End()
+
+	reading, err := provider.Current(ctx, local)
+	switch {
+	case err == nil:
+		span.SetAttributes(attribute.Float64("weather.temp_c", reading.TempC))
+	case errors.Is(ctx.Err(), context.Canceled):
+		// Outro provedor respondeu primeiro
+		span.SetAttributes(attribute.Bool("weather.cancelled", true))
+	default:
+		span.SetStatus(codes.Error, err.Error())
+		span.RecordError(err)
+	}
+	return reading, err
+}
+
+// Combina as leituras com sucesso na mediana ou na média, registrando a leitura de cada provedor
+// e a divergência entre elas
+func (r *Resolver) consenso(leituras []leitura, sucesso []int) *Result {
+	consenso := &domain.Consenso{Estrategia: r.config.Strategy}
+	temps := make([]float64, 0, len(sucesso))
+	for i, l := range leituras {
+		item := domain.LeituraProvedor{Provedor: r.providers[i].Name()}
+		if l.err != nil {
+			item.Erro = l.err.Error()
+		} else {
+			tempC := l.reading.TempC
+			item.TempC = &tempC
+			temps = append(temps, tempC)
+		}
+		consenso.Provedores = append(consenso.Provedores, item)
+	}
+
+	slices.Sort(temps)
+	consenso.Diferenca = arredonda(temps[len(temps)-1] - temps[0])
+	consenso.Divergencia = consenso.Diferenca > r.config.DisagreementThreshold
+
+	var tempC float64
+	if r.config.Strategy == StrategyMedian {
+		tempC = mediana(temps)
+	} else {
+		for _, t := range temps {
+			tempC += t
+		}
+		tempC /= float64(len(temps))
+	}
+	tempC = arredonda(tempC)
+
+	// A condição é a do provedor preferido entre os que responderam
+	var condicao string
+	for _, i := range sucesso {
+		if condicao = leituras[i].reading.Condicao; condicao != "" {
+			break
+		}
+	}
+	return &Result{
+		Reading:  Reading{TempC: tempC, TempF: arredonda(Fahrenheit(tempC)), Condicao: condicao},
+		Consenso: consenso,
+	}
+}
+
+// Mediana das temperaturas já ordenadas
+func mediana(temps []float64) float64 {
+	meio := len(temps) / 2
+	if len(temps)%2 == 0 {
+		return (temps[meio-1] + temps[meio]) / 2
+	}
+	return temps[meio]
+}
+
+// Arredonda a temperatura para uma casa decimal, como as leituras dos provedores
+func arredonda(temp float64) float64 {
+	return math.Round(temp*10) / 10
+}
+
+// Função que converte a temperatura de Celsius para Fahrenheit
+func Fahrenheit(tempC float64) float64 {
+	return tempC*1.8 + 32
+}
+
+// Função que realiza um GET no provedor e retorna o body. Status diferente de 200
+// é convertido em um problem.UpstreamError.
+func get(ctx context.Context, client *http.Client, service, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &problem.UpstreamError{Service: service, StatusCode: resp.StatusCode}
+	}
+	return io.ReadAll(resp.Body)
+}
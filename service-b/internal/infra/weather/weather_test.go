@@ -0,0 +1,174 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Provedor simulado que responde após o atraso informado, ou no cancelamento do contexto
+type providerMock struct {
+	name  string
+	delay time.Duration
+	tempC float64
+	err   error
+}
+
+func (p *providerMock) Name() string {
+	return p.name
+}
+
+func (p *providerMock) Current(ctx context.Context, local Location) (*Reading, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(p.delay):
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &Reading{TempC: p.tempC, TempF: Fahrenheit(p.tempC), Condicao: "Sol"}, nil
+}
+
+var ibirite = Location{Cidade: "Ibirité", Uf: "MG", Pais: "BR"}
+
+// Na estratégia first, a resposta mais rápida é utilizada e as demais consultas são canceladas.
+// Cada provedor gera o seu próprio span.
+func TestResolverFirst(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	resolver, err := NewResolver([]Provider{
+		&providerMock{name: "lento", delay: time.Second, tempC: 10},
+		&providerMock{name: "rapido", delay: 10 * time.Millisecond, tempC: 28.5},
+	}, Config{Tracer: tracer})
+	require.NoError(t, err)
+
+	start := time.Now()
+	result, err := resolver.Current(context.Background(), ibirite)
+	require.NoError(t, err)
+	assert.Equal(t, 28.5, result.TempC)
+	assert.Nil(t, result.Consenso)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	require.Len(t, spans, 2)
+	assert.Contains(t, spans["Provedor lento"].Attributes(), attribute.Bool("weather.cancelled", true))
+	assert.Contains(t, spans["Provedor rapido"].Attributes(), attribute.Float64("weather.temp_c", 28.5))
+}
+
+// Na mediana e na média, as leituras de todos os provedores são combinadas e a divergência é informada
+func TestResolverConsenso(t *testing.T) {
+	providers := []Provider{
+		&providerMock{name: "a", tempC: 20},
+		&providerMock{name: "b", tempC: 21},
+		&providerMock{name: "c", tempC: 25},
+		&providerMock{name: "d", err: errors.New("falha")},
+	}
+
+	resolver, err := NewResolver(providers, Config{Strategy: StrategyMedian})
+	require.NoError(t, err)
+	result, err := resolver.Current(context.Background(), ibirite)
+	require.NoError(t, err)
+	assert.Equal(t, 21.0, result.TempC)
+	assert.Equal(t, 69.8, result.TempF)
+	assert.Equal(t, "Sol", result.Condicao)
+	require.NotNil(t, result.Consenso)
+	assert.Equal(t, StrategyMedian, result.Consenso.Estrategia)
+	assert.Equal(t, 5.0, result.Consenso.Diferenca)
+	assert.True(t, result.Consenso.Divergencia)
+	require.Len(t, result.Consenso.Provedores, 4)
+	assert.Equal(t, 25.0, *result.Consenso.Provedores[2].TempC)
+	assert.Nil(t, result.Consenso.Provedores[3].TempC)
+	assert.Equal(t, "falha", result.Consenso.Provedores[3].Erro)
+
+	resolver, err = NewResolver(providers, Config{Strategy: StrategyAverage, DisagreementThreshold: 10})
+	require.NoError(t, err)
+	result, err = resolver.Current(context.Background(), ibirite)
+	require.NoError(t, err)
+	assert.Equal(t, 22.0, result.TempC)
+	assert.False(t, result.Consenso.Divergencia)
+}
+
+// Com todos os provedores falhando, o erro do provedor preferido é retornado
+func TestResolverErros(t *testing.T) {
+	unavailable := &problem.UpstreamError{Service: "a", StatusCode: http.StatusServiceUnavailable}
+	for _, strategy := range []string{StrategyFirst, StrategyMedian} {
+		resolver, err := NewResolver([]Provider{
+			&providerMock{name: "a", err: unavailable},
+			&providerMock{name: "b", err: errors.New("falha")},
+		}, Config{Strategy: strategy})
+		require.NoError(t, err)
+		_, err = resolver.Current(context.Background(), ibirite)
+		var weatherErr *Error
+		require.ErrorAs(t, err, &weatherErr)
+		assert.Equal(t, "a", weatherErr.Provider)
+		assert.ErrorIs(t, err, unavailable)
+	}
+
+	_, err := NewResolver(nil, Config{})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	_, err = NewResolver([]Provider{&providerMock{}}, Config{Strategy: "mode"})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+// Consulta das APIs reais simuladas: WeatherAPI e Open-Meteo (geocoding seguido da previsão)
+func TestProviders(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/weatherapi/current.json", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Ibirité, Brazil", r.URL.Query().Get("q"))
+		condicao := map[string]string{"": "Sunny", "es": "Soleado"}[r.URL.Query().Get("lang")]
+		w.Write([]byte(`{"current": {"temp_c": 28.5, "temp_f": 83.3, "condition": {"text": "` + condicao + `"}}}`))
+	})
+	mux.HandleFunc("/geocoding/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") != "Ibirité" || r.URL.Query().Get("countryCode") != "BR" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{"results": [{"latitude": -20.02, "longitude": -44.06}]}`))
+	})
+	mux.HandleFunc("/openmeteo/forecast", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "-20.02", r.URL.Query().Get("latitude"))
+		assert.Equal(t, "temperature_2m", r.URL.Query().Get("current"))
+		w.Write([]byte(`{"current": {"temperature_2m": 27.4}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	weatherAPI := &WeatherAPI{URL: server.URL + "/weatherapi/", Client: server.Client()}
+	reading, err := weatherAPI.Current(context.Background(), ibirite)
+	require.NoError(t, err)
+	assert.Equal(t, Reading{TempC: 28.5, TempF: 83.3, Condicao: "Sunny"}, *reading)
+
+	openMeteo := &OpenMeteo{GeocodingURL: server.URL + "/geocoding/", URL: server.URL + "/openmeteo/", Client: server.Client()}
+	reading, err = openMeteo.Current(context.Background(), ibirite)
+	require.NoError(t, err)
+	assert.Equal(t, Reading{TempC: 27.4, TempF: 81.3}, *reading)
+
+	_, err = openMeteo.Current(context.Background(), Location{Cidade: "Ibirité", Pais: "PT"})
+	assert.ErrorIs(t, err, ErrCityNotFound)
+
+	// A condição é consultada no idioma do contexto
+	reading, err = weatherAPI.Current(i18n.WithLanguage(context.Background(), i18n.Spanish), ibirite)
+	require.NoError(t, err)
+	assert.Equal(t, "Soleado", reading.Condicao)
+
+	weatherAPI.URL = server.URL + "/inexistente/"
+	_, err = weatherAPI.Current(context.Background(), ibirite)
+	var upstreamErr *problem.UpstreamError
+	require.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, http.StatusNotFound, upstreamErr.StatusCode)
+}
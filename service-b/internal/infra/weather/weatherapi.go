@@ -0,0 +1,68 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+)
+
+// Nome do provedor WeatherAPI
+const ProviderWeatherAPI = "weatherapi"
+
+// Provedor que consulta a temperatura na WeatherAPI (https://www.weatherapi.com)
+type WeatherAPI struct {
+	URL    string
+	Key    string
+	Client *http.Client
+}
+
+// Resposta da consulta current.json da WeatherAPI
+type weatherAPIResponse struct {
+	Current struct {
+		TempC     float64 `json:"temp_c"`
+		TempF     float64 `json:"temp_f"`
+		Condition struct {
+			Text string `json:"text"`
+		} `json:"condition"`
+	} `json:"current"`
+}
+
+func (p *WeatherAPI) Name() string {
+	return ProviderWeatherAPI
+}
+
+// Função que consulta a temperatura atual da cidade, com a condição no idioma do contexto. O nome
+// do país é incluído na consulta para diferenciar as cidades homônimas de outros países.
+func (p *WeatherAPI) Current(ctx context.Context, local Location) (*Reading, error) {
+	q := local.Cidade
+	if name := cep.CountryName(local.Pais); name != "" {
+		q += ", " + name
+	}
+	body, err := get(ctx, p.Client, ProviderWeatherAPI, p.URL+"current.json?q="+url.QueryEscape(q)+weatherAPILang(i18n.Language(ctx))+"&key="+p.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	var data weatherAPIResponse
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	return &Reading{
+		TempC:    data.Current.TempC,
+		TempF:    data.Current.TempF,
+		Condicao: data.Current.Condition.Text,
+	}, nil
+}
+
+// Parâmetro lang da WeatherAPI, que utiliza somente o idioma principal. O inglês é o idioma
+// padrão da API e não precisa ser informado.
+func weatherAPILang(lang string) string {
+	if lang == i18n.English {
+		return ""
+	}
+	return "&lang=" + i18n.Base(lang)
+}
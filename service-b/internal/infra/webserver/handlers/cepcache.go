@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+)
+
+// Configuração padrão do cache de CEPs. Os endereços mudam raramente, então o cache evita
+// consultar o ViaCEP a cada requisição e permite iniciar a consulta da temperatura imediatamente.
+const (
+	DefaultCepCacheTTL  = time.Hour
+	DefaultCepCacheSize = 10000
+)
+
+// Cache dos endereços dos CEPs encontrados, por país, com expiração e quantidade máxima de
+// itens. Somente as consultas com sucesso são armazenadas.
+type cepCache struct {
+	ttl  time.Duration
+	size int
+
+	mu    sync.Mutex
+	items map[cep.PostalCode]cepCacheItem
+}
+
+type cepCacheItem struct {
+	endereco  *Endereco
+	expiresAt time.Time
+}
+
+// Função que cria o cache. Tamanho zero desabilita o cache.
+func newCepCache(ttl time.Duration, size int) *cepCache {
+	if size <= 0 {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = DefaultCepCacheTTL
+	}
+	return &cepCache{ttl: ttl, size: size, items: map[cep.PostalCode]cepCacheItem{}}
+}
+
+// Busca o CEP no cache, ignorando os itens expirados
+func (c *cepCache) get(postalCode cep.PostalCode) (*Endereco, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.items[postalCode]
+	if !ok || time.Now().After(item.expiresAt) {
+		delete(c.items, postalCode)
+		return nil, false
+	}
+	return item.endereco, true
+}
+
+// Armazena o CEP. Com o cache cheio, os itens expirados são removidos e, se necessário,
+// o item mais antigo também.
+func (c *cepCache) set(postalCode cep.PostalCode, endereco *Endereco) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if _, ok := c.items[postalCode]; !ok && len(c.items) >= c.size {
+		var oldest cep.PostalCode
+		for key, item := range c.items {
+			if now.After(item.expiresAt) {
+				delete(c.items, key)
+				continue
+			}
+			if oldest.Code == "" || item.expiresAt.Before(c.items[oldest].expiresAt) {
+				oldest = key
+			}
+		}
+		if len(c.items) >= c.size {
+			delete(c.items, oldest)
+		}
+	}
+	c.items[postalCode] = cepCacheItem{endereco: endereco, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/cepstore"
+	"go.opentelemetry.io/otel"
+)
+
+// A base local deve ser utilizada primeiro ou como fallback do ViaCEP, de acordo com o modo
+func TestBuscaTemperaturaBaseLocal(t *testing.T) {
+	store, err := cepstore.Open(filepath.Join(t.TempDir(), "ceps.db"), otel.Tracer("test"))
+	require.NoError(t, err)
+	defer store.Close()
+	_, err = store.Import(context.Background(), strings.NewReader("cep,logradouro,localidade,uf\n32450150,Rua B,Ibirité,MG\n"), cepstore.FormatCSV, false)
+	require.NoError(t, err)
+
+	// ViaCEP indisponível e WeatherAPI respondendo normalmente
+	var viacepCalls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/ws/") {
+			viacepCalls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"current": {"temp_c": 28.5, "temp_f": 83.3}}`))
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		nome        string
+		mode        string
+		cep         string
+		status      int
+		viacepCalls int32
+	}{
+		{"fallback com cep exato", CepStoreModeFallback, "32450150", http.StatusOK, 1},
+		{"fallback com cep genérico", CepStoreModeFallback, "32450000", http.StatusOK, 1},
+		{"fallback sem o cep na base", CepStoreModeFallback, "01001000", http.StatusServiceUnavailable, 1},
+		{"base local primeiro", CepStoreModeFirst, "32450150", http.StatusOK, 0},
+		{"base local primeiro sem o cep", CepStoreModeFirst, "01001000", http.StatusServiceUnavailable, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.nome, func(t *testing.T) {
+			viacepCalls.Store(0)
+			server := novoServer(t, &TemplateOtelData{
+				RequestNameOTEL: "microservice-tracer-mock",
+				OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+				ViaCEPURL:       upstream.URL + "/ws/",
+				WeatherAPIURL:   upstream.URL + "/v1/",
+				CepStore:        store,
+				CepStoreMode:    tt.mode,
+			})
+
+			w := httptest.NewRecorder()
+			server.CreateServer().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+tt.cep, nil))
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.viacepCalls, viacepCalls.Load())
+
+			if tt.status == http.StatusOK {
+				var clima ClimaCidade
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &clima))
+				assert.Equal(t, "Ibirité", clima.Cidade)
+				assert.Equal(t, "MG", clima.Endereco.Uf)
+				assert.Equal(t, tt.cep[:5]+"-"+tt.cep[5:], clima.Endereco.Cep)
+			}
+		})
+	}
+}
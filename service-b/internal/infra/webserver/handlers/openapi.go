@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+	v1 "github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion/v1"
+	v2 "github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion/v2"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/openapi"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/render"
+)
+
+// Função que monta o documento OpenAPI do service-b a partir dos tipos utilizados pelos handlers.
+// Toda rota registrada no CreateServer deve estar descrita aqui (ver TestOpenAPIRotas).
+func (we *Webserver) OpenAPI() *openapi.Document {
+	doc := openapi.New(
+		"service-b",
+		"Orquestração da busca de temperatura por código postal (ViaCEP/Zippopotam + provedores de clima).",
+		"1.0.0",
+	)
+	doc.Servers = []openapi.Server{{URL: "http://localhost:8282", Description: "Ambiente local"}}
+
+	contratos := map[string]*openapi.Schema{
+		apiversion.V1: doc.AddSchema("ClimaCidadeV1", v1.ClimaCidade{}),
+		apiversion.V2: doc.AddSchema("ClimaCidadeV2", v2.ClimaCidade{}),
+	}
+	erro := doc.AddSchema("Problem", problem.Details{})
+
+	parametros := []openapi.Parameter{{
+		Name:        "cep",
+		In:          "path",
+		Description: "Código postal do país. BR: CEP com 8 dígitos, nos formatos 32450000, 32450-000 ou 32.450-000. PT: 1234-567 ou 1234567. AR: CPA (C1425ABC) ou código de 4 dígitos.",
+		Required:    true,
+		Schema:      &openapi.Schema{Type: "string"},
+		Example:     "32450000",
+	}, {
+		Name:        "country",
+		In:          "query",
+		Description: "País do código postal (ISO 3166-1 alpha-2). Padrão: BR.",
+		Schema:      &openapi.Schema{Type: "string", Enum: cep.Countries()},
+		Example:     cep.DefaultCountry,
+	}, {
+		Name:        "Accept-Language",
+		In:          "header",
+		Description: "Idioma das mensagens de erro e da condição do tempo (pt-BR, en ou es). Padrão: en.",
+		Schema:      &openapi.Schema{Type: "string"},
+		Example:     i18n.PortugueseBR,
+	}, {
+		Name:        "format",
+		In:          "query",
+		Description: "Formato da resposta, com prioridade sobre o header Accept: json (padrão), xml, csv ou protobuf.",
+		Schema:      &openapi.Schema{Type: "string", Enum: []string{render.JSON.Name, render.XML.Name, render.CSV.Name, render.Protobuf.Name}},
+	}}
+
+	// Operação da consulta no contrato da versão informada. A rota sem versão (/{cep}) é obsoleta
+	// quando a versão não é informada no header X-API-Version.
+	operacao := func(version string) *openapi.Operation {
+		schema := contratos[version]
+		example := apiversion.ClimaCidade(apiversion.WithVersion(context.Background(), version), &ClimaCidade{Cidade: "Ibirité", TempC: 28.5, TempF: 83.3, TempK: 301.5, Condicao: "Sunny"})
+		var formatos []string
+		for _, format := range render.Available(example) {
+			formatos = append(formatos, format.MediaTypes[0])
+		}
+		responses := map[string]*openapi.Response{
+			"200": formatResponse("Temperatura da cidade", schema, example),
+			"404": problemResponse("CEP não encontrado", erro, problem.ZipcodeNotFound("zipcode 99999999 does not exist")),
+			"406": problemResponse("Formato da resposta não suportado", erro, problem.NotAcceptable("the response is available as "+strings.Join(formatos, ", "))),
+			"422": problemResponse("CEP com formato inválido", erro, problem.InvalidZipcode("the zipcode must contain exactly 8 digits")),
+			"502": problemResponse("Resposta inválida do ViaCEP ou da WeatherAPI", erro, problem.BadGateway("weatherapi responded with status 500")),
+			"503": problemResponse("ViaCEP ou WeatherAPI indisponível", erro, problem.ServiceUnavailable("viacep is unavailable")),
+			"504": problemResponse("ViaCEP ou WeatherAPI não respondeu a tempo", erro, problem.GatewayTimeout("viacep did not respond in time")),
+		}
+		return &openapi.Operation{
+			Summary:     "Consulta a temperatura atual da cidade do CEP (" + version + ")",
+			OperationID: "buscaTemperatura" + strings.ToUpper(version),
+			Tags:        []string{"temperatura"},
+			Parameters:  parametros,
+			Responses:   responses,
+		}
+	}
+	for _, version := range apiversion.Versions() {
+		doc.AddOperation(http.MethodGet, "/"+version+"/{cep}", operacao(version))
+	}
+	semVersao := operacao(apiversion.Default)
+	semVersao.Summary = "Consulta a temperatura atual da cidade do CEP (sem versão)"
+	semVersao.Description = "Rota obsoleta: sem o header X-API-Version, utiliza a v1 e responde com os headers Deprecation e Link (rota /v1/{cep}). Com o header, utiliza a versão informada."
+	semVersao.OperationID = "buscaTemperatura"
+	semVersao.Deprecated = true
+	semVersao.Parameters = append(semVersao.Parameters, openapi.Parameter{
+		Name:        apiversion.Header,
+		In:          "header",
+		Description: "Versão do contrato da resposta (v1 ou v2). Padrão: v1.",
+		Schema:      &openapi.Schema{Type: "string", Enum: apiversion.Versions()},
+		Example:     apiversion.V2,
+	})
+	doc.AddOperation(http.MethodGet, "/{cep}", semVersao)
+
+	return doc
+}
+
+// Resposta de sucesso nos formatos em que o exemplo pode ser representado (ver render.Formats).
+// O exemplo é serializado em CSV; em protobuf, somente o tipo binário é documentado.
+func formatResponse(description string, schema *openapi.Schema, example any) *openapi.Response {
+	response := &openapi.Response{
+		Description: description,
+		Content: map[string]*openapi.MediaType{
+			render.MediaTypeJSON: {Schema: schema, Example: example},
+		},
+	}
+	for _, format := range render.Available(example) {
+		switch format.Name {
+		case render.XML.Name:
+			response.Content[format.MediaTypes[0]] = &openapi.MediaType{Schema: schema}
+		case render.CSV.Name:
+			var csv bytes.Buffer
+			format.Encode(&csv, example)
+			response.Content[format.MediaTypes[0]] = &openapi.MediaType{Schema: &openapi.Schema{Type: "string"}, Example: csv.String()}
+		case render.Protobuf.Name:
+			response.Content[format.MediaTypes[0]] = &openapi.MediaType{Schema: &openapi.Schema{Type: "string", Format: "binary"}}
+		}
+	}
+	return response
+}
+
+// Resposta de erro no formato application/problem+json
+func problemResponse(description string, schema *openapi.Schema, example *problem.Details) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content: map[string]*openapi.MediaType{
+			problem.ContentType: {Schema: schema, Example: example},
+		},
+	}
+}
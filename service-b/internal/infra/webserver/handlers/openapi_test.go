@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+// Rotas de infraestrutura que não fazem parte da API documentada
+var rotasNaoDocumentadas = map[string]bool{
+	"/metrics":       true,
+	"/openapi.json":  true,
+	"/docs":          true,
+	"/docs/assets/*": true,
+}
+
+// Falha caso as rotas do router e as operações do documento OpenAPI estejam diferentes
+func TestOpenAPIRotas(t *testing.T) {
+	server := novoServer(t, &TemplateOtelData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	})
+
+	var rotas []string
+	err := chi.Walk(server.CreateServer(), func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if !rotasNaoDocumentadas[route] {
+			rotas = append(rotas, method+" "+route)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, rotas, server.OpenAPI().Routes())
+}
+
+// O documento deve ser publicado em /openapi.json e a página do Swagger UI em /docs
+func TestOpenAPIHandler(t *testing.T) {
+	router := novoServer(t, &TemplateOtelData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	}).CreateServer()
+
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var doc map[string]any
+	err := json.Unmarshal(w.Body.Bytes(), &doc)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3.1.0", doc["openapi"])
+	assert.Contains(t, doc["paths"], "/{cep}")
+
+	req, _ = http.NewRequest("GET", "/docs", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `data-spec-url="/openapi.json"`)
+	assert.NotContains(t, w.Body.String(), "https://")
+
+	// Os arquivos estáticos do Swagger UI são publicados pelo próprio serviço
+	server := httptest.NewServer(router)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/docs/assets/swagger-ui-bundle.js")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "javascript")
+}
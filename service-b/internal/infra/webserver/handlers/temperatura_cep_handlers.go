@@ -1,68 +1,225 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"net/url"
-	"strconv"
+	"net/netip"
+	"strings"
 	"time"
 
-	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/bootstrap"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/deadline"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/domain"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/openapi"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/realip"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/render"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/address"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/cepstore"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/weather"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
-// Struct que será utilizada para formar a resposta com o valor das temperaturas
-type ClimaCidade struct {
-	Cidade string  `json:"city"`
-	TempC  float64 `json:"temp_C"`
-	TempF  float64 `json:"temp_F"`
-	TempK  float64 `json:"temp_K"`
-}
+// Struct que será utilizada para formar a resposta com o valor das temperaturas.
+// O contrato é definido no pacote domain, comum aos serviços.
+type ClimaCidade = domain.ClimaCidade
+
+// Struct com o endereço do CEP consultado no ViaCEP
+type Endereco = domain.Endereco
 
 // Struct que será utilizada para receber o cep do path da requisição
-type DadosCep struct {
-	Cep string `json:"cep"`
-}
+type DadosCep = domain.DadosCep
 
 // Struct para receber os dados para o webserver. A função BuscaTemperaturaHandler está anexada nessa struct. Com isso, ela terá acesso aos dados.
 type Webserver struct {
 	OtelData *TemplateOtelData
+	weather  *weather.Resolver
+	cepCache *cepCache
+}
+
+// Endereços padrão das APIs externas
+const (
+	DefaultViaCEPURL     = "http://viacep.com.br/ws/"
+	DefaultWeatherAPIURL = "http://api.weatherapi.com/v1/"
+	DefaultWeatherAPIKey = "6ceb0269ea6049eda52220700241706"
+)
+
+// Provedores de clima consultados por padrão, separados por vírgula, e a estratégia de combinação
+// das leituras (weather.StrategyFirst, weather.StrategyMedian ou weather.StrategyAverage)
+const (
+	DefaultWeatherProviders = weather.ProviderWeatherAPI
+	DefaultWeatherStrategy  = weather.StrategyFirst
+)
+
+// Divisão padrão do prazo da requisição entre as APIs externas. O ViaCEP pode utilizar metade
+// do tempo restante e a WeatherAPI utiliza o restante. A reserva é mantida para o envio da resposta.
+const (
+	DefaultViaCEPBudgetShare = 0.5
+	DefaultDeadlineReserve   = 50 * time.Millisecond
+)
+
+// Modos de uso da base local de CEPs
+const (
+	// A base local é consultada primeiro e o ViaCEP só é utilizado para os CEPs que não estão nela
+	CepStoreModeFirst = "first"
+	// O ViaCEP é consultado primeiro e a base local só é utilizada quando ele falha
+	CepStoreModeFallback = "fallback"
+)
+
+// Interface da base local de CEPs
+type CepStore interface {
+	Lookup(ctx context.Context, cep string) (*cepstore.Record, error)
+}
+
+// Erros retornados pela busca da temperatura
+var (
+	ErrInvalidZipcode  = errors.New("invalid zipcode")
+	ErrZipcodeNotFound = errors.New("can not find zipcode")
+)
+
+// Erro retornado quando uma API externa (provedor de endereços ou de clima) falha
+type ExternalServiceError struct {
+	Service string
+	Err     error
+}
+
+func (e *ExternalServiceError) Error() string {
+	return e.Service + ": " + e.Err.Error()
+}
+
+func (e *ExternalServiceError) Unwrap() error {
+	return e.Err
 }
 
-// Função que cria um novo webserver com base nos dados informados.
-func NewServer(templateOtelData *TemplateOtelData) *Webserver {
+// Função que cria um novo webserver com base nos dados informados. Retorna erro quando os
+// provedores de clima ou a estratégia configurados são inválidos.
+func NewServer(templateOtelData *TemplateOtelData) (*Webserver, error) {
+	if templateOtelData.ViaCEPURL == "" {
+		templateOtelData.ViaCEPURL = DefaultViaCEPURL
+	}
+	if templateOtelData.WeatherAPIURL == "" {
+		templateOtelData.WeatherAPIURL = DefaultWeatherAPIURL
+	}
+	if templateOtelData.WeatherAPIKey == "" {
+		templateOtelData.WeatherAPIKey = DefaultWeatherAPIKey
+	}
+	if templateOtelData.HTTPClient == nil {
+		templateOtelData.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if templateOtelData.ViaCEPBudgetShare == 0 {
+		templateOtelData.ViaCEPBudgetShare = DefaultViaCEPBudgetShare
+	}
+	if templateOtelData.DeadlineReserve == 0 {
+		templateOtelData.DeadlineReserve = DefaultDeadlineReserve
+	}
+	if len(templateOtelData.WeatherProviders) == 0 {
+		templateOtelData.WeatherProviders = []string{DefaultWeatherProviders}
+	}
+	if templateOtelData.OpenMeteoGeocodingURL == "" {
+		templateOtelData.OpenMeteoGeocodingURL = weather.DefaultOpenMeteoGeocodingURL
+	}
+	if templateOtelData.OpenMeteoURL == "" {
+		templateOtelData.OpenMeteoURL = weather.DefaultOpenMeteoURL
+	}
+	resolver, err := newWeatherResolver(templateOtelData)
+	if err != nil {
+		return nil, err
+	}
 	return &Webserver{
 		OtelData: templateOtelData,
+		weather:  resolver,
+		cepCache: newCepCache(templateOtelData.CepCacheTTL, templateOtelData.CepCacheSize),
+	}, nil
+}
+
+// Cria a consulta aos provedores de clima configurados. Um provedor ou estratégia desconhecidos
+// são erros de configuração.
+func newWeatherResolver(data *TemplateOtelData) (*weather.Resolver, error) {
+	providers := make([]weather.Provider, 0, len(data.WeatherProviders))
+	for _, name := range data.WeatherProviders {
+		switch name {
+		case weather.ProviderWeatherAPI:
+			providers = append(providers, &weather.WeatherAPI{URL: data.WeatherAPIURL, Key: data.WeatherAPIKey, Client: data.HTTPClient})
+		case weather.ProviderOpenMeteo:
+			providers = append(providers, &weather.OpenMeteo{GeocodingURL: data.OpenMeteoGeocodingURL, URL: data.OpenMeteoURL, Client: data.HTTPClient})
+		default:
+			return nil, fmt.Errorf("%w: unknown provider %q", weather.ErrInvalidConfig, name)
+		}
 	}
+	return weather.NewResolver(providers, weather.Config{
+		Strategy:              data.WeatherStrategy,
+		DisagreementThreshold: data.WeatherDisagreementThreshold,
+		Tracer:                data.OTELTracer,
+	})
 }
 
-// Cria um novo server utilizando o chi e acrescentando alguns midlewares importantes.
+// Cria um novo server utilizando o router comum aos serviços, que já inclui os midlewares importantes.
+// O realip utiliza os proxies confiáveis configurados.
 func (we *Webserver) CreateServer() *chi.Mux {
-	router := chi.NewRouter()
-
-	router.Use(middleware.RequestID)
-	router.Use(middleware.RealIP)
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.Logger)
+	router, _ := bootstrap.NewRouterWith(bootstrap.DefaultMiddlewares, map[string]func(http.Handler) http.Handler{
+		"realip": realip.Middleware(we.OtelData.TrustedProxies),
+	})
 	router.Use(middleware.Timeout(60 * time.Second))
 	// promhttp. Usado para gerar as métricas automáticas do prometheus
 	router.Handle("/metrics", promhttp.Handler())
-	router.Get("/{cep}", we.BuscaTemperaturaHandler)
+	// Documentação da API no formato OpenAPI e a página do Swagger UI
+	router.Get("/openapi.json", openapi.Handler(we.OpenAPI()))
+	router.Get("/docs", openapi.DocsHandler("service-b", "/openapi.json"))
+	router.Get(openapi.AssetsPath+"*", openapi.AssetsHandler())
+	// A rota sem versão utiliza a v1, exceto quando a versão é informada no header X-API-Version
+	router.With(apiversion.Unversioned(we.OtelData.APIDeprecation)).Get("/{cep}", we.BuscaTemperaturaHandler)
+	for _, version := range apiversion.Versions() {
+		router.With(apiversion.Fixed(version)).Get("/"+version+"/{cep}", we.BuscaTemperaturaHandler)
+	}
 	return router
 }
 
-// Struct para armazenamento dos dados do OTEL.
+// Struct para armazenamento dos dados do OTEL e das APIs externas.
 type TemplateOtelData struct {
 	RequestNameOTEL string
 	OTELTracer      trace.Tracer
+	ViaCEPURL       string
+	WeatherAPIURL   string
+	WeatherAPIKey   string
+	HTTPClient      *http.Client
+	// Base local de CEPs (opcional) e o modo de uso: CepStoreModeFirst ou CepStoreModeFallback
+	CepStore     CepStore
+	CepStoreMode string
+	// Fração do prazo restante utilizada pelo ViaCEP e reserva de tempo para o envio da resposta.
+	// O prazo é recebido do service-a no header deadline.Header ou no próprio gRPC.
+	ViaCEPBudgetShare float64
+	DeadlineReserve   time.Duration
+	// Provedores de clima consultados em paralelo, a estratégia de combinação das leituras e a
+	// diferença em graus Celsius a partir da qual as leituras são consideradas divergentes
+	WeatherProviders             []string
+	WeatherStrategy              string
+	WeatherDisagreementThreshold float64
+	OpenMeteoGeocodingURL        string
+	OpenMeteoURL                 string
+	// Expiração e quantidade máxima de CEPs no cache. Tamanho zero desabilita o cache.
+	CepCacheTTL  time.Duration
+	CepCacheSize int
+	// Provedores de endereço por país (cep.CountryPortugal, cep.CountryArgentina...). Os CEPs
+	// brasileiros são consultados no ViaCEP e na base local, exceto quando há um provedor para o BR.
+	AddressProviders map[string]address.Provider
+	// Proxies confiáveis, dos quais os headers com o IP de origem são aceitos
+	TrustedProxies []netip.Prefix
+	// Data a partir da qual a rota sem versão é obsoleta. Quando zerada, é utilizada a apiversion.DefaultDeprecation.
+	APIDeprecation time.Time
 }
 
 type ViaCEP struct {
@@ -79,24 +236,6 @@ type ViaCEP struct {
 	Erro        bool   `json:"erro"`
 }
 
-type ResponseBody struct {
-	Location struct {
-		Name      string  `json:"name"`
-		Region    string  `json:"region"`
-		Country   string  `json:"country"`
-		Lat       float64 `json:"lat"`
-		Lon       float64 `json:"lon"`
-		TzID      string  `json:"tz_id"`
-		Localtime string  `json:"localtime"`
-	} `json:"location"`
-	Current struct {
-		LastUpdatedEpoch int     `json:"last_updated_epoch"`
-		LastUpdated      string  `json:"last_updated"`
-		TempC            float64 `json:"temp_c"`
-		TempF            float64 `json:"temp_f"`
-	} `json:"current"`
-}
-
 // Função que busca a temperatura
 func (h *Webserver) BuscaTemperaturaHandler(w http.ResponseWriter, r *http.Request) {
 
@@ -109,120 +248,193 @@ func (h *Webserver) BuscaTemperaturaHandler(w http.ResponseWriter, r *http.Reque
 	ctx, span := h.OtelData.OTELTracer.Start(ctx, "Início Processamento "+h.OtelData.RequestNameOTEL)
 	defer span.End()
 
-	//Coletando o CEP  partir do parâmetro da URL
+	//Coletando o CEP  partir do parâmetro da URL e o país do parâmetro country (padrão BR)
 	cepParam := chi.URLParam(r, "cep")
+	pais := r.URL.Query().Get("country")
+
+	// Formato da resposta não suportado: a consulta não é realizada
+	if details := render.Acceptable(r, apiversion.ClimaCidade(ctx, &ClimaCidade{})); details != nil {
+		span.SetStatus(codes.Error, details.Message)
+		problem.Write(ctx, w, r, details)
+		return
+	}
+
+	// Realizando a busca da temperatura
+	climaCidade, err := h.BuscaTemperatura(ctx, pais, cepParam)
+	if err != nil {
+		details := ToProblem(cepParam, err)
+		span.SetStatus(codes.Error, details.Message)
+		log.Printf("%s: %s: %s", details.Message, cepParam, err)
+		problem.Write(ctx, w, r, details)
+		return
+	}
+
+	// Retornando a resposta no contrato da versão da rota e no formato negociado no header Accept
+	_, spanEnviandoResposta := h.OtelData.OTELTracer.Start(ctx, "Enviando resposta")
+	render.Write(w, r, http.StatusOK, apiversion.ClimaCidade(ctx, climaCidade))
+	spanEnviandoResposta.End()
+
+}
+
+// Função que realiza a busca da temperatura do código postal do país (vazio é BR): valida o
+// formato, busca o endereço no provedor do país (ou no cache) e consulta a temperatura nos
+// provedores de clima. Cada etapa gera o seu próprio span. É utilizada tanto pelo handler HTTP
+// quanto pelo servidor gRPC.
+func (h *Webserver) BuscaTemperatura(ctx context.Context, pais, cep string) (*ClimaCidade, error) {
 
 	// Criação de um span de validação CEP
 	ctx, spanCEP := h.OtelData.OTELTracer.Start(ctx, "Validar Formatação CEP")
 
-	// Caso o cep não esteja em um formato válido, retora o código 422 e a mensagem de erro.
-	if !validarFormatoCEP(cepParam) {
+	// Caso o cep não esteja em um formato válido para o país, retorna o erro ErrInvalidZipcode.
+	// Os formatos 32450-000 e 32.450-000 são normalizados para 32450000.
+	postalCode, err := normalizaCEP(pais, cep)
+	if err != nil {
 		spanCEP.SetStatus(codes.Error, "invalid zipcode")
-		log.Printf("invalid zipcode: %s", cepParam)
-		msg := struct {
-			Message string `json:"message"`
-		}{
-			Message: "invalid zipcode",
-		}
-		w.WriteHeader(http.StatusUnprocessableEntity)
-		json.NewEncoder(w).Encode(msg)
-		return
-
+		spanCEP.End()
+		return nil, err
 	}
 	spanCEP.End()
 
-	ctx, spanBuscaCepViaCep := h.OtelData.OTELTracer.Start(ctx, "Busca CEP")
-	// Buscando os dados da cidade
-	dadosCep, err := BuscaCepViaCep(cepParam)
+	ctx, spanBuscaCepViaCep := h.OtelData.OTELTracer.Start(ctx, "Busca CEP", trace.WithAttributes(
+		attribute.String("cep.country", postalCode.Country),
+	))
+	// Buscando o endereço, primeiro no cache
+	endereco, cached := h.cepCache.get(postalCode)
+	spanBuscaCepViaCep.SetAttributes(attribute.Bool("cep.cache_hit", cached))
+	if !cached {
+		endereco, err = h.buscaEndereco(ctx, postalCode)
+	}
 	if err != nil {
-		spanBuscaCepViaCep.SetStatus(codes.Error, "can not find zipcode")
-		log.Printf("can not find zipcode: %s", cepParam)
-		msg := struct {
-			Message string `json:"message"`
-		}{
-			Message: "can not find zipcode",
-		}
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(msg)
-		return
+		spanBuscaCepViaCep.SetStatus(codes.Error, "Erro ao consultar o endereço do CEP")
+		spanBuscaCepViaCep.RecordError(err)
+		spanBuscaCepViaCep.End()
+		return nil, err
 	}
-
+	h.cepCache.set(postalCode, endereco)
 	spanBuscaCepViaCep.End()
 
 	ctx, spanConsultaTemperaturaCidade := h.OtelData.OTELTracer.Start(ctx, "Busca Temperatura")
 
 	// Coletando a temperatura da cidade
-	climaCidade, err := ConsultaTemperaturaCidade(dadosCep.Localidade)
+	climaCidade, err := h.ConsultaTemperaturaCidade(ctx, endereco)
 	if err != nil {
 		spanConsultaTemperaturaCidade.SetStatus(codes.Error, "Erro ao consultar os parâmetros para a localidade.")
-		log.Printf("Erro ao consultar os parâmetros para a localidade %s: %s", dadosCep.Localidade, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+		spanConsultaTemperaturaCidade.RecordError(err)
+		spanConsultaTemperaturaCidade.End()
+		log.Printf("Erro ao consultar os parâmetros para a localidade %s: %s", endereco.Cidade, err)
+
+		// A falha é atribuída ao provedor preferido
+		var weatherErr *weather.Error
+		if errors.As(err, &weatherErr) {
+			return nil, &ExternalServiceError{Service: weatherErr.Provider, Err: weatherErr.Err}
+		}
+		return nil, &ExternalServiceError{Service: "weather", Err: err}
 	}
-
 	spanConsultaTemperaturaCidade.End()
 
-	// Retornando a resposta
-	ctx, spanEnviandoResposta := h.OtelData.OTELTracer.Start(ctx, "Enviando resposta")
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	climaCidade.Endereco = endereco
+	return climaCidade, nil
+}
 
-	json.NewEncoder(w).Encode(climaCidade)
-	spanEnviandoResposta.End()
+// Função que busca o endereço do código postal no provedor do país. Sem provedor configurado
+// para o BR, o CEP é consultado no ViaCEP e na base local.
+func (h *Webserver) buscaEndereco(ctx context.Context, postalCode cep.PostalCode) (*Endereco, error) {
+	provider, ok := h.OtelData.AddressProviders[postalCode.Country]
+	if !ok {
+		if postalCode.Country != cep.CountryBrazil {
+			return nil, fmt.Errorf("%w: %w: no address provider for %s", ErrInvalidZipcode, cep.ErrUnsupportedCountry, postalCode.Country)
+		}
+		dadosCep, err := h.buscaCep(ctx, postalCode.Code)
+		if err != nil {
+			// O CEP não existe. Qualquer outro erro indica falha no ViaCEP.
+			if errors.Is(err, ErrZipcodeNotFound) {
+				return nil, err
+			}
+			return nil, &ExternalServiceError{Service: "viacep", Err: err}
+		}
+		return &Endereco{
+			Cep:        dadosCep.Cep,
+			Logradouro: dadosCep.Logradouro,
+			Bairro:     dadosCep.Bairro,
+			Cidade:     dadosCep.Localidade,
+			Uf:         dadosCep.Uf,
+			Pais:       cep.CountryBrazil,
+		}, nil
+	}
 
+	// O provedor pode utilizar parte do prazo restante, assim como o ViaCEP
+	ctx, cancel := deadline.Budget(ctx, h.OtelData.ViaCEPBudgetShare, h.OtelData.DeadlineReserve)
+	defer cancel()
+	endereco, err := provider.Lookup(ctx, postalCode)
+	switch {
+	case errors.Is(err, address.ErrNotFound):
+		return nil, ErrZipcodeNotFound
+	case err != nil:
+		return nil, &ExternalServiceError{Service: provider.Name(), Err: err}
+	}
+	return endereco, nil
 }
 
-// Função que vai realizar a consulta dos dados de temperatura da cidade
-func ConsultaTemperaturaCidade(cidade string) (*ClimaCidade, error) {
+// Função que converte o erro da busca no problema que deve ser respondido ao cliente
+func ToProblem(valor string, err error) *problem.Details {
+	var externalErr *ExternalServiceError
+	switch {
+	case errors.Is(err, cep.ErrUnsupportedCountry):
+		return problem.InvalidZipcode("").Detailf("the country must be one of %s", strings.Join(cep.Countries(), ", "))
+	case errors.Is(err, cep.ErrInvalidFormatPT):
+		return problem.InvalidZipcode(cep.ErrInvalidFormatPT.Error())
+	case errors.Is(err, cep.ErrInvalidFormatAR):
+		return problem.InvalidZipcode(cep.ErrInvalidFormatAR.Error())
+	case errors.Is(err, cep.ErrInvalidRange):
+		return problem.InvalidZipcode(cep.ErrInvalidRange.Error())
+	case errors.Is(err, ErrInvalidZipcode):
+		return problem.InvalidZipcode(cep.ErrInvalidFormat.Error())
+	case errors.Is(err, ErrZipcodeNotFound):
+		return problem.ZipcodeNotFound("").Detailf("zipcode %s does not exist", valor)
+	case errors.As(err, &externalErr):
+		return problem.FromUpstreamError(externalErr.Service, externalErr.Err)
+	}
+	return problem.Internal("")
+}
 
-	// Constante para acesso
-	const CONSTANTE = "6ceb0269ea6049eda52220700241706"
+// Função que vai realizar a consulta dos dados de temperatura da cidade do endereço nos provedores
+// de clima. Os provedores são consultados em paralelo, cada um com o seu próprio span.
+func (h *Webserver) ConsultaTemperaturaCidade(ctx context.Context, endereco *Endereco) (*ClimaCidade, error) {
 
-	// Realizando o encode para caracteres especiais e espaço
-	encodedCidade := url.QueryEscape(cidade)
+	// A consulta do clima é a última chamada externa e pode utilizar todo o prazo restante
+	ctx, cancel := deadline.Budget(ctx, 1, h.OtelData.DeadlineReserve)
+	defer cancel()
 
-	// Coletando os daodos no webservice
-	url := "http://api.weatherapi.com/v1/current.json?q=" + encodedCidade + "&lang=pt&country=Brazil&key=" + CONSTANTE
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	cidade := endereco.Cidade
+	result, err := h.weather.Current(ctx, weather.Location{Cidade: cidade, Uf: endereco.Uf, Pais: endereco.Pais})
 	if err != nil {
 		return nil, err
 	}
-
-	// Realizando o Unmarshal
-	var clima ClimaCidade
-	var data ResponseBody
-	err = json.Unmarshal(body, &data)
-	if err != nil {
-		log.Printf("Erro ao fazer Unmarshal do JSON weatherapi: %s", err)
-		return nil, err
+	if result.Consenso != nil && result.Consenso.Divergencia {
+		log.Printf("Provedores de clima divergentes para a localidade %s: diferença de %.1f °C", cidade, result.Consenso.Diferenca)
 	}
 
-	// Segregando os dados e calculando a temperatura em kelvin a partir da temperatura em Celsius
-	clima.Cidade = cidade
-	clima.TempC = data.Current.TempC
-	clima.TempF = data.Current.TempF
-	clima.TempK = data.Current.TempC + 273.0
-
-	// Enviando a resposta
-	return &clima, nil
+	// Calculando a temperatura em kelvin a partir da temperatura em Celsius
+	return &ClimaCidade{
+		Cidade:   cidade,
+		TempC:    result.TempC,
+		TempF:    result.TempF,
+		TempK:    domain.Kelvin(result.TempC),
+		Condicao: result.Condicao,
+		Consenso: result.Consenso,
+	}, nil
 
 }
 
 // Função que realiza a busca no site ViaCep o CEP informado por parâmetro.
-func BuscaCepViaCep(cep string) (*ViaCEP, error) {
+func (h *Webserver) BuscaCepViaCep(ctx context.Context, cep string) (*ViaCEP, error) {
 
-	url := "http://viacep.com.br/ws/" + cep + "/json/"
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	// O ViaCEP utiliza somente parte do prazo restante, deixando tempo para a WeatherAPI
+	ctx, cancel := deadline.Budget(ctx, h.OtelData.ViaCEPBudgetShare, h.OtelData.DeadlineReserve)
+	defer cancel()
+
+	url := h.OtelData.ViaCEPURL + cep + "/json/"
+	body, err := h.get(ctx, "viacep", url)
 	if err != nil {
 		return nil, err
 	}
@@ -235,20 +447,82 @@ func BuscaCepViaCep(cep string) (*ViaCEP, error) {
 
 	// Caso o cep não tenha sido encontrado, a variável "erro" recebe o valor true.
 	if dadosCep.Erro {
-		return nil, errors.New("can not find zipcode")
+		return nil, ErrZipcodeNotFound
 	}
 	return &dadosCep, nil
 
 }
 
-// Função que valida o formato CEP informado por parâmetro
-func validarFormatoCEP(parametro string) bool {
-	// Verifica se o parâmetro tem exatamente 8 caracteres
-	if len(parametro) != 8 {
-		return false
+// Função que busca o CEP no ViaCEP e na base local, de acordo com o modo configurado.
+// Sem a base local, apenas o ViaCEP é consultado.
+func (h *Webserver) buscaCep(ctx context.Context, cep string) (*ViaCEP, error) {
+	if h.OtelData.CepStore == nil {
+		return h.BuscaCepViaCep(ctx, cep)
+	}
+
+	if h.OtelData.CepStoreMode == CepStoreModeFirst {
+		if dadosCep, ok := h.buscaCepBaseLocal(ctx, cep); ok {
+			return dadosCep, nil
+		}
+		return h.BuscaCepViaCep(ctx, cep)
+	}
+
+	// Um CEP inexistente no ViaCEP não é procurado na base local
+	dadosCep, err := h.BuscaCepViaCep(ctx, cep)
+	if err == nil || errors.Is(err, ErrZipcodeNotFound) {
+		return dadosCep, err
+	}
+	if local, ok := h.buscaCepBaseLocal(ctx, cep); ok {
+		log.Printf("ViaCEP indisponível, cep %s encontrado na base local: %s", cep, err)
+		return local, nil
+	}
+	return nil, err
+}
+
+// Função que busca o CEP na base local e o converte no formato do ViaCEP
+func (h *Webserver) buscaCepBaseLocal(ctx context.Context, cep string) (*ViaCEP, bool) {
+	record, err := h.OtelData.CepStore.Lookup(ctx, cep)
+	if err != nil {
+		if !errors.Is(err, cepstore.ErrNotFound) {
+			log.Printf("Erro ao consultar o cep %s na base local: %s", cep, err)
+		}
+		return nil, false
 	}
+	return &ViaCEP{
+		Cep:        record.Cep[:5] + "-" + record.Cep[5:],
+		Logradouro: record.Logradouro,
+		Bairro:     record.Bairro,
+		Localidade: record.Localidade,
+		Uf:         record.Uf,
+		Ibge:       record.Ibge,
+	}, true
+}
 
-	// Verifica se todos os caracteres são números inteiros
-	_, err := strconv.Atoi(parametro)
-	return err == nil
+// Função que realiza um GET em uma API externa e retorna o body. Status diferente de 200
+// é convertido em um problem.UpstreamError.
+func (h *Webserver) get(ctx context.Context, service, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.OtelData.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &problem.UpstreamError{Service: service, StatusCode: resp.StatusCode}
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Função que valida e normaliza o código postal do país informado por parâmetro. O erro retornado
+// permite utilizar errors.Is tanto com ErrInvalidZipcode quanto com os erros do pacote cep.
+func normalizaCEP(pais, parametro string) (cep.PostalCode, error) {
+	c, err := cep.ParsePostalCode(pais, parametro)
+	if err != nil {
+		return cep.PostalCode{}, fmt.Errorf("%w: %w", ErrInvalidZipcode, err)
+	}
+	return c, nil
 }
@@ -1,15 +1,53 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/apiversion"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/cep"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/deadline"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/i18n"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/pb"
+	"github.com/wandermaia/desafio-temperatura-cep/pkg/problem"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/address"
+	"github.com/wandermaia/desafio-temperatura-cep/service-b/internal/infra/weather"
 	"go.opentelemetry.io/otel"
+	"google.golang.org/protobuf/proto"
 )
 
+// Server mock para simular o ViaCEP e a WeatherAPI
+func newUpstreamMock(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/32450000/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cep": "32450-000", "localidade": "Ibirité", "uf": "MG"}`))
+	})
+	mux.HandleFunc("/ws/99999999/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"erro": true}`))
+	})
+	mux.HandleFunc("/v1/current.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"location": {"name": "Ibirite"}, "current": {"temp_c": 28.5, "temp_f": 83.3}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Cria o webserver dos testes, interrompendo o teste quando a configuração é inválida
+func novoServer(t *testing.T, templateData *TemplateOtelData) *Webserver {
+	t.Helper()
+	server, err := NewServer(templateData)
+	require.NoError(t, err)
+	return server
+}
+
 // Cep Válido. Deve retornar Código 200 e o Response Body
 // no formato: { "city: "São Paulo", "temp_C": 28.5, "temp_F": 28.5, "temp_K": 28.5 }
 func TestBuscaTemperaturaHandlerOk(t *testing.T) {
@@ -17,13 +55,16 @@ func TestBuscaTemperaturaHandlerOk(t *testing.T) {
 	tracer := otel.Tracer("microservice-tracer-mock")
 
 	// Dados para a criação do servidor
+	upstream := newUpstreamMock(t)
 	templateData := &TemplateOtelData{
 		RequestNameOTEL: "microservice-tracer-mock",
 		OTELTracer:      tracer,
+		ViaCEPURL:       upstream.URL + "/ws/",
+		WeatherAPIURL:   upstream.URL + "/v1/",
 	}
 
 	// Criação do server
-	server := NewServer(templateData)
+	server := novoServer(t, templateData)
 	router := server.CreateServer()
 
 	//Realizando a chamada
@@ -47,13 +88,16 @@ func TestBuscaTemperaturaHandlerCepInvalido(t *testing.T) {
 	tracer := otel.Tracer("microservice-tracer-mock")
 
 	// Dados para a criação do servidor
+	upstream := newUpstreamMock(t)
 	templateData := &TemplateOtelData{
 		RequestNameOTEL: "microservice-tracer-mock",
 		OTELTracer:      tracer,
+		ViaCEPURL:       upstream.URL + "/ws/",
+		WeatherAPIURL:   upstream.URL + "/v1/",
 	}
 
 	// Criação do server
-	server := NewServer(templateData)
+	server := novoServer(t, templateData)
 	router := server.CreateServer()
 
 	//Realizando a chamada
@@ -77,17 +121,20 @@ func TestBuscaTemperaturaHandlerCepNaoEncontrado(t *testing.T) {
 	tracer := otel.Tracer("microservice-tracer-mock")
 
 	// Dados para a criação do servidor
+	upstream := newUpstreamMock(t)
 	templateData := &TemplateOtelData{
 		RequestNameOTEL: "microservice-tracer-mock",
 		OTELTracer:      tracer,
+		ViaCEPURL:       upstream.URL + "/ws/",
+		WeatherAPIURL:   upstream.URL + "/v1/",
 	}
 
 	// Criação do server
-	server := NewServer(templateData)
+	server := novoServer(t, templateData)
 	router := server.CreateServer()
 
 	//Realizando a chamada
-	req, _ := http.NewRequest("GET", "/00000000", nil)
+	req, _ := http.NewRequest("GET", "/99999999", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -99,3 +146,485 @@ func TestBuscaTemperaturaHandlerCepNaoEncontrado(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Empty(t, clima)
 }
+
+// Cep INVÁLIDO. A resposta deve seguir o formato application/problem+json
+// e manter o campo "message" para compatibilidade.
+func TestBuscaTemperaturaHandlerProblemJSON(t *testing.T) {
+	templateData := &TemplateOtelData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+	}
+	router := novoServer(t, templateData).CreateServer()
+
+	req, _ := http.NewRequest("GET", "/3245000a", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var details problem.Details
+	err := json.Unmarshal(w.Body.Bytes(), &details)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, problem.TypeInvalidZipcode, details.Type)
+	assert.Equal(t, http.StatusUnprocessableEntity, details.Status)
+	assert.Equal(t, "/3245000a", details.Instance)
+	assert.Equal(t, "invalid zipcode", details.Message)
+}
+
+// Os formatos 32450-000 e 32.450-000 devem ser normalizados. Sinais e CEPs fora
+// das faixas conhecidas devem retornar 422.
+func TestBuscaTemperaturaHandlerFormatosCEP(t *testing.T) {
+	upstream := newUpstreamMock(t)
+	router := novoServer(t, &TemplateOtelData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ViaCEPURL:       upstream.URL + "/ws/",
+		WeatherAPIURL:   upstream.URL + "/v1/",
+	}).CreateServer()
+
+	tests := []struct {
+		cep    string
+		status int
+		detail string
+	}{
+		{"32450-000", http.StatusOK, ""},
+		{"32.450-000", http.StatusOK, ""},
+		{"+3245000", http.StatusUnprocessableEntity, "the zipcode must contain exactly 8 digits"},
+		{"3245-0000", http.StatusUnprocessableEntity, "the zipcode must contain exactly 8 digits"},
+		{"00000000", http.StatusUnprocessableEntity, "the zipcode is not in a known brazilian range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cep, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+tt.cep, nil))
+			assert.Equal(t, tt.status, w.Code)
+
+			if tt.status != http.StatusOK {
+				var details problem.Details
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+				assert.Equal(t, tt.detail, details.Detail)
+			}
+		})
+	}
+}
+
+// Falhas nas APIs externas devem ser convertidas em 502, 503 ou 504
+func TestBuscaTemperaturaHandlerFalhaUpstream(t *testing.T) {
+	weatherComErro := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/current.json" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"cep": "32450-000", "localidade": "Ibirité", "uf": "MG"}`))
+	}))
+	defer weatherComErro.Close()
+
+	lento := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer lento.Close()
+
+	indisponivel := httptest.NewServer(http.NotFoundHandler())
+	indisponivel.Close()
+
+	testes := []struct {
+		nome      string
+		viaCEPURL string
+		weather   string
+		status    int
+		tipo      string
+	}{
+		{"weatherapi com erro", weatherComErro.URL + "/ws/", weatherComErro.URL + "/v1/", http.StatusBadGateway, problem.TypeBadGateway},
+		{"viacep indisponível", indisponivel.URL + "/ws/", indisponivel.URL + "/v1/", http.StatusServiceUnavailable, problem.TypeServiceUnavailable},
+		{"viacep lento", lento.URL + "/ws/", lento.URL + "/v1/", http.StatusGatewayTimeout, problem.TypeGatewayTimeout},
+	}
+
+	for _, tt := range testes {
+		t.Run(tt.nome, func(t *testing.T) {
+			templateData := &TemplateOtelData{
+				RequestNameOTEL: "microservice-tracer-mock",
+				OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+				ViaCEPURL:       tt.viaCEPURL,
+				WeatherAPIURL:   tt.weather,
+				HTTPClient:      &http.Client{Timeout: 50 * time.Millisecond},
+			}
+			router := novoServer(t, templateData).CreateServer()
+
+			req, _ := http.NewRequest("GET", "/32450000", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var details problem.Details
+			err := json.Unmarshal(w.Body.Bytes(), &details)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.tipo, details.Type)
+		})
+	}
+}
+
+// O prazo recebido do service-a é dividido entre o ViaCEP e a WeatherAPI. Com o ViaCEP lento,
+// a resposta 504 é enviada dentro do prazo, sem esperar o timeout do client HTTP.
+func TestBuscaTemperaturaHandlerPrazo(t *testing.T) {
+	lento := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer lento.Close()
+
+	router := novoServer(t, &TemplateOtelData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ViaCEPURL:       lento.URL + "/ws/",
+		WeatherAPIURL:   lento.URL + "/v1/",
+	}).CreateServer()
+
+	req, _ := http.NewRequest("GET", "/32450000", nil)
+	req.Header.Set(deadline.Header, "400")
+	w := httptest.NewRecorder()
+	start := time.Now()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	// Metade do prazo, descontada a reserva, é utilizada pelo ViaCEP
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+
+	// Sem o header, o prazo da requisição é o do servidor
+	upstream := newUpstreamMock(t)
+	router = novoServer(t, &TemplateOtelData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ViaCEPURL:       upstream.URL + "/ws/",
+		WeatherAPIURL:   upstream.URL + "/v1/",
+	}).CreateServer()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/32450000", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// Com vários provedores de clima, a resposta contém a mediana e o consenso entre eles. O CEP
+// encontrado fica no cache, então a segunda requisição não consulta o ViaCEP.
+func TestBuscaTemperaturaHandlerProvedores(t *testing.T) {
+	var consultasViaCEP atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/32450000/json/", func(w http.ResponseWriter, r *http.Request) {
+		consultasViaCEP.Add(1)
+		w.Write([]byte(`{"cep": "32450-000", "localidade": "Ibirité", "uf": "MG"}`))
+	})
+	mux.HandleFunc("/v1/current.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"current": {"temp_c": 28.5, "temp_f": 83.3, "condition": {"text": "Ensolarado"}}}`))
+	})
+	mux.HandleFunc("/geocoding/search", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"results": [{"latitude": -20.02, "longitude": -44.06}]}`))
+	})
+	mux.HandleFunc("/openmeteo/forecast", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"current": {"temperature_2m": 27.5}}`))
+	})
+	upstream := httptest.NewServer(mux)
+	defer upstream.Close()
+
+	router := novoServer(t, &TemplateOtelData{
+		RequestNameOTEL:       "microservice-tracer-mock",
+		OTELTracer:            otel.Tracer("microservice-tracer-mock"),
+		ViaCEPURL:             upstream.URL + "/ws/",
+		WeatherAPIURL:         upstream.URL + "/v1/",
+		OpenMeteoGeocodingURL: upstream.URL + "/geocoding/",
+		OpenMeteoURL:          upstream.URL + "/openmeteo/",
+		WeatherProviders:      []string{"weatherapi", "openmeteo"},
+		WeatherStrategy:       "median",
+		CepCacheSize:          10,
+	}).CreateServer()
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/32450000", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var clima ClimaCidade
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &clima))
+		assert.Equal(t, 28.0, clima.TempC)
+		assert.Equal(t, "Ensolarado", clima.Condicao)
+		assert.NotNil(t, clima.Consenso)
+		assert.Len(t, clima.Consenso.Provedores, 2)
+		assert.False(t, clima.Consenso.Divergencia)
+	}
+	assert.EqualValues(t, 1, consultasViaCEP.Load())
+
+	// Provedor ou estratégia desconhecidos são erros de configuração
+	_, err := NewServer(&TemplateOtelData{WeatherProviders: []string{"accuweather"}, WeatherStrategy: "first"})
+	assert.ErrorIs(t, err, weather.ErrInvalidConfig)
+	_, err = NewServer(&TemplateOtelData{WeatherProviders: []string{"weatherapi"}, WeatherStrategy: "mode"})
+	assert.ErrorIs(t, err, weather.ErrInvalidConfig)
+}
+
+// O cache ignora os itens expirados e remove o mais antigo quando está cheio
+func TestCepCache(t *testing.T) {
+	br := func(code string) cep.PostalCode { return cep.PostalCode{Country: cep.CountryBrazil, Code: code} }
+	cache := newCepCache(50*time.Millisecond, 2)
+	cache.set(br("32450000"), &Endereco{Cidade: "Ibirité"})
+	time.Sleep(time.Millisecond)
+	cache.set(br("01001000"), &Endereco{Cidade: "São Paulo"})
+	cache.set(br("30110001"), &Endereco{Cidade: "Belo Horizonte"})
+
+	_, ok := cache.get(br("32450000"))
+	assert.False(t, ok)
+	endereco, ok := cache.get(br("30110001"))
+	assert.True(t, ok)
+	assert.Equal(t, "Belo Horizonte", endereco.Cidade)
+
+	time.Sleep(60 * time.Millisecond)
+	_, ok = cache.get(br("30110001"))
+	assert.False(t, ok)
+
+	// Tamanho zero desabilita o cache
+	cache = newCepCache(time.Hour, 0)
+	cache.set(br("32450000"), &Endereco{})
+	_, ok = cache.get(br("32450000"))
+	assert.False(t, ok)
+}
+
+// Provedor de endereços local, utilizado no lugar do Zippopotam
+type enderecosLocais map[string]*Endereco
+
+func (e enderecosLocais) Name() string {
+	return "local"
+}
+
+func (e enderecosLocais) Lookup(ctx context.Context, code cep.PostalCode) (*Endereco, error) {
+	if endereco, ok := e[code.String()]; ok {
+		return endereco, nil
+	}
+	return nil, address.ErrNotFound
+}
+
+// O parâmetro country define o validador e o provedor de endereços. A temperatura é consultada
+// com a localidade e o país do endereço.
+func TestBuscaTemperaturaHandlerPaises(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/current.json", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Lisboa, Portugal", r.URL.Query().Get("q"))
+		w.Write([]byte(`{"current": {"temp_c": 18.5, "temp_f": 65.3}}`))
+	})
+	upstream := httptest.NewServer(mux)
+	defer upstream.Close()
+
+	router := novoServer(t, &TemplateOtelData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		WeatherAPIURL:   upstream.URL + "/v1/",
+		AddressProviders: map[string]address.Provider{
+			cep.CountryPortugal: enderecosLocais{"1000001": {Cep: "1000-001", Cidade: "Lisboa", Uf: "Lisboa", Pais: "PT"}},
+		},
+	}).CreateServer()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/1000-001?country=pt", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var clima ClimaCidade
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &clima))
+	assert.Equal(t, "Lisboa", clima.Cidade)
+	assert.Equal(t, "PT", clima.Endereco.Pais)
+
+	testes := []struct {
+		url    string
+		status int
+		detail string
+	}{
+		{"/4470-558?country=PT", http.StatusNotFound, "zipcode 4470-558 does not exist"},
+		{"/32450-000?country=PT", http.StatusUnprocessableEntity, cep.ErrInvalidFormatPT.Error()},
+		{"/C1425ABC?country=AR", http.StatusUnprocessableEntity, "the country must be one of AR, BR, PT"},
+		{"/10001?country=US", http.StatusUnprocessableEntity, "the country must be one of AR, BR, PT"},
+	}
+	for _, tt := range testes {
+		t.Run(tt.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			assert.Equal(t, tt.status, w.Code)
+			var details problem.Details
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+			assert.Equal(t, tt.detail, details.Detail)
+		})
+	}
+}
+
+// O idioma negociado no Accept-Language traduz as mensagens de erro e é repassado à WeatherAPI,
+// que retorna a condição no mesmo idioma
+func TestBuscaTemperaturaHandlerIdioma(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/32450000/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cep": "32450-000", "localidade": "Ibirité", "uf": "MG"}`))
+	})
+	mux.HandleFunc("/ws/99999999/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"erro": true}`))
+	})
+	mux.HandleFunc("/v1/current.json", func(w http.ResponseWriter, r *http.Request) {
+		condicao := map[string]string{"": "Sunny", "pt": "Sol", "es": "Soleado"}[r.URL.Query().Get("lang")]
+		w.Write([]byte(`{"current": {"temp_c": 28.5, "temp_f": 83.3, "condition": {"text": "` + condicao + `"}}}`))
+	})
+	upstream := httptest.NewServer(mux)
+	defer upstream.Close()
+
+	router := novoServer(t, &TemplateOtelData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ViaCEPURL:       upstream.URL + "/ws/",
+		WeatherAPIURL:   upstream.URL + "/v1/",
+	}).CreateServer()
+
+	testes := []struct {
+		idioma   string
+		condicao string
+		titulo   string
+		detail   string
+	}{
+		{"", "Sunny", "can not find zipcode", "zipcode 99999999 does not exist"},
+		{"pt-BR,pt;q=0.9", "Sol", "CEP não encontrado", "o CEP 99999999 não existe"},
+		{"es-AR", "Soleado", "código postal no encontrado", "el código postal 99999999 no existe"},
+	}
+	for _, tt := range testes {
+		t.Run(tt.idioma, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/32450000", nil)
+			req.Header.Set(i18n.Header, tt.idioma)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			var clima ClimaCidade
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &clima))
+			assert.Equal(t, tt.condicao, clima.Condicao)
+
+			req = httptest.NewRequest(http.MethodGet, "/99999999", nil)
+			req.Header.Set(i18n.Header, tt.idioma)
+			w = httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			var details problem.Details
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+			assert.Equal(t, tt.titulo, details.Title)
+			assert.Equal(t, tt.titulo, details.Message)
+			assert.Equal(t, tt.detail, details.Detail)
+		})
+	}
+}
+
+// Teste da resposta nos formatos negociados no header Accept. Sem formato aceitável, a resposta
+// é 406 sem consultar os serviços externos.
+func TestBuscaTemperaturaHandlerFormatosResposta(t *testing.T) {
+	var consultas atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/32450000/json/", func(w http.ResponseWriter, r *http.Request) {
+		consultas.Add(1)
+		w.Write([]byte(`{"cep": "32450-000", "localidade": "Ibirité", "uf": "MG"}`))
+	})
+	mux.HandleFunc("/v1/current.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"current": {"temp_c": 28.5, "temp_f": 83.3, "condition": {"text": "Sunny"}}}`))
+	})
+	upstream := httptest.NewServer(mux)
+	defer upstream.Close()
+
+	router := novoServer(t, &TemplateOtelData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ViaCEPURL:       upstream.URL + "/ws/",
+		WeatherAPIURL:   upstream.URL + "/v1/",
+	}).CreateServer()
+	busca := func(accept, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := busca("application/xml", "/32450000")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<city>Ibirité</city><temp_C>28.5</temp_C>")
+
+	w = busca("text/csv", "/32450000")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "cep,country,city,state,temp_C,temp_F,temp_K,condition\n32450-000,BR,Ibirité,MG,28.5,83.3,301.5,Sunny\n", w.Body.String())
+
+	w = busca("application/x-protobuf", "/32450000")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+	var clima pb.ClimaCidade
+	assert.NoError(t, proto.Unmarshal(w.Body.Bytes(), &clima))
+	assert.Equal(t, "Ibirité", clima.City)
+	assert.Equal(t, "MG", clima.Address.State)
+
+	// O parâmetro format tem prioridade sobre o header Accept
+	w = busca("application/xml", "/32450000?format=json")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, int32(4), consultas.Load())
+
+	w = busca("text/html", "/32450000")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	var details problem.Details
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &details))
+	assert.Equal(t, "the response is available as application/json, application/xml, text/csv, application/x-protobuf", details.Detail)
+	assert.Equal(t, int32(4), consultas.Load())
+}
+
+// Teste das rotas versionadas e da seleção da versão no header X-API-Version
+func TestBuscaTemperaturaHandlerVersoes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws/32450000/json/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"cep": "32450-000", "localidade": "Ibirité", "uf": "MG"}`))
+	})
+	mux.HandleFunc("/v1/current.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"current": {"temp_c": 28.5, "temp_f": 83.3, "condition": {"text": "Sunny"}}}`))
+	})
+	upstream := httptest.NewServer(mux)
+	defer upstream.Close()
+
+	router := novoServer(t, &TemplateOtelData{
+		RequestNameOTEL: "microservice-tracer-mock",
+		OTELTracer:      otel.Tracer("microservice-tracer-mock"),
+		ViaCEPURL:       upstream.URL + "/ws/",
+		WeatherAPIURL:   upstream.URL + "/v1/",
+	}).CreateServer()
+	busca := func(url string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	v1JSON := `{"city":"Ibirité","temp_C":28.5,"temp_F":83.3,"temp_K":301.5,"address":{"cep":"32450-000","city":"Ibirité","state":"MG","country":"BR"},"condition":"Sunny"}`
+	v2JSON := `{"location":{"postal_code":"32450-000","country":"BR","state":"MG","city":"Ibirité"},"temperature":{"celsius":28.5,"fahrenheit":83.3,"kelvin":301.5},"conditions":{"description":"Sunny"}}`
+
+	w := busca("/v1/32450000")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, v1JSON, w.Body.String())
+	assert.Equal(t, apiversion.V1, w.Header().Get(apiversion.Header))
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	w = busca("/v2/32450000")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, v2JSON, w.Body.String())
+	assert.Equal(t, apiversion.V2, w.Header().Get(apiversion.Header))
+
+	// Rota sem versão: v1 obsoleta ou a versão do header
+	w = busca("/32450000")
+	assert.JSONEq(t, v1JSON, w.Body.String())
+	assert.NotEmpty(t, w.Header().Get("Deprecation"))
+	assert.Equal(t, `</v1/32450000>; rel="successor-version"`, w.Header().Get("Link"))
+
+	w = busca("/32450000", apiversion.Header, "2")
+	assert.JSONEq(t, v2JSON, w.Body.String())
+	assert.Empty(t, w.Header().Get("Deprecation"))
+
+	w = busca("/32450000", apiversion.Header, "v9")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// A v2 não possui representação em protobuf
+	w = busca("/v2/32450000", "Accept", "application/x-protobuf")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	w = busca("/v2/32450000?format=csv")
+	assert.Equal(t, "postal_code,country,state,city,celsius,fahrenheit,kelvin,conditions\n32450-000,BR,MG,Ibirité,28.5,83.3,301.5,Sunny\n", w.Body.String())
+}